@@ -16,6 +16,7 @@ import (
 	"video_conference/internal/repository"
 	"video_conference/internal/service"
 	"video_conference/pkg/logger"
+	"video_conference/pkg/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -62,8 +63,15 @@ func main() {
 	// Инициализация репозиториев
 	repos := repository.NewRepositories(dbPool, rdb, appLogger)
 
+	// Хранилище файлов (вложения чата)
+	fileStorage, err := newFileStorage(cfg)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize file storage", "error", err)
+	}
+	appLogger.Info("File storage initialized", "backend", cfg.Storage.Backend)
+
 	// Инициализация сервисов
-	services := service.NewServices(repos, cfg, appLogger)
+	services := service.NewServices(repos, fileStorage, cfg, appLogger)
 
 	// Инициализация middleware
 	authMiddleware := middleware.NewAuthMiddleware(services.Auth, appLogger)
@@ -89,6 +97,9 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Автозавершение комнат по расписанию и после простоя
+	services.RoomSweeper.Start()
+
 	// Graceful shutdown
 	go func() {
 		appLogger.Info("Starting server", "port", cfg.Server.Port)
@@ -111,6 +122,9 @@ func main() {
 		appLogger.Fatal("Server forced to shutdown", "error", err)
 	}
 
+	services.RoomSweeper.Stop()
+	services.Breakout.Stop()
+
 	appLogger.Info("Server exited")
 }
 
@@ -153,6 +167,18 @@ func setupRouter(
 		// Анонимные endpoints отключены - теперь требуется авторизация
 		// Все операции с комнатами через protected endpoints
 
+		// SSE уведомление гостя о решении по заявке в waiting room (токен в заголовке или в query-параметре token)
+		v1.GET("/rooms/:id/waiting-room/:entryId/events", externalAuthMiddleware.RequireQueryAuth(), handlers.WaitingRoom.Events)
+
+		// Webhook-и LiveKit (подпись проверяется в handler-е по API ключу)
+		v1.POST("/webhooks/livekit", handlers.LiveKitWebhook.Receive)
+
+		// Подписка на календарь (доступ по секретному токену в ссылке, без авторизации)
+		v1.GET("/calendar/feeds/:token", handlers.Calendar.Feed)
+
+		// Скачивание файлов локального хранилища (доступ по подписанной ссылке, без авторизации)
+		v1.GET("/files/*key", handlers.ChatAttachment.Download)
+
 		// Защищенные endpoints (через внешний Auth-сервис)
 		// Используем ExternalAuthMiddleware для JWT токенов от NextUp Auth-сервиса
 		protected := v1.Group("")
@@ -166,16 +192,96 @@ func setupRouter(
 				rooms.GET("/:id", handlers.Room.GetByID)
 				rooms.PUT("/:id", handlers.Room.Update)
 				rooms.DELETE("/:id", handlers.Room.Delete)
+				rooms.POST("/:id/cancel", handlers.Room.Cancel)
 				rooms.POST("/:id/join", handlers.Room.Join)
 				rooms.POST("/:id/leave", handlers.Room.Leave)
 				rooms.POST("/:id/invite", handlers.Room.CreateInvite)
+				rooms.GET("/:id/invites", handlers.Invite.List)
+				rooms.DELETE("/:id/invites/:inviteId", handlers.Invite.Revoke)
 				rooms.GET("/:id/participants", handlers.Room.GetParticipants)
+				rooms.POST("/:id/participants/:participantId/co-host", handlers.Room.PromoteCoHost)
+				rooms.DELETE("/:id/participants/:participantId/co-host", handlers.Room.DemoteCoHost)
+				rooms.GET("/:id/calendar.ics", handlers.Calendar.RoomICS)
+			}
+
+			// Повторяющиеся встречи
+			series := protected.Group("/series")
+			{
+				series.POST("", handlers.RoomSeries.Create)
+				series.GET("/upcoming", handlers.RoomSeries.ListUpcoming)
+				series.GET("/:id", handlers.RoomSeries.GetByID)
+				series.DELETE("/:id", handlers.RoomSeries.Cancel)
+			}
+
+			// Ссылка на подписку календаря
+			calendar := protected.Group("/calendar")
+			{
+				calendar.POST("/feed-token", handlers.Calendar.IssueFeedToken)
+				calendar.DELETE("/feed-token", handlers.Calendar.RevokeFeedToken)
+			}
+
+			// Вход по приглашению
+			invites := protected.Group("/invites")
+			{
+				invites.POST("/:token/redeem", handlers.Invite.Redeem)
 			}
 
 			// Медиа токены для авторизованных
 			media := protected.Group("/rooms/:id/media")
 			{
 				media.POST("/token", handlers.Media.GetToken)
+				media.POST("/breakout-token", handlers.Breakout.GetToken)
+			}
+
+			// Сессионные залы (хост и co-host; список - все участники)
+			breakouts := protected.Group("/rooms/:id/breakouts")
+			{
+				breakouts.GET("", handlers.Breakout.List)
+				breakouts.POST("", handlers.Breakout.Create)
+				breakouts.PUT("/assignments", handlers.Breakout.Assign)
+				breakouts.POST("/assignments/random", handlers.Breakout.AssignRandom)
+				breakouts.POST("/open", handlers.Breakout.Open)
+				breakouts.POST("/close", handlers.Breakout.Close)
+				breakouts.POST("/recall", handlers.Breakout.Recall)
+				breakouts.POST("/broadcast", handlers.Breakout.Broadcast)
+			}
+
+			// Опросы (управление - хост и co-host, голосование - участники)
+			polls := protected.Group("/rooms/:id/polls")
+			{
+				polls.GET("", handlers.Poll.List)
+				polls.POST("", handlers.Poll.Create)
+				polls.GET("/export", handlers.Poll.Export)
+				polls.GET("/:pollId", handlers.Poll.Get)
+				polls.DELETE("/:pollId", handlers.Poll.Delete)
+				polls.POST("/:pollId/open", handlers.Poll.Open)
+				polls.POST("/:pollId/close", handlers.Poll.Close)
+				polls.POST("/:pollId/votes", handlers.Poll.Vote)
+			}
+
+			// Очередь поднятых рук (опустить чужую руку и вызвать выступающего - хост и co-host)
+			hands := protected.Group("/rooms/:id/hands")
+			{
+				hands.GET("", handlers.HandRaise.Queue)
+				hands.POST("", handlers.HandRaise.Raise)
+				hands.DELETE("", handlers.HandRaise.Lower)
+				hands.DELETE("/:participantId", handlers.HandRaise.LowerParticipant)
+				hands.POST("/lower-all", handlers.HandRaise.LowerAll)
+				hands.POST("/next", handlers.HandRaise.NextSpeaker)
+			}
+
+			// Реакции участников (статистика - в /rooms/:id/stats/reactions)
+			reactions := protected.Group("/rooms/:id/reactions")
+			{
+				reactions.POST("", handlers.Reaction.Send)
+			}
+
+			// Входящие трансляции внешних ведущих (хост)
+			ingress := protected.Group("/rooms/:id/ingress")
+			{
+				ingress.GET("", handlers.Media.ListIngresses)
+				ingress.POST("", handlers.Media.CreateIngress)
+				ingress.DELETE("/:ingressId", handlers.Media.RevokeIngress)
 			}
 
 			// Waiting room
@@ -184,6 +290,40 @@ func setupRouter(
 				waitingRoom.GET("", handlers.WaitingRoom.List)
 				waitingRoom.POST("/:entryId/approve", handlers.WaitingRoom.Approve)
 				waitingRoom.POST("/:entryId/reject", handlers.WaitingRoom.Reject)
+				waitingRoom.GET("/:entryId", handlers.WaitingRoom.GetEntry)
+			}
+
+			// Модерация (хост и co-host)
+			moderation := protected.Group("/rooms/:id")
+			{
+				moderation.POST("/lock", handlers.Moderation.Lock)
+				moderation.POST("/unlock", handlers.Moderation.Unlock)
+				moderation.POST("/participants/:participantId/kick", handlers.Moderation.Kick)
+				moderation.POST("/participants/:participantId/mute", handlers.Moderation.Mute)
+			}
+
+			// Запись встреч (хост)
+			recordings := protected.Group("/rooms/:id/recordings")
+			{
+				recordings.GET("", handlers.Recording.List)
+				recordings.POST("", handlers.Recording.Start)
+				recordings.POST("/:recordingId/stop", handlers.Recording.Stop)
+			}
+
+			// Трансляции RTMP/HLS (хост)
+			streams := protected.Group("/rooms/:id/streams")
+			{
+				streams.GET("", handlers.LiveStream.List)
+				streams.POST("", handlers.LiveStream.Start)
+				streams.POST("/:streamId/stop", handlers.LiveStream.Stop)
+			}
+
+			// Вход по телефону (хост и co-host)
+			dialIn := protected.Group("/rooms/:id/dial-in")
+			{
+				dialIn.GET("", handlers.DialIn.Get)
+				dialIn.POST("", handlers.DialIn.Enable)
+				dialIn.DELETE("", handlers.DialIn.Disable)
 			}
 
 			// Чат
@@ -191,7 +331,18 @@ func setupRouter(
 			{
 				chat.GET("/messages", handlers.Chat.GetMessages)
 				chat.POST("/messages", handlers.Chat.SendMessage)
+				chat.PATCH("/messages/:messageId", handlers.Chat.EditMessage)
+				chat.GET("/messages/:messageId/history", handlers.Chat.GetHistory)
+				chat.GET("/messages/:messageId/thread", handlers.Chat.GetThread)
+				chat.GET("/mentions", handlers.Chat.GetMentions)
 				chat.DELETE("/messages/:messageId", handlers.Chat.DeleteMessage)
+				chat.GET("/controls", handlers.Chat.GetControls)
+				chat.GET("/export", handlers.Chat.ExportTranscript)
+				chat.PATCH("/controls", handlers.Chat.UpdateControls)
+				chat.POST("/participants/:participantId/disable", handlers.Chat.DisableParticipant)
+				chat.POST("/participants/:participantId/enable", handlers.Chat.EnableParticipant)
+				chat.POST("/attachments", handlers.ChatAttachment.Upload)
+				chat.GET("/attachments/:attachmentId", handlers.ChatAttachment.Get)
 			}
 
 			// Статистика
@@ -199,12 +350,13 @@ func setupRouter(
 			{
 				stats.GET("", handlers.Stats.GetRoomStats)
 				stats.GET("/participants/:participantId", handlers.Stats.GetParticipantStats)
+				stats.GET("/reactions", handlers.Reaction.Stats)
 			}
 		}
 	}
 
-	// WebSocket endpoint для чата
-	router.GET("/ws/chat/:id", handlers.WebSocket.HandleChat)
+	// WebSocket endpoint для чата (токен в заголовке или в query-параметре token)
+	router.GET("/ws/chat/:id", externalAuthMiddleware.RequireQueryAuth(), handlers.WebSocket.HandleChat)
 
 	// Screen share endpoints (публичные для демонстрации)
 	screenShare := router.Group("/screen-share")
@@ -218,3 +370,19 @@ func setupRouter(
 
 	return router
 }
+
+// newFileStorage создает хранилище файлов по STORAGE_BACKEND. Ссылки локального хранилища
+// ведут на /api/v1/files по внешнему адресу приложения.
+func newFileStorage(cfg *config.Config) (storage.Storage, error) {
+	if cfg.Storage.Backend == "s3" {
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.Storage.S3Endpoint,
+			Region:    cfg.Storage.S3Region,
+			Bucket:    cfg.Storage.S3Bucket,
+			AccessKey: cfg.Storage.S3AccessKey,
+			SecretKey: cfg.Storage.S3SecretKey,
+			PathStyle: cfg.Storage.S3PathStyle,
+		})
+	}
+	return storage.NewLocal(cfg.Storage.LocalDir, cfg.Server.PublicURL+"/api/v1/files", cfg.Storage.URLSecret)
+}
@@ -65,6 +65,28 @@ CREATE INDEX idx_user_sessions_user_id ON user_sessions(user_id);
 CREATE INDEX idx_user_sessions_expires_at ON user_sessions(expires_at);
 CREATE INDEX idx_user_sessions_token_hash ON user_sessions(refresh_token_hash);
 
+-- ============================================
+-- ТАБЛИЦА СЕРИЙ ПОВТОРЯЮЩИХСЯ ВСТРЕЧ
+-- ============================================
+CREATE TABLE IF NOT EXISTS room_series (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    host_user_id UUID NOT NULL REFERENCES users(id),
+    title TEXT NOT NULL,
+    description TEXT,
+    max_participants INTEGER NOT NULL DEFAULT 10 CHECK (max_participants > 0 AND max_participants <= 500),
+    waiting_room_enabled BOOLEAN NOT NULL DEFAULT true,
+    settings JSONB NOT NULL DEFAULT '{}'::jsonb,
+    rrule TEXT NOT NULL,
+    start_at TIMESTAMPTZ NOT NULL,
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0 AND duration_minutes <= 1440),
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    cancelled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_room_series_host ON room_series(host_user_id);
+
 -- ============================================
 -- ТАБЛИЦА КОМНАТ
 -- ============================================
@@ -84,14 +106,34 @@ CREATE TABLE IF NOT EXISTS rooms (
     is_locked BOOLEAN NOT NULL DEFAULT false,
     password_hash TEXT,
     settings JSONB NOT NULL DEFAULT '{}'::jsonb,
+    series_id UUID REFERENCES room_series(id) ON DELETE SET NULL,
+    occurrence_start_at TIMESTAMPTZ,
+    -- Основная комната для сессионного зала (breakout room)
+    parent_room_id UUID REFERENCES rooms(id) ON DELETE CASCADE,
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
 CREATE INDEX idx_rooms_host ON rooms(host_user_id);
+CREATE UNIQUE INDEX idx_rooms_series_occurrence ON rooms(series_id, occurrence_start_at);
 CREATE INDEX idx_rooms_status ON rooms(status);
 CREATE INDEX idx_rooms_scheduled_start ON rooms(scheduled_start_at);
 CREATE INDEX idx_rooms_livekit_name ON rooms(livekit_room_name);
+CREATE INDEX idx_rooms_parent ON rooms(parent_room_id) WHERE parent_room_id IS NOT NULL;
+
+-- ============================================
+-- ТАБЛИЦА РАСПРЕДЕЛЕНИЯ ПО СЕССИОННЫМ ЗАЛАМ
+-- ============================================
+-- Не больше одного зала на пользователя в рамках основной комнаты
+CREATE TABLE IF NOT EXISTS breakout_assignments (
+    parent_room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    breakout_room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (parent_room_id, user_id)
+);
+
+CREATE INDEX idx_breakout_assignments_room ON breakout_assignments(breakout_room_id);
 
 -- ============================================
 -- ТАБЛИЦА ПРИГЛАШЕНИЙ В КОМНАТЫ
@@ -105,6 +147,7 @@ CREATE TABLE IF NOT EXISTS room_invites (
     expires_at TIMESTAMPTZ,
     max_uses INTEGER,
     used_count INTEGER NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMPTZ,
     created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
@@ -112,6 +155,15 @@ CREATE INDEX idx_room_invites_room_id ON room_invites(room_id);
 CREATE INDEX idx_room_invites_token ON room_invites(link_token);
 CREATE INDEX idx_room_invites_expires ON room_invites(expires_at);
 
+-- ============================================
+-- ТАБЛИЦА ТОКЕНОВ ПОДПИСКИ НА КАЛЕНДАРЬ
+-- ============================================
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
 -- ============================================
 -- ТАБЛИЦА УЧАСТНИКОВ КОМНАТ
 -- ============================================
@@ -119,7 +171,7 @@ CREATE TABLE IF NOT EXISTS room_participants (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
     user_id UUID REFERENCES users(id),
-    role TEXT NOT NULL CHECK (role IN ('host','co_host','participant')),
+    role TEXT NOT NULL CHECK (role IN ('host','co_host','participant','ingest')),
     display_name TEXT NOT NULL,
     livekit_sid TEXT UNIQUE,
     joined_at TIMESTAMPTZ NOT NULL,
@@ -128,7 +180,10 @@ CREATE TABLE IF NOT EXISTS room_participants (
     is_kicked BOOLEAN NOT NULL DEFAULT false,
     initial_muted BOOLEAN NOT NULL DEFAULT false,
     client_ip INET,
-    user_agent TEXT
+    user_agent TEXT,
+    -- web, phone (звонок через SIP) или ingress (внешний ведущий); для звонков - маскированный номер
+    source TEXT NOT NULL DEFAULT 'web' CHECK (source IN ('web','phone','ingress')),
+    caller_id TEXT
 );
 
 CREATE INDEX idx_rp_room_id_joined_at ON room_participants(room_id, joined_at);
@@ -136,6 +191,18 @@ CREATE INDEX idx_rp_user_id ON room_participants(user_id);
 CREATE INDEX idx_rp_livekit_sid ON room_participants(livekit_sid);
 CREATE INDEX idx_rp_left_at ON room_participants(left_at) WHERE left_at IS NULL;
 
+-- ============================================
+-- ТАБЛИЦА ПОДКЛЮЧЕНИЯ ПО ТЕЛЕФОНУ (SIP dial-in)
+-- ============================================
+-- PIN комнаты для входа по телефону и правило маршрутизации звонков в LiveKit SIP
+CREATE TABLE IF NOT EXISTS room_dial_ins (
+    room_id UUID PRIMARY KEY REFERENCES rooms(id) ON DELETE CASCADE,
+    phone_number TEXT NOT NULL,
+    pin TEXT NOT NULL UNIQUE,
+    sip_dispatch_rule_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
 -- ============================================
 -- ТАБЛИЦА WAITING ROOM (комната ожидания)
 -- ============================================
@@ -167,12 +234,192 @@ CREATE TABLE IF NOT EXISTS chat_messages (
     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     edited_at TIMESTAMPTZ,
     deleted_at TIMESTAMPTZ,
-    deleted_by_participant_id UUID REFERENCES room_participants(id)
+    deleted_by_participant_id UUID REFERENCES room_participants(id),
+    -- Ответ на сообщение и первое сообщение ветки (ветки одноуровневые)
+    reply_to_message_id BIGINT REFERENCES chat_messages(id) ON DELETE SET NULL,
+    thread_root_id BIGINT REFERENCES chat_messages(id) ON DELETE CASCADE,
+    -- Адресат: room - вся комната, participant - один участник, hosts - только хост и co-host
+    recipient_type TEXT NOT NULL DEFAULT 'room' CHECK (recipient_type IN ('room','participant','hosts')),
+    recipient_participant_id UUID REFERENCES room_participants(id)
 );
 
 CREATE INDEX idx_chat_room_created_at ON chat_messages(room_id, created_at DESC);
 CREATE INDEX idx_chat_sender ON chat_messages(sender_participant_id, created_at DESC);
 CREATE INDEX idx_chat_deleted ON chat_messages(deleted_at) WHERE deleted_at IS NULL;
+CREATE INDEX idx_chat_thread ON chat_messages(thread_root_id, created_at) WHERE thread_root_id IS NOT NULL;
+CREATE INDEX idx_chat_recipient ON chat_messages(recipient_participant_id, created_at DESC) WHERE recipient_participant_id IS NOT NULL;
+
+-- Упомянутые в сообщении участники (@имя разрешается в room_participants.id)
+CREATE TABLE IF NOT EXISTS chat_message_mentions (
+    message_id BIGINT NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    participant_id UUID NOT NULL REFERENCES room_participants(id) ON DELETE CASCADE,
+    PRIMARY KEY (message_id, participant_id)
+);
+
+CREATE INDEX idx_chat_mentions_participant ON chat_message_mentions(participant_id, message_id DESC);
+
+-- ============================================
+-- ТАБЛИЦА ИСТОРИИ ПРАВОК СООБЩЕНИЙ ЧАТА
+-- ============================================
+-- При каждой правке прежний текст сообщения сохраняется отдельной записью.
+-- created_at - когда эта версия была написана, replaced_at - когда ее заменила правка.
+CREATE TABLE IF NOT EXISTS chat_message_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    message_id BIGINT NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    replaced_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_chat_revisions_message ON chat_message_revisions(message_id, id);
+
+-- ============================================
+-- ТАБЛИЦА ВЛОЖЕНИЙ ЧАТА
+-- ============================================
+-- Файл загружается до отправки сообщения (message_id NULL) и привязывается к нему при отправке.
+-- Сами файлы лежат во внешнем хранилище (локальный каталог или S3) по storage_key.
+CREATE TABLE IF NOT EXISTS chat_attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    message_id BIGINT REFERENCES chat_messages(id) ON DELETE SET NULL,
+    uploader_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL,
+    thumbnail_key TEXT,
+    width INT,
+    height INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    -- Когда повторить очистку, если прошлая попытка удалить файлы не удалась
+    cleanup_retry_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_chat_attachments_message ON chat_attachments(message_id) WHERE message_id IS NOT NULL;
+CREATE INDEX idx_chat_attachments_room ON chat_attachments(room_id);
+CREATE INDEX idx_chat_attachments_pending ON chat_attachments(created_at) WHERE message_id IS NULL;
+
+-- ============================================
+-- ТАБЛИЦА ЗАПИСЕЙ ВСТРЕЧ (LiveKit Egress)
+-- ============================================
+-- Запись всей комнаты (room_composite) или отдельной дорожки (track).
+-- Статус обновляется по webhook-ам egress_started/egress_updated/egress_ended.
+-- egress_id пуст, пока Egress запускается: строка создается до вызова API.
+CREATE TABLE IF NOT EXISTS recordings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    egress_id TEXT UNIQUE,
+    kind TEXT NOT NULL CHECK (kind IN ('room_composite','track')),
+    track_sid TEXT,
+    status TEXT NOT NULL DEFAULT 'starting' CHECK (status IN ('starting','active','ending','completed','failed','aborted')),
+    file_location TEXT,
+    duration_seconds INTEGER,
+    size_bytes BIGINT,
+    error TEXT,
+    started_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ,
+    ended_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_recordings_room ON recordings(room_id, created_at DESC);
+
+-- ============================================
+-- ТАБЛИЦА ТРАНСЛЯЦИЙ ВСТРЕЧ (LiveKit Egress: RTMP и HLS)
+-- ============================================
+-- rtmp_url - адрес сервера без ключа, ключ трансляции хранится только в зашифрованном виде.
+-- Для HLS в playlist_location сохраняется адрес плейлиста в хранилище Egress.
+CREATE TABLE IF NOT EXISTS live_streams (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    egress_id TEXT UNIQUE,
+    protocol TEXT NOT NULL CHECK (protocol IN ('rtmp','hls')),
+    rtmp_url TEXT,
+    stream_key_encrypted TEXT,
+    playlist_location TEXT,
+    status TEXT NOT NULL DEFAULT 'starting' CHECK (status IN ('starting','active','ending','completed','failed','aborted')),
+    error TEXT,
+    started_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ,
+    ended_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_live_streams_room ON live_streams(room_id, created_at DESC);
+
+-- ============================================
+-- ТАБЛИЦА ВХОДЯЩИХ ТРАНСЛЯЦИЙ (LiveKit Ingress)
+-- ============================================
+-- Ключ потока хранится только в зашифрованном виде. Отозванный ingress удаляется в LiveKit,
+-- строка остается для истории (revoked_at).
+CREATE TABLE IF NOT EXISTS room_ingresses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    ingress_id TEXT NOT NULL UNIQUE,
+    input_type TEXT NOT NULL CHECK (input_type IN ('rtmp','whip')),
+    url TEXT NOT NULL,
+    stream_key_encrypted TEXT NOT NULL,
+    participant_name TEXT NOT NULL,
+    created_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_room_ingresses_room ON room_ingresses(room_id, created_at DESC);
+
+-- ============================================
+-- ТАБЛИЦЫ ОПРОСОВ
+-- ============================================
+-- Опрос создается черновиком, открывается и закрывается хостом или co-host.
+-- Закрытые опросы остаются для выгрузки результатов после встречи.
+CREATE TABLE IF NOT EXISTS polls (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    created_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    question TEXT NOT NULL,
+    multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+    anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft','open','closed')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    opened_at TIMESTAMPTZ,
+    closed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_polls_room ON polls(room_id, created_at);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL,
+    text TEXT NOT NULL,
+    UNIQUE (poll_id, position)
+);
+
+-- Голос хранит пользователя и в анонимном опросе: так один пользователь голосует один раз
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    display_name TEXT NOT NULL,
+    voted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (poll_id, option_id, user_id)
+);
+
+CREATE INDEX idx_poll_votes_user ON poll_votes(poll_id, user_id);
+
+-- ============================================
+-- ТАБЛИЦА СТАТИСТИКИ РЕАКЦИЙ
+-- ============================================
+-- Сами реакции не хранятся, только число реакций каждого вида за минуту встречи
+CREATE TABLE IF NOT EXISTS room_reaction_stats (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    minute TIMESTAMPTZ NOT NULL,
+    emoji TEXT NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (room_id, minute, emoji)
+);
 
 -- ============================================
 -- ТАБЛИЦА СТАТИСТИКИ УЧАСТНИКОВ
@@ -234,7 +481,7 @@ CREATE TABLE IF NOT EXISTS audit_log (
     id BIGSERIAL PRIMARY KEY,
     event_time TIMESTAMPTZ NOT NULL DEFAULT now(),
     actor_user_id UUID REFERENCES users(id),
-    actor_role TEXT NOT NULL CHECK (actor_role IN ('user','host','technical_admin','system')),
+    actor_role TEXT NOT NULL CHECK (actor_role IN ('user','host','co_host','technical_admin','system')),
     room_id UUID REFERENCES rooms(id),
     event_type TEXT NOT NULL,
     payload JSONB NOT NULL DEFAULT '{}'::jsonb
@@ -357,10 +604,24 @@ CREATE TRIGGER update_anonymous_rooms_updated_at
 COMMENT ON TABLE users IS 'Основная таблица пользователей системы';
 COMMENT ON TABLE user_sessions IS 'Сессии пользователей для управления refresh tokens';
 COMMENT ON TABLE rooms IS 'Комнаты видеоконференций';
+COMMENT ON TABLE room_series IS 'Серии повторяющихся встреч';
+COMMENT ON TABLE calendar_feed_tokens IS 'Токены ссылок подписки на календарь встреч пользователя';
+COMMENT ON TABLE breakout_assignments IS 'Распределение участников по сессионным залам';
 COMMENT ON TABLE room_invites IS 'Приглашения в комнаты по ссылкам';
 COMMENT ON TABLE room_participants IS 'Участники комнат с их ролями и статусами';
+COMMENT ON TABLE room_dial_ins IS 'Номер и PIN для подключения к комнате по телефону';
 COMMENT ON TABLE waiting_room_entries IS 'Заявки на вход в комнату через waiting room';
 COMMENT ON TABLE chat_messages IS 'Сообщения чата в комнатах';
+COMMENT ON TABLE chat_message_mentions IS 'Упоминания участников в сообщениях чата';
+COMMENT ON TABLE chat_message_revisions IS 'Предыдущие версии отредактированных сообщений чата';
+COMMENT ON TABLE chat_attachments IS 'Вложения (файлы и изображения) сообщений чата';
+COMMENT ON TABLE recordings IS 'Записи встреч через LiveKit Egress';
+COMMENT ON TABLE live_streams IS 'Трансляции встреч в RTMP и HLS через LiveKit Egress';
+COMMENT ON TABLE room_ingresses IS 'Входящие трансляции (RTMP/WHIP) внешних ведущих через LiveKit Ingress';
+COMMENT ON TABLE polls IS 'Опросы в комнатах';
+COMMENT ON TABLE poll_options IS 'Варианты ответа опросов';
+COMMENT ON TABLE poll_votes IS 'Голоса участников в опросах';
+COMMENT ON TABLE room_reaction_stats IS 'Число реакций в комнатах по минутам';
 COMMENT ON TABLE participant_stats IS 'Статистика качества соединения участников';
 COMMENT ON TABLE user_settings IS 'Настройки пользователей (устройства, качество видео и т.д.)';
 COMMENT ON TABLE user_video_profiles IS 'Видеопрофили пользователей (фоны, фильтры)';
@@ -18,12 +18,17 @@ type Config struct {
 	Redis       RedisConfig
 	JWT         JWTConfig
 	LiveKit     LiveKitConfig
+	Room        RoomConfig
+	Chat        ChatConfig
+	Storage     StorageConfig
+	SIP         SIPConfig
 	Log         LogConfig
 }
 
 type ServerConfig struct {
 	Port         int
 	Host         string
+	PublicURL    string // Внешний адрес приложения для ссылок (вход в комнату, подписка на календарь)
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 }
@@ -50,12 +55,54 @@ type JWTConfig struct {
 }
 
 type LiveKitConfig struct {
-	URL         string // Внутренний URL для бэкенда
-	FrontendURL string // Публичный URL для фронтенда
-	APIKey      string
-	APISecret   string
-	HostIP      string // IP адрес хоста для локальной сети
-	Port        string // Порт LiveKit для клиентов (внешний)
+	URL             string // Внутренний URL для бэкенда
+	FrontendURL     string // Публичный URL для фронтенда
+	APIURL          string // HTTP адрес серверного API (Twirp), по умолчанию выводится из URL
+	EgressURL       string // HTTP адрес Egress API, по умолчанию совпадает с APIURL
+	RecordingDir    string // Каталог (префикс пути) для файлов записей в хранилище Egress
+	StreamDir       string // Каталог для HLS сегментов и плейлистов трансляций
+	StreamKeySecret string // Секрет шифрования ключей RTMP трансляций в БД
+	APIKey          string
+	APISecret       string
+	HostIP          string // IP адрес хоста для локальной сети
+	Port            string // Порт LiveKit для клиентов (внешний)
+}
+
+type RoomConfig struct {
+	WaitingRoomEntryTTL   time.Duration // Через сколько необработанная заявка в waiting room истекает
+	PasswordMaxAttempts   int           // Неудачных попыток ввода пароля комнаты с одного IP за окно
+	PasswordAttemptWindow time.Duration
+	SweepInterval         time.Duration // Период проверки просроченных комнат, 0 отключает автозавершение
+	InactivityTimeout     time.Duration // Через сколько активная комната без участников завершается
+	SeriesHorizon         time.Duration // На сколько вперед создаются вхождения повторяющихся встреч
+	ReactionMaxPerWindow  int           // Реакций от одного участника за окно
+	ReactionWindow        time.Duration // Окно лимита реакций, не меньше секунды
+}
+
+type ChatConfig struct {
+	AttachmentMaxSize         int64         // Максимальный размер вложения в байтах
+	AttachmentAllowedTypes    []string      // Разрешенные MIME типы вложений
+	AttachmentRetention       time.Duration // Сколько вложения хранятся после завершения комнаты, если в комнате не задано иначе
+	AttachmentCleanupInterval time.Duration // Период удаления вложений с истекшим сроком хранения, 0 отключает очистку
+	EditWindow                time.Duration // Сколько после отправки сообщение можно редактировать, 0 - без ограничения
+}
+
+type StorageConfig struct {
+	Backend     string        // local или s3
+	LocalDir    string        // Каталог файлов для local
+	URLSecret   string        // Секрет подписи ссылок на скачивание для local
+	URLTTL      time.Duration // Срок действия ссылок на скачивание
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool // Бакет в пути URL, нужно для MinIO
+}
+
+type SIPConfig struct {
+	DialInNumber string   // Номер для звонков в комнаты, пусто - вход по телефону отключен
+	TrunkIDs     []string // SIP trunk-и LiveKit, с которых принимаются звонки; пусто - любые
 }
 
 type LogConfig struct {
@@ -71,6 +118,7 @@ func Load() (*Config, error) {
 		Server: ServerConfig{
 			Port:         getEnvAsInt("SERVER_PORT", 8080),
 			Host:         getEnv("SERVER_HOST", "0.0.0.0"),
+			PublicURL:    strings.TrimSuffix(getEnv("PUBLIC_URL", "http://localhost"), "/"),
 			ReadTimeout:  getEnvAsDuration("SERVER_READ_TIMEOUT", 15*time.Second),
 			WriteTimeout: getEnvAsDuration("SERVER_WRITE_TIMEOUT", 15*time.Second),
 		},
@@ -93,18 +141,69 @@ func Load() (*Config, error) {
 			Issuer:        getEnv("JWT_ISSUER", "video-conference"),
 		},
 		LiveKit: LiveKitConfig{
-			URL:         getEnv("LIVEKIT_URL", "ws://localhost:7880"),
-			FrontendURL: getEnv("LIVEKIT_FRONTEND_URL", ""),
-			APIKey:      getEnv("LIVEKIT_API_KEY", "devkey"),
-			APISecret:   getEnv("LIVEKIT_API_SECRET", "secret"),
-			HostIP:      getEnv("HOST_IP", GetLocalIP()),
-			Port:        getEnv("LIVEKIT_PORT", "7880"),
+			URL:             getEnv("LIVEKIT_URL", "ws://localhost:7880"),
+			FrontendURL:     getEnv("LIVEKIT_FRONTEND_URL", ""),
+			APIURL:          getEnv("LIVEKIT_API_URL", ""),
+			EgressURL:       getEnv("LIVEKIT_EGRESS_URL", ""),
+			RecordingDir:    strings.TrimSuffix(getEnv("LIVEKIT_RECORDING_DIR", "recordings"), "/"),
+			StreamDir:       strings.TrimSuffix(getEnv("LIVEKIT_STREAM_DIR", "streams"), "/"),
+			StreamKeySecret: getEnv("STREAM_KEY_SECRET", ""),
+			APIKey:          getEnv("LIVEKIT_API_KEY", "devkey"),
+			APISecret:       getEnv("LIVEKIT_API_SECRET", "secret"),
+			HostIP:          getEnv("HOST_IP", GetLocalIP()),
+			Port:            getEnv("LIVEKIT_PORT", "7880"),
+		},
+		Room: RoomConfig{
+			WaitingRoomEntryTTL:   getEnvAsDuration("ROOM_WAITING_ENTRY_TTL", 15*time.Minute),
+			PasswordMaxAttempts:   getEnvAsInt("ROOM_PASSWORD_MAX_ATTEMPTS", 5),
+			PasswordAttemptWindow: getEnvAsDuration("ROOM_PASSWORD_ATTEMPT_WINDOW", 15*time.Minute),
+			SweepInterval:         getEnvAsDuration("ROOM_SWEEP_INTERVAL", time.Minute),
+			InactivityTimeout:     getEnvAsDuration("ROOM_INACTIVITY_TIMEOUT", 30*time.Minute),
+			SeriesHorizon:         getEnvAsDuration("ROOM_SERIES_HORIZON", 30*24*time.Hour),
+			ReactionMaxPerWindow:  getEnvAsInt("ROOM_REACTION_MAX_PER_WINDOW", 10),
+			ReactionWindow:        getEnvAsDuration("ROOM_REACTION_WINDOW", 10*time.Second),
+		},
+		Chat: ChatConfig{
+			AttachmentMaxSize:         int64(getEnvAsInt("CHAT_ATTACHMENT_MAX_SIZE", 25<<20)),
+			AttachmentAllowedTypes:    getEnvAsList("CHAT_ATTACHMENT_ALLOWED_TYPES"),
+			AttachmentRetention:       getEnvAsDuration("CHAT_ATTACHMENT_RETENTION", 30*24*time.Hour),
+			AttachmentCleanupInterval: getEnvAsDuration("CHAT_ATTACHMENT_CLEANUP_INTERVAL", 10*time.Minute),
+			EditWindow:                getEnvAsDuration("CHAT_EDIT_WINDOW", 15*time.Minute),
+		},
+		Storage: StorageConfig{
+			Backend:     getEnv("STORAGE_BACKEND", "local"),
+			LocalDir:    getEnv("STORAGE_LOCAL_DIR", "data/files"),
+			URLSecret:   getEnv("STORAGE_URL_SECRET", "your-storage-url-secret-change-in-production"),
+			URLTTL:      getEnvAsDuration("STORAGE_URL_TTL", 15*time.Minute),
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle: getEnvAsBool("S3_PATH_STYLE", false),
+		},
+		SIP: SIPConfig{
+			DialInNumber: getEnv("SIP_DIAL_IN_NUMBER", ""),
+			TrunkIDs:     getEnvAsList("SIP_TRUNK_IDS"),
 		},
 		Log: LogConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
 		},
 	}
 
+	if len(cfg.Chat.AttachmentAllowedTypes) == 0 {
+		cfg.Chat.AttachmentAllowedTypes = []string{
+			"image/jpeg", "image/png", "image/gif", "image/webp",
+			"application/pdf", "text/plain", "application/zip",
+		}
+	}
+
+	// Заглушка секрета допустима только для разработки: ключи трансляций, зашифрованные
+	// общеизвестным секретом, фактически хранятся открыто
+	if cfg.LiveKit.StreamKeySecret == "" && cfg.Environment == "development" {
+		cfg.LiveKit.StreamKeySecret = "your-stream-key-secret-change-in-production"
+	}
+
 	if err := cfg.validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
@@ -119,6 +218,16 @@ func (c *Config) validate() error {
 	if c.Database.DSN == "" {
 		return fmt.Errorf("database DSN must be set")
 	}
+	if c.LiveKit.StreamKeySecret == "" {
+		return fmt.Errorf("STREAM_KEY_SECRET must be set outside development")
+	}
+	// Окно реакций - TTL ключа в Redis в целых секундах; 0 сделал бы ключ вечным
+	if c.Room.ReactionWindow < time.Second {
+		return fmt.Errorf("ROOM_REACTION_WINDOW must be at least 1s")
+	}
+	if c.Storage.Backend != "local" && c.Storage.Backend != "s3" {
+		return fmt.Errorf("unknown storage backend %q", c.Storage.Backend)
+	}
 	return nil
 }
 
@@ -137,6 +246,25 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsList разбирает список значений через запятую, пустые элементы пропускаются
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	valueStr := getEnv(key, "")
 	if value, err := time.ParseDuration(valueStr); err == nil {
@@ -0,0 +1,54 @@
+package config
+
+import "testing"
+
+func TestStreamKeySecretRequiredOutsideDevelopment(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		secret      string
+		wantErr     bool
+	}{
+		{name: "development uses placeholder", environment: "development"},
+		{name: "production without secret", environment: "production", wantErr: true},
+		{name: "production with secret", environment: "production", secret: "0123456789abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", tt.environment)
+			t.Setenv("STREAM_KEY_SECRET", tt.secret)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.LiveKit.StreamKeySecret == "" {
+				t.Error("stream key secret is empty")
+			}
+		})
+	}
+}
+
+func TestReactionWindowMustBeAtLeastOneSecond(t *testing.T) {
+	tests := []struct {
+		window  string
+		wantErr bool
+	}{
+		{window: "1s"},
+		{window: "10s"},
+		{window: "500ms", wantErr: true},
+		{window: "0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.window, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", "development")
+			t.Setenv("ROOM_REACTION_WINDOW", tt.window)
+
+			if _, err := Load(); (err != nil) != tt.wantErr {
+				t.Fatalf("Load error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ type AuditLog struct {
 const (
 	ActorRoleUser          = "user"
 	ActorRoleHost          = "host"
+	ActorRoleCoHost        = "co_host"
 	ActorRoleTechnicalAdmin = "technical_admin"
 	ActorRoleSystem        = "system"
 )
@@ -29,10 +30,34 @@ const (
 	EventTypeRoomDeleted     = "ROOM_DELETED"
 	EventTypeRoomJoined      = "ROOM_JOINED"
 	EventTypeRoomLeft        = "ROOM_LEFT"
+	EventTypeRoomEnded       = "ROOM_ENDED"
+	EventTypeRoomCancelled   = "ROOM_CANCELLED"
+	EventTypeSeriesCreated   = "SERIES_CREATED"
+	EventTypeSeriesCancelled = "SERIES_CANCELLED"
 	EventTypeUserKicked      = "USER_KICKED"
 	EventTypeRoomLocked      = "ROOM_LOCKED"
 	EventTypeRoomUnlocked    = "ROOM_UNLOCKED"
+	EventTypeParticipantMuted = "PARTICIPANT_MUTED"
+	EventTypeCoHostPromoted  = "COHOST_PROMOTED"
+	EventTypeCoHostDemoted   = "COHOST_DEMOTED"
 	EventTypeWaitingRoomApproved = "WAITING_ROOM_APPROVED"
 	EventTypeWaitingRoomRejected = "WAITING_ROOM_REJECTED"
+	EventTypeRecordingStarted    = "RECORDING_STARTED"
+	EventTypeRecordingStopped    = "RECORDING_STOPPED"
+	EventTypeStreamStarted       = "STREAM_STARTED"
+	EventTypeStreamStopped       = "STREAM_STOPPED"
+	EventTypeDialInEnabled       = "DIAL_IN_ENABLED"
+	EventTypeDialInDisabled      = "DIAL_IN_DISABLED"
+	EventTypeIngressCreated      = "INGRESS_CREATED"
+	EventTypeIngressRevoked      = "INGRESS_REVOKED"
+	EventTypeBreakoutsCreated    = "BREAKOUTS_CREATED"
+	EventTypeBreakoutsOpened     = "BREAKOUTS_OPENED"
+	EventTypeBreakoutsClosed     = "BREAKOUTS_CLOSED"
+	EventTypePollCreated         = "POLL_CREATED"
+	EventTypePollOpened          = "POLL_OPENED"
+	EventTypePollClosed          = "POLL_CLOSED"
+	EventTypePollDeleted         = "POLL_DELETED"
+	EventTypeChatControlsUpdated = "CHAT_CONTROLS_UPDATED"
+	EventTypeChatMessageDeleted  = "CHAT_MESSAGE_DELETED"
 )
 
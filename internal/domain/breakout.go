@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BreakoutAssignment - пользователь основной комнаты распределен в сессионный зал
+type BreakoutAssignment struct {
+	ParentRoomID   uuid.UUID `json:"parent_room_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	BreakoutRoomID uuid.UUID `json:"breakout_room_id"`
+	AssignedAt     time.Time `json:"assigned_at"`
+}
+
+// BreakoutRoom - сессионный зал с распределенными в него пользователями.
+// ClosesAt задан, пока идет обратный отсчет до закрытия.
+type BreakoutRoom struct {
+	ID              uuid.UUID   `json:"id"`
+	Title           string      `json:"title"`
+	Status          string      `json:"status"`
+	ClosesAt        *time.Time  `json:"closes_at,omitempty"`
+	AssignedUserIDs []uuid.UUID `json:"assigned_user_ids"`
+}
+
+// BreakoutPayload - событие сессионных залов в канале основной комнаты и залов
+type BreakoutPayload struct {
+	Action    string          `json:"action"`
+	Breakouts []*BreakoutRoom `json:"breakouts,omitempty"`
+	ClosesAt  *time.Time      `json:"closes_at,omitempty"`
+}
+
+const (
+	BreakoutActionCreated  = "created"
+	BreakoutActionAssigned = "assigned"
+	BreakoutActionOpened   = "opened"
+	BreakoutActionClosing  = "closing"
+	BreakoutActionClosed   = "closed"
+)
@@ -7,15 +7,127 @@ import (
 )
 
 type ChatMessage struct {
-	ID                    int64      `json:"id"`
-	RoomID                uuid.UUID  `json:"room_id"`
-	SenderParticipantID   *uuid.UUID `json:"sender_participant_id,omitempty"`
-	MessageType           string     `json:"message_type"`
-	Content               string     `json:"content"`
-	CreatedAt             time.Time  `json:"created_at"`
-	EditedAt              *time.Time `json:"edited_at,omitempty"`
-	DeletedAt             *time.Time `json:"deleted_at,omitempty"`
+	ID                     int64      `json:"id"`
+	RoomID                 uuid.UUID  `json:"room_id"`
+	SenderParticipantID    *uuid.UUID `json:"sender_participant_id,omitempty"`
+	MessageType            string     `json:"message_type"`
+	Content                string     `json:"content"`
+	CreatedAt              time.Time  `json:"created_at"`
+	EditedAt               *time.Time `json:"edited_at,omitempty"`
+	Edited                 bool       `json:"edited"` // Сообщение правилось; предыдущие версии видят модераторы
+	DeletedAt              *time.Time `json:"deleted_at,omitempty"`
 	DeletedByParticipantID *uuid.UUID `json:"deleted_by_participant_id,omitempty"`
+	// Ответ на сообщение: ReplyToMessageID - исходное сообщение, ThreadRootID - первое сообщение ветки
+	ReplyToMessageID *int64 `json:"reply_to_message_id,omitempty"`
+	ThreadRootID     *int64 `json:"thread_root_id,omitempty"`
+	// ReplyCount - число ответов в ветке, заполняется для первых сообщений веток
+	ReplyCount int `json:"reply_count,omitempty"`
+	// Mentions - ID упомянутых участников комнаты
+	Mentions []uuid.UUID `json:"mentions,omitempty"`
+	// Attachments - вложенные файлы с действующими ссылками на скачивание
+	Attachments []*ChatAttachment `json:"attachments,omitempty"`
+	// Адресат: вся комната, один участник (RecipientParticipantID) или только хост и co-host.
+	// Личные сообщения видят отправитель и адресаты.
+	RecipientType          string     `json:"recipient_type"`
+	RecipientParticipantID *uuid.UUID `json:"recipient_participant_id,omitempty"`
+}
+
+// IsPrivate - сообщение адресовано не всей комнате
+func (m *ChatMessage) IsPrivate() bool {
+	return m.RecipientType != "" && m.RecipientType != ChatRecipientRoom
+}
+
+// ChatViewer - кто читает чат: личные сообщения видны отправителю и адресату под любым
+// их участием в комнате, сообщения для ведущих - еще и хосту и co-host (Host)
+type ChatViewer struct {
+	UserID uuid.UUID
+	Host   bool
+}
+
+// ChatControls - ограничения чата комнаты, которые задают хост и co-host. На ведущих не действуют.
+type ChatControls struct {
+	Enabled bool `json:"enabled"`
+	// Минимальный интервал между сообщениями одного участника, 0 - без ограничения
+	SlowModeSeconds int `json:"slow_mode_seconds"`
+	// Пользователи, которым чат отключен
+	DisabledUserIDs []uuid.UUID `json:"disabled_user_ids"`
+}
+
+// ChatControls читает ограничения чата из настроек комнаты
+func (r *Room) ChatControls() *ChatControls {
+	controls := &ChatControls{
+		Enabled:         r.SettingBool(RoomSettingChatEnabled, true),
+		DisabledUserIDs: []uuid.UUID{},
+	}
+	controls.SlowModeSeconds, _ = r.SettingInt(RoomSettingChatSlowModeSeconds)
+	for _, value := range r.SettingStrings(RoomSettingChatDisabledUsers) {
+		if userID, err := uuid.Parse(value); err == nil {
+			controls.DisabledUserIDs = append(controls.DisabledUserIDs, userID)
+		}
+	}
+	return controls
+}
+
+// ChatTranscriptEntry - сообщение в выгрузке чата. Имена отправителя и адресата берутся из их
+// участия в комнате, у вложений выгружаются только имена файлов. Текст удаленного сообщения не выгружается.
+type ChatTranscriptEntry struct {
+	ID               int64      `json:"id"`
+	MessageType      string     `json:"message_type"`
+	SenderName       string     `json:"sender_name,omitempty"`
+	RecipientType    string     `json:"recipient_type"`
+	RecipientName    string     `json:"recipient_name,omitempty"`
+	Content          string     `json:"content"`
+	CreatedAt        time.Time  `json:"created_at"`
+	Edited           bool       `json:"edited"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	Deleted          bool       `json:"deleted"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+	ReplyToMessageID *int64     `json:"reply_to_message_id,omitempty"`
+	ThreadRootID     *int64     `json:"thread_root_id,omitempty"`
+	Attachments      []string   `json:"attachments,omitempty"`
+}
+
+// ChatMessageRevision - предыдущая версия текста сообщения. CreatedAt - когда версия
+// была написана (отправка или прошлая правка), ReplacedAt - когда ее заменила правка.
+type ChatMessageRevision struct {
+	ID         int64     `json:"id"`
+	MessageID  int64     `json:"message_id"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// ChatMessageHistory - сообщение в текущей версии и его предыдущие версии от старых к новым
+type ChatMessageHistory struct {
+	Message   *ChatMessage           `json:"message"`
+	Revisions []*ChatMessageRevision `json:"revisions"`
+}
+
+// ChatAttachment - файл, загруженный в чат. До отправки сообщения MessageID пуст.
+// Ссылки URL и ThumbnailURL подписаны и действуют ограниченное время.
+type ChatAttachment struct {
+	ID             uuid.UUID `json:"id"`
+	RoomID         uuid.UUID `json:"room_id"`
+	MessageID      *int64    `json:"message_id,omitempty"`
+	UploaderUserID uuid.UUID `json:"uploader_user_id"`
+	FileName       string    `json:"file_name"`
+	ContentType    string    `json:"content_type"`
+	SizeBytes      int64     `json:"size_bytes"`
+	StorageKey     string    `json:"-"`
+	ThumbnailKey   *string   `json:"-"`
+	// Размеры исходного изображения, для остальных файлов пусты
+	Width        *int      `json:"width,omitempty"`
+	Height       *int      `json:"height,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	URLExpiresAt time.Time `json:"url_expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ChatThread - ветка ответов: первое сообщение и страница ответов в порядке отправки
+type ChatThread struct {
+	Root    *ChatMessage   `json:"root"`
+	Replies []*ChatMessage `json:"replies"`
 }
 
 const (
@@ -23,3 +135,9 @@ const (
 	MessageTypeSystem = "system"
 )
 
+// Адресаты сообщения чата
+const (
+	ChatRecipientRoom        = "room"
+	ChatRecipientParticipant = "participant"
+	ChatRecipientHosts       = "hosts"
+)
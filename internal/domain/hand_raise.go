@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RaisedHand - поднятая рука участника в очереди комнаты. Очередь хранится в Redis
+// и упорядочена по времени поднятия.
+type RaisedHand struct {
+	ParticipantID uuid.UUID  `json:"participant_id"`
+	UserID        *uuid.UUID `json:"user_id,omitempty"`
+	DisplayName   string     `json:"display_name"`
+	RaisedAt      time.Time  `json:"raised_at"`
+}
+
+// HandQueuePayload - изменение очереди поднятых рук. Queue - очередь после изменения;
+// Speaker заполнен для действия next_speaker.
+type HandQueuePayload struct {
+	Action        string        `json:"action"`
+	ParticipantID *uuid.UUID    `json:"participant_id,omitempty"`
+	Queue         []*RaisedHand `json:"queue"`
+	Speaker       *RaisedHand   `json:"speaker,omitempty"`
+	CanPublish    bool          `json:"can_publish,omitempty"`
+}
+
+const (
+	HandActionRaised      = "raised"
+	HandActionLowered     = "lowered"
+	HandActionLoweredAll  = "lowered_all"
+	HandActionNextSpeaker = "next_speaker"
+)
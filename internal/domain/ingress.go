@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoomIngress - входящая трансляция внешнего ведущего (OBS и т.п.) через LiveKit Ingress.
+// В комнате она появляется участником с ролью ParticipantRoleIngest.
+type RoomIngress struct {
+	ID        uuid.UUID `json:"id"`
+	RoomID    uuid.UUID `json:"room_id"`
+	IngressID string    `json:"ingress_id"`
+	InputType string    `json:"input_type"`
+	URL       string    `json:"url"`
+	// StreamKey заполняется расшифрованным ключом только в ответах хосту
+	StreamKey          string     `json:"stream_key,omitempty"`
+	StreamKeyEncrypted string     `json:"-"`
+	ParticipantName    string     `json:"participant_name"`
+	CreatedByUserID    *uuid.UUID `json:"created_by_user_id,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+}
+
+const (
+	IngressInputRTMP = "rtmp"
+	IngressInputWHIP = "whip"
+)
+
+// Identity - identity участника ingress в LiveKit
+func (i *RoomIngress) Identity() string {
+	return "ingress_" + i.ID.String()
+}
+
+// IsRevoked - ingress отозван и удален в LiveKit
+func (i *RoomIngress) IsRevoked() bool {
+	return i.RevokedAt != nil
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LiveStream - трансляция комнаты через LiveKit Egress
+type LiveStream struct {
+	ID       uuid.UUID `json:"id"`
+	RoomID   uuid.UUID `json:"room_id"`
+	EgressID string    `json:"egress_id"`
+	Protocol string    `json:"protocol"`
+	// RTMPURL - адрес сервера без ключа трансляции
+	RTMPURL            *string    `json:"rtmp_url,omitempty"`
+	StreamKeyEncrypted *string    `json:"-"`
+	PlaylistLocation   *string    `json:"playlist_location,omitempty"`
+	Status             string     `json:"status"`
+	Error              *string    `json:"error,omitempty"`
+	StartedByUserID    *uuid.UUID `json:"started_by_user_id,omitempty"`
+	StartedAt          *time.Time `json:"started_at,omitempty"`
+	EndedAt            *time.Time `json:"ended_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+const (
+	StreamProtocolRTMP = "rtmp"
+	StreamProtocolHLS  = "hls"
+)
+
+const (
+	LiveStreamStatusStarting  = "starting"
+	LiveStreamStatusActive    = "active"
+	LiveStreamStatusEnding    = "ending"
+	LiveStreamStatusCompleted = "completed"
+	LiveStreamStatusFailed    = "failed"
+	LiveStreamStatusAborted   = "aborted"
+)
+
+// IsFinished - трансляция завершена и больше не изменится
+func (s *LiveStream) IsFinished() bool {
+	switch s.Status {
+	case LiveStreamStatusCompleted, LiveStreamStatusFailed, LiveStreamStatusAborted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Poll - опрос во время встречи. Результаты (Votes, Voters, TotalVoters) считаются
+// по голосам при каждом чтении и в БД не хранятся.
+type Poll struct {
+	ID              uuid.UUID     `json:"id"`
+	RoomID          uuid.UUID     `json:"room_id"`
+	CreatedByUserID *uuid.UUID    `json:"created_by_user_id,omitempty"`
+	Question        string        `json:"question"`
+	MultipleChoice  bool          `json:"multiple_choice"`
+	Anonymous       bool          `json:"anonymous"`
+	Status          string        `json:"status"`
+	Options         []*PollOption `json:"options"`
+	TotalVoters     int           `json:"total_voters"`
+	// MyOptionIDs - варианты, выбранные запросившим пользователем (только в ответах REST)
+	MyOptionIDs []uuid.UUID `json:"my_option_ids,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	OpenedAt    *time.Time  `json:"opened_at,omitempty"`
+	ClosedAt    *time.Time  `json:"closed_at,omitempty"`
+}
+
+// PollOption - вариант ответа с числом голосов; Voters заполняется только в неанонимных опросах
+type PollOption struct {
+	ID       uuid.UUID    `json:"id"`
+	Position int          `json:"position"`
+	Text     string       `json:"text"`
+	Votes    int          `json:"votes"`
+	Voters   []*PollVoter `json:"voters,omitempty"`
+}
+
+// PollVoter - проголосовавший в неанонимном опросе
+type PollVoter struct {
+	UserID      uuid.UUID `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+}
+
+// PollVote - голос пользователя за один вариант
+type PollVote struct {
+	PollID      uuid.UUID
+	OptionID    uuid.UUID
+	UserID      uuid.UUID
+	DisplayName string
+	VotedAt     time.Time
+}
+
+// PollPayload - событие опроса в канале комнаты
+type PollPayload struct {
+	Action string `json:"action"`
+	Poll   *Poll  `json:"poll"`
+}
+
+const (
+	PollStatusDraft  = "draft"
+	PollStatusOpen   = "open"
+	PollStatusClosed = "closed"
+)
+
+const (
+	PollActionOpened  = "opened"
+	PollActionVoted   = "voted"
+	PollActionClosed  = "closed"
+	PollActionDeleted = "deleted"
+)
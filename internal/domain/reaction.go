@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reaction - реакция участника, которая рассылается в канал комнаты и в БД не хранится;
+// для статистики сохраняются только счетчики по минутам.
+type Reaction struct {
+	ParticipantID uuid.UUID  `json:"participant_id"`
+	UserID        *uuid.UUID `json:"user_id,omitempty"`
+	DisplayName   string     `json:"display_name"`
+	Emoji         string     `json:"emoji"`
+	SentAt        time.Time  `json:"sent_at"`
+}
+
+// ReactionCount - число реакций одного вида за минуту встречи
+type ReactionCount struct {
+	Minute time.Time `json:"minute"`
+	Emoji  string    `json:"emoji"`
+	Count  int       `json:"count"`
+}
+
+// ReactionMinute - реакции за одну минуту встречи по видам
+type ReactionMinute struct {
+	Minute time.Time      `json:"minute"`
+	Counts map[string]int `json:"counts"`
+	Total  int            `json:"total"`
+}
+
+// ReactionStats - статистика реакций комнаты: итог по видам и разбивка по минутам
+type ReactionStats struct {
+	RoomID  uuid.UUID         `json:"room_id"`
+	Total   int               `json:"total"`
+	Totals  map[string]int    `json:"totals"`
+	Minutes []*ReactionMinute `json:"minutes"`
+}
+
+// AllowedReactions - реакции, которые можно отправить в комнату
+var AllowedReactions = []string{"👍", "👎", "👏", "😂", "❤️", "🎉", "😮", "🤔"}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoomEvent - типизированный JSON-конверт для real-time канала комнаты.
+// Передается через Redis pub/sub между репликами и отправляется клиентам по WebSocket.
+type RoomEvent struct {
+	Type    string          `json:"type"`
+	RoomID  uuid.UUID       `json:"room_id"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	SentAt  time.Time       `json:"sent_at"`
+	// Recipients - ID участников (или слушателей), которым адресовано событие; пусто - всей комнате
+	Recipients []uuid.UUID `json:"recipients,omitempty"`
+}
+
+// ChatMessageRef - ссылка на сообщение чата (для событий удаления)
+type ChatMessageRef struct {
+	MessageID int64 `json:"message_id"`
+}
+
+// TypingPayload - индикатор набора текста
+type TypingPayload struct {
+	ParticipantID uuid.UUID `json:"participant_id"`
+	DisplayName   string    `json:"display_name"`
+	IsTyping      bool      `json:"is_typing"`
+}
+
+// PresencePayload - подключение/отключение участника от real-time канала
+type PresencePayload struct {
+	ParticipantID uuid.UUID  `json:"participant_id"`
+	UserID        *uuid.UUID `json:"user_id,omitempty"`
+	DisplayName   string     `json:"display_name"`
+	Status        string     `json:"status"`
+}
+
+// ModerationPayload - действие хоста или co-host над комнатой или участником
+type ModerationPayload struct {
+	Action        string     `json:"action"`
+	ParticipantID *uuid.UUID `json:"participant_id,omitempty"`
+	ActorUserID   uuid.UUID  `json:"actor_user_id"`
+	Banned        bool       `json:"banned,omitempty"`
+	TrackKind     string     `json:"track_kind,omitempty"`
+}
+
+// RoomStatusPayload - комната завершена или отменена
+type RoomStatusPayload struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+const (
+	RoomEventTypeMessage      = "message"
+	RoomEventTypeMention      = "mention"
+	RoomEventTypeEdit         = "edit"
+	RoomEventTypeDelete       = "delete"
+	RoomEventTypeTyping       = "typing"
+	RoomEventTypePresence     = "presence"
+	RoomEventTypeWaitingRoom  = "waiting_room"
+	RoomEventTypeModeration   = "moderation"
+	RoomEventTypeRole         = "role"
+	RoomEventTypeRoomStatus   = "room_status"
+	RoomEventTypeBreakout     = "breakout"
+	RoomEventTypePoll         = "poll"
+	RoomEventTypeHand         = "hand"
+	RoomEventTypeReaction     = "reaction"
+	RoomEventTypeChatControls = "chat_controls"
+	RoomEventTypeError        = "error"
+)
+
+const (
+	ModerationActionKicked   = "kicked"
+	ModerationActionMuted    = "muted"
+	ModerationActionLocked   = "locked"
+	ModerationActionUnlocked = "unlocked"
+)
+
+const (
+	PresenceStatusOnline  = "online"
+	PresenceStatusOffline = "offline"
+)
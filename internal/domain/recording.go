@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Recording - запись встречи через LiveKit Egress
+type Recording struct {
+	ID              uuid.UUID  `json:"id"`
+	RoomID          uuid.UUID  `json:"room_id"`
+	EgressID        string     `json:"egress_id"`
+	Kind            string     `json:"kind"`
+	TrackSID        *string    `json:"track_sid,omitempty"`
+	Status          string     `json:"status"`
+	FileLocation    *string    `json:"file_location,omitempty"`
+	DurationSeconds *int       `json:"duration_seconds,omitempty"`
+	SizeBytes       *int64     `json:"size_bytes,omitempty"`
+	Error           *string    `json:"error,omitempty"`
+	StartedByUserID *uuid.UUID `json:"started_by_user_id,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+const (
+	RecordingKindRoomComposite = "room_composite"
+	RecordingKindTrack         = "track"
+)
+
+const (
+	RecordingStatusStarting  = "starting"
+	RecordingStatusActive    = "active"
+	RecordingStatusEnding    = "ending"
+	RecordingStatusCompleted = "completed"
+	RecordingStatusFailed    = "failed"
+	RecordingStatusAborted   = "aborted"
+)
+
+// IsFinished - запись завершена и больше не изменится
+func (r *Recording) IsFinished() bool {
+	switch r.Status {
+	case RecordingStatusCompleted, RecordingStatusFailed, RecordingStatusAborted:
+		return true
+	}
+	return false
+}
@@ -22,6 +22,9 @@ type Room struct {
 	IsLocked           bool                   `json:"is_locked"`
 	PasswordHash       *string                `json:"-"`
 	Settings           map[string]interface{} `json:"settings"`
+	SeriesID           *uuid.UUID             `json:"series_id,omitempty"`
+	OccurrenceStartAt  *time.Time             `json:"occurrence_start_at,omitempty"` // Исходное время вхождения серии
+	ParentRoomID       *uuid.UUID             `json:"parent_room_id,omitempty"`      // Основная комната сессионного зала
 	CreatedAt          time.Time              `json:"created_at"`
 	UpdatedAt          time.Time              `json:"updated_at"`
 }
@@ -35,6 +38,7 @@ type RoomInvite struct {
 	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
 	MaxUses       *int       `json:"max_uses,omitempty"`
 	UsedCount     int        `json:"used_count"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
 	CreatedAt     time.Time  `json:"created_at"`
 }
 
@@ -52,6 +56,18 @@ type RoomParticipant struct {
 	InitialMuted  bool       `json:"initial_muted"`
 	ClientIP      *string    `json:"client_ip,omitempty"`
 	UserAgent     *string    `json:"user_agent,omitempty"`
+	Source        string     `json:"source"`
+	// CallerID - маскированный номер звонящего для участников по телефону
+	CallerID      *string    `json:"caller_id,omitempty"`
+}
+
+// RoomDialIn - номер и PIN для входа в комнату по телефону
+type RoomDialIn struct {
+	RoomID            uuid.UUID `json:"room_id"`
+	PhoneNumber       string    `json:"phone_number"`
+	PIN               string    `json:"pin"`
+	SIPDispatchRuleID string    `json:"-"`
+	CreatedAt         time.Time `json:"created_at"`
 }
 
 type WaitingRoomEntry struct {
@@ -77,6 +93,8 @@ const (
 	ParticipantRoleHost       = "host"
 	ParticipantRoleCoHost     = "co_host"
 	ParticipantRoleParticipant = "participant"
+	// Входящая трансляция внешнего ведущего (LiveKit Ingress), не пользователь системы
+	ParticipantRoleIngest = "ingest"
 )
 
 const (
@@ -86,3 +104,64 @@ const (
 	WaitingRoomStatusExpired  = "expired"
 )
 
+const (
+	ParticipantSourceWeb   = "web"
+	ParticipantSourcePhone = "phone"
+	ParticipantSourceIngress = "ingress"
+)
+
+// Ключи Room.Settings
+const (
+	// Вебинар: обычные участники только смотрят и слушают
+	RoomSettingWebinarMode      = "webinar_mode"
+	RoomSettingAllowCamera      = "allow_camera"
+	RoomSettingAllowMicrophone  = "allow_microphone"
+	RoomSettingAllowScreenShare = "allow_screen_share"
+	// За сколько минут до scheduled_start_at участники могут войти; без настройки вход не ограничен
+	RoomSettingJoinBeforeStartMinutes = "join_before_start_minutes"
+	// Вход по телефону включен; выставляется сервером вместе с номером, PIN выдается отдельно
+	RoomSettingDialInEnabled = "dial_in_enabled"
+	RoomSettingDialInNumber  = "dial_in_number"
+	// Сколько дней хранить вложения чата после завершения комнаты; без настройки - CHAT_ATTACHMENT_RETENTION
+	RoomSettingAttachmentRetentionDays = "attachment_retention_days"
+	// Ограничения чата; выставляются ведущими через ChatService, а не обновлением комнаты
+	RoomSettingChatEnabled         = "chat_enabled"
+	RoomSettingChatSlowModeSeconds = "chat_slow_mode_seconds"
+	RoomSettingChatDisabledUsers   = "chat_disabled_users"
+)
+
+// SettingBool возвращает булеву настройку комнаты или def, если она не задана
+func (r *Room) SettingBool(key string, def bool) bool {
+	if value, ok := r.Settings[key].(bool); ok {
+		return value
+	}
+	return def
+}
+
+// SettingStrings возвращает список строк из настройки комнаты. Из JSON список приходит как []interface{}.
+func (r *Room) SettingStrings(key string) []string {
+	switch value := r.Settings[key].(type) {
+	case []string:
+		return value
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// SettingInt возвращает целочисленную настройку комнаты. Числа из JSON приходят как float64.
+func (r *Room) SettingInt(key string) (int, bool) {
+	switch value := r.Settings[key].(type) {
+	case float64:
+		return int(value), true
+	case int:
+		return value, true
+	}
+	return 0, false
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoomSeries - шаблон повторяющейся встречи. Вхождения серии создаются как обычные
+// комнаты (Room.SeriesID), поэтому каждое можно изменить или отменить отдельно.
+type RoomSeries struct {
+	ID                 uuid.UUID              `json:"id"`
+	HostUserID         uuid.UUID              `json:"host_user_id"`
+	Title              string                 `json:"title"`
+	Description        *string                `json:"description,omitempty"`
+	MaxParticipants    int                    `json:"max_participants"`
+	WaitingRoomEnabled bool                   `json:"waiting_room_enabled"`
+	Settings           map[string]interface{} `json:"settings"`
+	RRule              string                 `json:"rrule"`    // FREQ=DAILY|WEEKLY, INTERVAL, BYDAY, COUNT, UNTIL
+	StartAt            time.Time              `json:"start_at"` // DTSTART: время первого вхождения
+	DurationMinutes    int                    `json:"duration_minutes"`
+	Timezone           string                 `json:"timezone"` // IANA зона, в которой считаются дни недели и время начала
+	CancelledAt        *time.Time             `json:"cancelled_at,omitempty"`
+	CreatedAt          time.Time              `json:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+}
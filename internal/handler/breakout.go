@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+type BreakoutHandler struct {
+	breakoutService service.BreakoutService
+	mediaService    service.MediaService
+	log             logger.Logger
+}
+
+func NewBreakoutHandler(breakoutService service.BreakoutService, mediaService service.MediaService, log logger.Logger) *BreakoutHandler {
+	return &BreakoutHandler{
+		breakoutService: breakoutService,
+		mediaService:    mediaService,
+		log:             log,
+	}
+}
+
+type CreateBreakoutsRequest struct {
+	Count int `json:"count" binding:"required"`
+	// Названия залов по порядку; для остальных - "Комната N"
+	Titles []string `json:"titles,omitempty"`
+}
+
+type AssignBreakoutsRequest struct {
+	// user_id -> ID зала; null снимает распределение
+	Assignments map[uuid.UUID]*uuid.UUID `json:"assignments" binding:"required"`
+}
+
+type CloseBreakoutsRequest struct {
+	// Отсчет до закрытия в секундах; 0 - закрыть сразу
+	CountdownSeconds int `json:"countdown_seconds"`
+}
+
+type BroadcastBreakoutsRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+type BreakoutTokenRequest struct {
+	DisplayName string `json:"display_name"`
+	// Зал для ведущих; участники получают токен своего зала
+	BreakoutRoomID *uuid.UUID `json:"breakout_room_id,omitempty"`
+}
+
+// Create - создание сессионных залов (POST /api/v1/rooms/:id/breakouts)
+func (h *BreakoutHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req CreateBreakoutsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	breakouts, err := h.breakoutService.Create(c.Request.Context(), roomID, userID.(uuid.UUID), service.CreateBreakoutsParams{
+		Count:  req.Count,
+		Titles: req.Titles,
+	})
+	if err != nil {
+		c.JSON(breakoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, breakouts)
+}
+
+// List - залы комнаты с распределением (GET /api/v1/rooms/:id/breakouts)
+func (h *BreakoutHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	breakouts, err := h.breakoutService.List(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(breakoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakouts)
+}
+
+// Assign - ручное распределение (PUT /api/v1/rooms/:id/breakouts/assignments)
+func (h *BreakoutHandler) Assign(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req AssignBreakoutsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	breakouts, err := h.breakoutService.Assign(c.Request.Context(), roomID, userID.(uuid.UUID), req.Assignments)
+	if err != nil {
+		c.JSON(breakoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakouts)
+}
+
+// AssignRandom - случайное распределение (POST /api/v1/rooms/:id/breakouts/assignments/random)
+func (h *BreakoutHandler) AssignRandom(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	breakouts, err := h.breakoutService.AssignRandom(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(breakoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakouts)
+}
+
+// Open - открытие залов (POST /api/v1/rooms/:id/breakouts/open)
+func (h *BreakoutHandler) Open(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	breakouts, err := h.breakoutService.Open(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(breakoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakouts)
+}
+
+// Close - закрытие залов с отсчетом (POST /api/v1/rooms/:id/breakouts/close)
+func (h *BreakoutHandler) Close(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req CloseBreakoutsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	countdown := time.Duration(req.CountdownSeconds) * time.Second
+	breakouts, err := h.breakoutService.Close(c.Request.Context(), roomID, userID.(uuid.UUID), countdown)
+	if err != nil {
+		c.JSON(breakoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakouts)
+}
+
+// Recall - возврат всех в основную комнату (POST /api/v1/rooms/:id/breakouts/recall)
+func (h *BreakoutHandler) Recall(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	if err := h.breakoutService.Recall(c.Request.Context(), roomID, userID.(uuid.UUID)); err != nil {
+		c.JSON(breakoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Breakouts closed"})
+}
+
+// Broadcast - сообщение во все открытые залы (POST /api/v1/rooms/:id/breakouts/broadcast)
+func (h *BreakoutHandler) Broadcast(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req BroadcastBreakoutsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.breakoutService.Broadcast(c.Request.Context(), roomID, userID.(uuid.UUID), req.Message); err != nil {
+		c.JSON(breakoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message sent"})
+}
+
+// GetToken - LiveKit токен своего зала (POST /api/v1/rooms/:id/media/breakout-token)
+func (h *BreakoutHandler) GetToken(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req BreakoutTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, url, breakoutID, err := h.mediaService.GetBreakoutToken(c.Request.Context(), roomID, userID.(uuid.UUID), req.DisplayName, req.BreakoutRoomID)
+	if err != nil {
+		c.JSON(breakoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "url": url, "breakout_room_id": breakoutID})
+}
+
+func breakoutErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "breakout not found", "not assigned to a breakout":
+		return http.StatusNotFound
+	case "only host or co-host can manage breakouts", "not a room participant", "you are banned from this room":
+		return http.StatusForbidden
+	case "room is not available", "breakout room cannot have breakouts", "breakouts already exist", "no breakouts", "breakouts are not open":
+		return http.StatusConflict
+	case "failed to create breakout", "failed to update breakout", "failed to assign breakout", "failed to join breakout",
+		"failed to check participant ban", "failed to generate token", "failed to update room":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+type CalendarHandler struct {
+	calendarService service.CalendarService
+	log             logger.Logger
+}
+
+func NewCalendarHandler(calendarService service.CalendarService, log logger.Logger) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+		log:             log,
+	}
+}
+
+type CalendarFeedResponse struct {
+	URL       string `json:"url"`
+	WebcalURL string `json:"webcal_url"`
+}
+
+// RoomICS - встреча в формате iCalendar (GET /api/v1/rooms/:id/calendar.ics)
+func (h *CalendarHandler) RoomICS(c *gin.Context) {
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	data, err := h.calendarService.RoomICS(c.Request.Context(), roomID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "room not found":
+			status = http.StatusNotFound
+		case "room is not scheduled":
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="meeting-`+roomID.String()+`.ics"`)
+	c.Data(http.StatusOK, calendarContentType, data)
+}
+
+// IssueFeedToken - новая ссылка подписки на календарь (POST /api/v1/calendar/feed-token).
+// Прежняя ссылка перестает работать.
+func (h *CalendarHandler) IssueFeedToken(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	token, err := h.calendarService.IssueFeedToken(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	url := h.calendarService.FeedURL(token)
+	c.JSON(http.StatusCreated, &CalendarFeedResponse{
+		URL:       url,
+		WebcalURL: webcalURL(url),
+	})
+}
+
+// RevokeFeedToken - отзыв ссылки подписки (DELETE /api/v1/calendar/feed-token)
+func (h *CalendarHandler) RevokeFeedToken(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	if err := h.calendarService.RevokeFeedToken(c.Request.Context(), userID.(uuid.UUID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked"})
+}
+
+// Feed - подписка на встречи пользователя (GET /api/v1/calendar/feeds/:token.ics)
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	data, err := h.calendarService.Feed(c.Request.Context(), token)
+	if err != nil {
+		if err.Error() == "calendar feed not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error("Failed to build calendar feed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build calendar feed"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, calendarContentType, data)
+}
+
+// webcalURL заменяет схему http(s) на webcal, чтобы ссылка открывалась в календаре как подписка
+func webcalURL(url string) string {
+	for _, scheme := range []string{"https://", "http://"} {
+		if strings.HasPrefix(url, scheme) {
+			return "webcal://" + strings.TrimPrefix(url, scheme)
+		}
+	}
+	return url
+}
@@ -29,11 +29,21 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		return
 	}
 
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	messages, err := h.chatService.GetMessages(c.Request.Context(), roomID, limit, offset)
+	messages, err := h.chatService.GetMessages(c.Request.Context(), roomID, userID.(uuid.UUID), limit, offset)
 	if err != nil {
+		if err.Error() == "room not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -42,7 +52,15 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 }
 
 type SendMessageRequest struct {
-	Content string `json:"content" binding:"required"`
+	// Текст может быть пустым, если есть вложения
+	Content string `json:"content"`
+	// Ответ в ветке на сообщение с этим ID
+	ReplyToMessageID *int64 `json:"reply_to_message_id"`
+	// Вложения, загруженные через POST /rooms/:id/chat/attachments
+	AttachmentIDs []uuid.UUID `json:"attachment_ids" binding:"max=10"`
+	// Личное сообщение: participant - участнику RecipientParticipantID, hosts - хосту и co-host
+	RecipientType          string     `json:"recipient_type" binding:"omitempty,oneof=room participant hosts"`
+	RecipientParticipantID *uuid.UUID `json:"recipient_participant_id"`
 }
 
 func (h *ChatHandler) SendMessage(c *gin.Context) {
@@ -64,20 +82,86 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	message, err := h.chatService.SendMessage(c.Request.Context(), roomID, userID.(uuid.UUID), req.Content)
+	message, err := h.chatService.SendMessage(c.Request.Context(), roomID, userID.(uuid.UUID), service.SendMessageParams{
+		Content:                req.Content,
+		ReplyToMessageID:       req.ReplyToMessageID,
+		AttachmentIDs:          req.AttachmentIDs,
+		RecipientType:          req.RecipientType,
+		RecipientParticipantID: req.RecipientParticipantID,
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, message)
 }
 
+// GetThread - ветка ответов (GET /api/v1/rooms/:id/chat/messages/:messageId/thread)
+func (h *ChatHandler) GetThread(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	messageID, err := strconv.ParseInt(c.Param("messageId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message ID"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	thread, err := h.chatService.GetThread(c.Request.Context(), roomID, messageID, userID.(uuid.UUID), limit, offset)
+	if err != nil {
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, thread)
+}
+
+// GetMentions - сообщения с упоминаниями текущего пользователя (GET /api/v1/rooms/:id/chat/mentions)
+func (h *ChatHandler) GetMentions(c *gin.Context) {
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	messages, err := h.chatService.GetMentions(c.Request.Context(), roomID, userID.(uuid.UUID), limit, offset)
+	if err != nil {
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
 type EditMessageRequest struct {
 	Content string `json:"content" binding:"required"`
 }
 
+// EditMessage - правка своего сообщения (PATCH /api/v1/rooms/:id/chat/messages/:messageId)
 func (h *ChatHandler) EditMessage(c *gin.Context) {
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
 	messageID, err := strconv.ParseInt(c.Param("messageId"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message ID"})
@@ -96,15 +180,39 @@ func (h *ChatHandler) EditMessage(c *gin.Context) {
 		return
 	}
 
-	message, err := h.chatService.EditMessage(c.Request.Context(), messageID, userID.(uuid.UUID), req.Content)
+	message, err := h.chatService.EditMessage(c.Request.Context(), roomID, messageID, userID.(uuid.UUID), req.Content)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, message)
 }
 
+// GetHistory - предыдущие версии сообщения для ведущих (GET /api/v1/rooms/:id/chat/messages/:messageId/history)
+func (h *ChatHandler) GetHistory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	messageID, err := strconv.ParseInt(c.Param("messageId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message ID"})
+		return
+	}
+
+	history, err := h.chatService.GetHistory(c.Request.Context(), roomID, messageID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
 func (h *ChatHandler) DeleteMessage(c *gin.Context) {
 	messageID, err := strconv.ParseInt(c.Param("messageId"), 10, 64)
 	if err != nil {
@@ -119,10 +227,140 @@ func (h *ChatHandler) DeleteMessage(c *gin.Context) {
 	}
 
 	if err := h.chatService.DeleteMessage(c.Request.Context(), messageID, userID.(uuid.UUID)); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Message deleted"})
 }
 
+// ExportTranscript - выгрузка истории чата для хоста и co-host
+// (GET /api/v1/rooms/:id/chat/export?format=json|markdown|text). Сообщения пишутся в ответ потоком.
+func (h *ChatHandler) ExportTranscript(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", service.TranscriptFormatJSON)
+	transcript, err := h.chatService.ExportTranscript(c.Request.Context(), roomID, userID.(uuid.UUID), format)
+	if err != nil {
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", transcript.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="`+transcript.FileName+`"`)
+	c.Status(http.StatusOK)
+
+	// Статус уже отправлен, поэтому ошибку посреди выгрузки остается только залогировать
+	if err := transcript.Stream(c.Request.Context(), c.Writer); err != nil {
+		h.log.Error("Failed to export chat transcript", "error", err, "room_id", roomID)
+	}
+}
+
+type UpdateChatControlsRequest struct {
+	Enabled *bool `json:"enabled"`
+	// 0 отключает медленный режим
+	SlowModeSeconds *int `json:"slow_mode_seconds" binding:"omitempty,min=0,max=3600"`
+}
+
+// GetControls - ограничения чата комнаты (GET /api/v1/rooms/:id/chat/controls)
+func (h *ChatHandler) GetControls(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	controls, err := h.chatService.GetControls(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, controls)
+}
+
+// UpdateControls - включить/отключить чат и медленный режим (PATCH /api/v1/rooms/:id/chat/controls)
+func (h *ChatHandler) UpdateControls(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req UpdateChatControlsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	controls, err := h.chatService.UpdateControls(c.Request.Context(), roomID, userID.(uuid.UUID), service.UpdateChatControlsParams{
+		Enabled:         req.Enabled,
+		SlowModeSeconds: req.SlowModeSeconds,
+	})
+	if err != nil {
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, controls)
+}
+
+// DisableParticipant - отключить чат участнику (POST /api/v1/rooms/:id/chat/participants/:participantId/disable)
+func (h *ChatHandler) DisableParticipant(c *gin.Context) {
+	h.setParticipantChat(c, false)
+}
+
+// EnableParticipant - вернуть участнику чат (POST /api/v1/rooms/:id/chat/participants/:participantId/enable)
+func (h *ChatHandler) EnableParticipant(c *gin.Context) {
+	h.setParticipantChat(c, true)
+}
+
+func (h *ChatHandler) setParticipantChat(c *gin.Context, enabled bool) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	participantID, err := uuid.Parse(c.Param("participantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid participant ID"})
+		return
+	}
+
+	controls, err := h.chatService.SetParticipantChat(c.Request.Context(), roomID, participantID, userID.(uuid.UUID), enabled)
+	if err != nil {
+		c.JSON(chatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, controls)
+}
+
+func chatErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "message not found", "reply target not found", "attachment not found", "participant not found", "recipient not found":
+		return http.StatusNotFound
+	case "only sender can edit message", "edit window has expired", "only host or co-host can view message history",
+		"only sender or moderator can delete message", "only host or co-host can manage chat", "not a room participant",
+		"only host or co-host can export chat",
+		"chat is disabled", "chat is disabled for participant", "cannot restrict host or co-host chat":
+		return http.StatusForbidden
+	case "attachment already sent":
+		return http.StatusConflict
+	case "slow mode is enabled":
+		return http.StatusTooManyRequests
+	case "failed to get attachments", "failed to get message history", "failed to update room", "failed to send message":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
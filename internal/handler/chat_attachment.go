@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+	"video_conference/pkg/storage"
+)
+
+// Запас на заголовки multipart сверх максимального размера файла
+const multipartOverhead = 1 << 20
+
+type ChatAttachmentHandler struct {
+	attachmentService service.ChatAttachmentService
+	maxSize           int64
+	log               logger.Logger
+}
+
+func NewChatAttachmentHandler(attachmentService service.ChatAttachmentService, maxSize int64, log logger.Logger) *ChatAttachmentHandler {
+	return &ChatAttachmentHandler{
+		attachmentService: attachmentService,
+		maxSize:           maxSize,
+		log:               log,
+	}
+}
+
+// Upload - загрузить вложение до отправки сообщения (POST /api/v1/rooms/:id/chat/attachments,
+// multipart/form-data с полем file). ID вложения передается в attachment_ids при отправке.
+func (h *ChatAttachmentHandler) Upload(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "attachment is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read attachment"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.Upload(c.Request.Context(), roomID, userID.(uuid.UUID), header.Filename, header.Size, file)
+	if err != nil {
+		c.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// Get - вложение со свежими ссылками на скачивание (GET /api/v1/rooms/:id/chat/attachments/:attachmentId)
+func (h *ChatAttachmentHandler) Get(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment ID"})
+		return
+	}
+
+	attachment, err := h.attachmentService.Get(c.Request.Context(), roomID, attachmentID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// Download - скачать файл локального хранилища по подписанной ссылке (GET /api/v1/files/*key).
+// Доступ проверяется подписью, поэтому endpoint без авторизации.
+func (h *ChatAttachmentHandler) Download(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": storage.ErrInvalidSignature.Error()})
+		return
+	}
+	fileName := c.Query("name")
+
+	file, err := h.attachmentService.OpenFile(key, fileName, expires, c.Query("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrInvalidSignature):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrInvalidKey):
+			c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrNotFound.Error()})
+		default:
+			h.log.Error("Failed to open file", "error", err, "key", key)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		}
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		return
+	}
+
+	// Файлы пользователей не должны исполняться в контексте нашего домена
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "sandbox")
+	c.Header("Cache-Control", "private, max-age=300")
+	if fileName != "" {
+		// Тип по расширению присланного имени доверия не заслуживает: файл только скачивается
+		c.Header("Content-Type", "application/octet-stream")
+		c.Header("Content-Disposition", storage.ContentDisposition(fileName))
+	}
+	http.ServeContent(c.Writer, c.Request, fileName, info.ModTime(), file)
+}
+
+func attachmentErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "attachment not found":
+		return http.StatusNotFound
+	case "not a room participant":
+		return http.StatusForbidden
+	case "room is not available":
+		return http.StatusConflict
+	case "attachment is too large", "image dimensions are too large":
+		return http.StatusRequestEntityTooLarge
+	case "attachment type is not allowed":
+		return http.StatusUnsupportedMediaType
+	case "failed to store attachment", "failed to create thumbnail", "failed to sign attachment url":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
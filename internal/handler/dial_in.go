@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+type DialInHandler struct {
+	dialInService service.DialInService
+	log           logger.Logger
+}
+
+func NewDialInHandler(dialInService service.DialInService, log logger.Logger) *DialInHandler {
+	return &DialInHandler{
+		dialInService: dialInService,
+		log:           log,
+	}
+}
+
+// Get - номер и PIN комнаты (GET /api/v1/rooms/:id/dial-in)
+func (h *DialInHandler) Get(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	dialIn, err := h.dialInService.Get(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(dialInErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dialIn)
+}
+
+// Enable - включение входа по телефону (POST /api/v1/rooms/:id/dial-in)
+func (h *DialInHandler) Enable(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	dialIn, err := h.dialInService.Enable(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(dialInErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dialIn)
+}
+
+// Disable - отключение входа по телефону (DELETE /api/v1/rooms/:id/dial-in)
+func (h *DialInHandler) Disable(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	if err := h.dialInService.Disable(c.Request.Context(), roomID, userID.(uuid.UUID)); err != nil {
+		c.JSON(dialInErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "dial-in disabled"})
+}
+
+func dialInErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "dial-in not found":
+		return http.StatusNotFound
+	case "only host or co-host can manage invites":
+		return http.StatusForbidden
+	case "room is not available":
+		return http.StatusConflict
+	case "dial-in is not configured":
+		return http.StatusServiceUnavailable
+	case "failed to configure dial-in":
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
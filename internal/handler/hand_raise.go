@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+type HandRaiseHandler struct {
+	handService service.HandRaiseService
+	log         logger.Logger
+}
+
+func NewHandRaiseHandler(handService service.HandRaiseService, log logger.Logger) *HandRaiseHandler {
+	return &HandRaiseHandler{
+		handService: handService,
+		log:         log,
+	}
+}
+
+type NextSpeakerRequest struct {
+	// Разрешить выступающему публиковать камеру и микрофон
+	GrantPublish bool `json:"grant_publish"`
+}
+
+// Queue - очередь поднятых рук (GET /api/v1/rooms/:id/hands)
+func (h *HandRaiseHandler) Queue(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	queue, err := h.handService.Queue(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(handErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// Raise - поднять руку (POST /api/v1/rooms/:id/hands)
+func (h *HandRaiseHandler) Raise(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	queue, err := h.handService.Raise(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(handErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// Lower - опустить свою руку (DELETE /api/v1/rooms/:id/hands)
+func (h *HandRaiseHandler) Lower(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	if err := h.handService.Lower(c.Request.Context(), roomID, userID.(uuid.UUID)); err != nil {
+		c.JSON(handErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hand lowered"})
+}
+
+// LowerParticipant - опустить руку участника (DELETE /api/v1/rooms/:id/hands/:participantId)
+func (h *HandRaiseHandler) LowerParticipant(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	participantID, err := uuid.Parse(c.Param("participantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid participant ID"})
+		return
+	}
+
+	if err := h.handService.LowerParticipant(c.Request.Context(), roomID, participantID, userID.(uuid.UUID)); err != nil {
+		c.JSON(handErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hand lowered"})
+}
+
+// LowerAll - опустить все руки (POST /api/v1/rooms/:id/hands/lower-all)
+func (h *HandRaiseHandler) LowerAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	if err := h.handService.LowerAll(c.Request.Context(), roomID, userID.(uuid.UUID)); err != nil {
+		c.JSON(handErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All hands lowered"})
+}
+
+// NextSpeaker - следующий выступающий из очереди (POST /api/v1/rooms/:id/hands/next)
+func (h *HandRaiseHandler) NextSpeaker(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req NextSpeakerRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	speaker, err := h.handService.NextSpeaker(c.Request.Context(), roomID, userID.(uuid.UUID), req.GrantPublish)
+	if err != nil {
+		c.JSON(handErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, speaker)
+}
+
+func handErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "participant not found":
+		return http.StatusNotFound
+	case "only host or co-host can moderate room", "not a room participant":
+		return http.StatusForbidden
+	case "room is not available", "hand is not raised", "hand queue is empty", "participant is not connected":
+		return http.StatusConflict
+	case "failed to update participant permissions":
+		return http.StatusBadGateway
+	case "failed to raise hand", "failed to lower hand", "failed to lower hands", "failed to get hand queue", "failed to update hand queue":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
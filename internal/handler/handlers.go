@@ -14,6 +14,7 @@ type Handlers struct {
 	Room             *RoomHandler
 	WaitingRoom      *WaitingRoomHandler
 	Chat             *ChatHandler
+	ChatAttachment   *ChatAttachmentHandler
 	AnonymousChat    *AnonymousChatHandler
 	Media            *MediaHandler
 	AnonymousMedia   *AnonymousMediaHandler
@@ -21,6 +22,18 @@ type Handlers struct {
 	Stats            *StatsHandler
 	WebSocket        *WebSocketHandler
 	ScreenShare      *ScreenShareHandler
+	Invite           *InviteHandler
+	Moderation       *ModerationHandler
+	LiveKitWebhook   *LiveKitWebhookHandler
+	RoomSeries       *RoomSeriesHandler
+	Calendar         *CalendarHandler
+	Recording        *RecordingHandler
+	LiveStream       *LiveStreamHandler
+	DialIn           *DialInHandler
+	Breakout         *BreakoutHandler
+	Poll             *PollHandler
+	HandRaise        *HandRaiseHandler
+	Reaction         *ReactionHandler
 }
 
 func NewHandlers(services *service.Services, repos *repository.Repositories, cfg *config.Config, log logger.Logger) *Handlers {
@@ -28,13 +41,26 @@ func NewHandlers(services *service.Services, repos *repository.Repositories, cfg
 		Health:      NewHealthHandler(cfg),
 		Auth:        NewAuthHandler(services.Auth, log),
 		User:        NewUserHandler(services.User, log),
-		Room:        NewRoomHandler(services.Room, log),
-		WaitingRoom: NewWaitingRoomHandler(services.Room, log),
+		Room:        NewRoomHandler(services.Room, services.RoomLifecycle, log),
+		WaitingRoom: NewWaitingRoomHandler(services.WaitingRoom, services.Realtime, log),
 		Chat:        NewChatHandler(services.Chat, log),
+		ChatAttachment: NewChatAttachmentHandler(services.ChatAttachment, cfg.Chat.AttachmentMaxSize, log),
 		Media:       NewMediaHandler(services.Media, log),
 		Stats:       NewStatsHandler(services.Stats, log),
-		WebSocket:   NewWebSocketHandler(services.Chat, log),
+		WebSocket:   NewWebSocketHandler(services.Chat, services.Room, services.Realtime, log),
 		ScreenShare: NewScreenShareHandler(services.ScreenCapture, services.AudioCapture, services.WebRTC, log),
+		Invite:      NewInviteHandler(services.Room, services.Media, log),
+		Moderation:  NewModerationHandler(services.Moderation, log),
+		LiveKitWebhook: NewLiveKitWebhookHandler(services.LiveKitWebhook, cfg.LiveKit, log),
+		RoomSeries:     NewRoomSeriesHandler(services.RoomSeries, log),
+		Calendar:       NewCalendarHandler(services.Calendar, log),
+		Recording:      NewRecordingHandler(services.Recording, log),
+		LiveStream:     NewLiveStreamHandler(services.LiveStream, log),
+		DialIn:         NewDialInHandler(services.DialIn, log),
+		Breakout:       NewBreakoutHandler(services.Breakout, services.Media, log),
+		Poll:           NewPollHandler(services.Poll, log),
+		HandRaise:      NewHandRaiseHandler(services.HandRaise, log),
+		Reaction:       NewReactionHandler(services.Reaction, log),
 	}
 	
 	// Инициализируем анонимные handlers если сервисы доступны
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InviteHandler struct {
+	roomService  service.RoomService
+	mediaService service.MediaService
+	log          logger.Logger
+}
+
+func NewInviteHandler(roomService service.RoomService, mediaService service.MediaService, log logger.Logger) *InviteHandler {
+	return &InviteHandler{
+		roomService:  roomService,
+		mediaService: mediaService,
+		log:          log,
+	}
+}
+
+type RedeemInviteRequest struct {
+	DisplayName string `json:"display_name"`
+}
+
+// Redeem - вход в комнату по приглашению (POST /api/v1/invites/:token/redeem).
+// Держатель приглашения проходит мимо waiting room и сразу получает LiveKit токен.
+func (h *InviteHandler) Redeem(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	token := c.Param("token")
+
+	var req RedeemInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Если имя не передано, используем имя из токена Auth-сервиса
+	displayName := req.DisplayName
+	if displayName == "" {
+		displayName = c.GetString("user_display_name")
+	}
+	displayName = normalizeDisplayName(displayName)
+
+	room, participant, err := h.roomService.RedeemInvite(c.Request.Context(), token, userID.(uuid.UUID), displayName)
+	if err != nil {
+		c.JSON(inviteErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	livekitToken, url, err := h.mediaService.GetToken(c.Request.Context(), room.ID, userID.(uuid.UUID), participant.DisplayName)
+	if err != nil {
+		h.log.Error("Failed to issue LiveKit token for invite", "error", err, "room_id", room.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"room":        room,
+		"participant": participant,
+		"token":       livekitToken,
+		"url":         url,
+	})
+}
+
+func (h *InviteHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	invites, err := h.roomService.ListInvites(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(inviteErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+func (h *InviteHandler) Revoke(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	inviteID, err := uuid.Parse(c.Param("inviteId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite ID"})
+		return
+	}
+
+	if err := h.roomService.RevokeInvite(c.Request.Context(), roomID, inviteID, userID.(uuid.UUID)); err != nil {
+		c.JSON(inviteErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite revoked"})
+}
+
+func inviteErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "invite not found":
+		return http.StatusNotFound
+	case "only host or co-host can manage invites", "room is locked", "you are banned from this room":
+		return http.StatusForbidden
+	case "invite revoked", "invite expired", "invite usage limit reached", "invite is no longer valid":
+		return http.StatusGone
+	case "room is full":
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+type LiveStreamHandler struct {
+	streamService service.LiveStreamService
+	log           logger.Logger
+}
+
+func NewLiveStreamHandler(streamService service.LiveStreamService, log logger.Logger) *LiveStreamHandler {
+	return &LiveStreamHandler{
+		streamService: streamService,
+		log:           log,
+	}
+}
+
+type StartStreamRequest struct {
+	// rtmp или hls
+	Protocol string `json:"protocol" binding:"required"`
+	// Адрес RTMP сервера без ключа, например rtmp://a.rtmp.youtube.com/live2
+	RTMPURL   string `json:"rtmp_url,omitempty"`
+	StreamKey string `json:"stream_key,omitempty"`
+}
+
+// Start - запуск трансляции (POST /api/v1/rooms/:id/streams)
+func (h *LiveStreamHandler) Start(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req StartStreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stream, err := h.streamService.Start(c.Request.Context(), roomID, userID.(uuid.UUID), service.StartStreamParams{
+		Protocol:  req.Protocol,
+		RTMPURL:   req.RTMPURL,
+		StreamKey: req.StreamKey,
+	})
+	if err != nil {
+		c.JSON(streamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, stream)
+}
+
+// Stop - остановка трансляции (POST /api/v1/rooms/:id/streams/:streamId/stop)
+func (h *LiveStreamHandler) Stop(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	streamID, err := uuid.Parse(c.Param("streamId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid stream ID"})
+		return
+	}
+
+	stream, err := h.streamService.Stop(c.Request.Context(), roomID, streamID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(streamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stream)
+}
+
+// List - трансляции комнаты (GET /api/v1/rooms/:id/streams)
+func (h *LiveStreamHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	streams, err := h.streamService.List(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(streamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, streams)
+}
+
+func streamErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "stream not found":
+		return http.StatusNotFound
+	case "only host can manage streams":
+		return http.StatusForbidden
+	case "room is not available", "stream already in progress", "stream is not active":
+		return http.StatusConflict
+	case "failed to start stream", "failed to stop egress":
+		return http.StatusBadGateway
+	case "stream key encryption is not configured", "failed to encrypt stream key", "failed to save stream", "failed to update stream":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/webhook"
+	"video_conference/internal/config"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+type LiveKitWebhookHandler struct {
+	webhookService service.LiveKitWebhookService
+	keyProvider    auth.KeyProvider
+	log            logger.Logger
+}
+
+func NewLiveKitWebhookHandler(webhookService service.LiveKitWebhookService, cfg config.LiveKitConfig, log logger.Logger) *LiveKitWebhookHandler {
+	return &LiveKitWebhookHandler{
+		webhookService: webhookService,
+		keyProvider:    auth.NewSimpleKeyProvider(cfg.APIKey, cfg.APISecret),
+		log:            log,
+	}
+}
+
+// Receive - прием webhook-ов LiveKit (POST /api/v1/webhooks/livekit).
+// Подпись проверяется по API ключу LiveKit; при ошибке обработки возвращаем 500, чтобы LiveKit повторил доставку.
+func (h *LiveKitWebhookHandler) Receive(c *gin.Context) {
+	event, err := webhook.ReceiveWebhookEvent(c.Request, h.keyProvider)
+	if err != nil {
+		h.log.Warn("Rejected LiveKit webhook", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid webhook signature"})
+		return
+	}
+
+	if err := h.webhookService.HandleEvent(c.Request.Context(), event); err != nil {
+		h.log.Error("Failed to handle LiveKit webhook", "error", err, "event", event.Event, "id", event.Id)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to handle event"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"io"
 	"net/http"
 
 	"video_conference/internal/service"
@@ -23,7 +24,7 @@ func NewMediaHandler(mediaService service.MediaService, log logger.Logger) *Medi
 }
 
 type GetTokenRequest struct {
-	DisplayName string `json:"display_name" binding:"required"`
+	DisplayName string `json:"display_name"`
 }
 
 func (h *MediaHandler) GetToken(c *gin.Context) {
@@ -35,16 +36,108 @@ func (h *MediaHandler) GetToken(c *gin.Context) {
 	}
 
 	var req GetTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	token, url, err := h.mediaService.GetToken(c.Request.Context(), roomID, userID.(uuid.UUID), req.DisplayName)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(roomAccessErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"token": token, "url": url})
 }
+
+type CreateIngressRequest struct {
+	// rtmp или whip
+	InputType string `json:"input_type" binding:"required"`
+	// Имя ведущего в комнате
+	Name string `json:"name,omitempty"`
+}
+
+// CreateIngress - вход для внешнего кодировщика (POST /api/v1/rooms/:id/ingress)
+func (h *MediaHandler) CreateIngress(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req CreateIngressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ingress, err := h.mediaService.CreateIngress(c.Request.Context(), roomID, userID.(uuid.UUID), service.CreateIngressParams{
+		InputType: req.InputType,
+		Name:      req.Name,
+	})
+	if err != nil {
+		c.JSON(ingressErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ingress)
+}
+
+// ListIngresses - входы комнаты (GET /api/v1/rooms/:id/ingress)
+func (h *MediaHandler) ListIngresses(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	ingresses, err := h.mediaService.ListIngresses(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(ingressErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ingresses)
+}
+
+// RevokeIngress - отзыв входа (DELETE /api/v1/rooms/:id/ingress/:ingressId)
+func (h *MediaHandler) RevokeIngress(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	ingressID, err := uuid.Parse(c.Param("ingressId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ingress ID"})
+		return
+	}
+
+	if err := h.mediaService.RevokeIngress(c.Request.Context(), roomID, ingressID, userID.(uuid.UUID)); err != nil {
+		c.JSON(ingressErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ingress revoked"})
+}
+
+func ingressErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "ingress not found":
+		return http.StatusNotFound
+	case "only host can manage ingress":
+		return http.StatusForbidden
+	case "room is not available", "ingress already revoked":
+		return http.StatusConflict
+	case "failed to create ingress", "failed to delete ingress":
+		return http.StatusBadGateway
+	case "stream key encryption is not configured", "failed to save ingress", "failed to revoke ingress":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
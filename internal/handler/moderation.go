@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+type ModerationHandler struct {
+	moderationService service.ModerationService
+	log               logger.Logger
+}
+
+func NewModerationHandler(moderationService service.ModerationService, log logger.Logger) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: moderationService,
+		log:               log,
+	}
+}
+
+type KickParticipantRequest struct {
+	Ban    bool    `json:"ban"`
+	Reason *string `json:"reason,omitempty"`
+}
+
+type MuteParticipantRequest struct {
+	// audio, video или all (по умолчанию)
+	Kind string `json:"kind"`
+}
+
+// Kick - исключение участника (POST /api/v1/rooms/:id/participants/:participantId/kick)
+func (h *ModerationHandler) Kick(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, participantID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req KickParticipantRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	participant, err := h.moderationService.Kick(c.Request.Context(), roomID, participantID, userID.(uuid.UUID), req.Ban, req.Reason)
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, participant)
+}
+
+// Mute - принудительное отключение дорожек участника (POST /api/v1/rooms/:id/participants/:participantId/mute)
+func (h *ModerationHandler) Mute(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, participantID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req MuteParticipantRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	muted, err := h.moderationService.Mute(c.Request.Context(), roomID, participantID, userID.(uuid.UUID), req.Kind)
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"muted_tracks": muted})
+}
+
+func (h *ModerationHandler) Lock(c *gin.Context) {
+	h.setLocked(c, true)
+}
+
+func (h *ModerationHandler) Unlock(c *gin.Context) {
+	h.setLocked(c, false)
+}
+
+func (h *ModerationHandler) setLocked(c *gin.Context, locked bool) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	lockFn := h.moderationService.Unlock
+	if locked {
+		lockFn = h.moderationService.Lock
+	}
+
+	room, err := lockFn(ctx, roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, room)
+}
+
+func (h *ModerationHandler) parseIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	participantID, err := uuid.Parse(c.Param("participantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid participant ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return roomID, participantID, true
+}
+
+func moderationErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "participant not found":
+		return http.StatusNotFound
+	case "only host or co-host can moderate room", "cannot moderate room host", "cannot moderate co-host":
+		return http.StatusForbidden
+	case "participant is not in the room":
+		return http.StatusConflict
+	case "failed to remove participant from media server", "failed to mute participant on media server":
+		return http.StatusBadGateway
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+const pollExportContentType = "text/csv; charset=utf-8"
+
+type PollHandler struct {
+	pollService service.PollService
+	log         logger.Logger
+}
+
+func NewPollHandler(pollService service.PollService, log logger.Logger) *PollHandler {
+	return &PollHandler{
+		pollService: pollService,
+		log:         log,
+	}
+}
+
+type CreatePollRequest struct {
+	Question       string   `json:"question" binding:"required"`
+	Options        []string `json:"options" binding:"required"`
+	MultipleChoice bool     `json:"multiple_choice"`
+	Anonymous      bool     `json:"anonymous"`
+}
+
+type VotePollRequest struct {
+	OptionIDs []uuid.UUID `json:"option_ids" binding:"required"`
+}
+
+// Create - новый опрос-черновик (POST /api/v1/rooms/:id/polls)
+func (h *PollHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req CreatePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	poll, err := h.pollService.Create(c.Request.Context(), roomID, userID.(uuid.UUID), service.CreatePollParams{
+		Question:       req.Question,
+		Options:        req.Options,
+		MultipleChoice: req.MultipleChoice,
+		Anonymous:      req.Anonymous,
+	})
+	if err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, poll)
+}
+
+// List - опросы комнаты с результатами (GET /api/v1/rooms/:id/polls)
+func (h *PollHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	polls, err := h.pollService.List(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, polls)
+}
+
+// Get - опрос с результатами (GET /api/v1/rooms/:id/polls/:pollId)
+func (h *PollHandler) Get(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, pollID, ok := parsePollParams(c)
+	if !ok {
+		return
+	}
+
+	poll, err := h.pollService.Get(c.Request.Context(), roomID, pollID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// Open - начало голосования (POST /api/v1/rooms/:id/polls/:pollId/open)
+func (h *PollHandler) Open(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, pollID, ok := parsePollParams(c)
+	if !ok {
+		return
+	}
+
+	poll, err := h.pollService.Open(c.Request.Context(), roomID, pollID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// Close - завершение голосования (POST /api/v1/rooms/:id/polls/:pollId/close)
+func (h *PollHandler) Close(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, pollID, ok := parsePollParams(c)
+	if !ok {
+		return
+	}
+
+	poll, err := h.pollService.Close(c.Request.Context(), roomID, pollID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// Delete - удаление опроса (DELETE /api/v1/rooms/:id/polls/:pollId)
+func (h *PollHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, pollID, ok := parsePollParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.pollService.Delete(c.Request.Context(), roomID, pollID, userID.(uuid.UUID)); err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Poll deleted"})
+}
+
+// Vote - голос участника (POST /api/v1/rooms/:id/polls/:pollId/votes)
+func (h *PollHandler) Vote(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, pollID, ok := parsePollParams(c)
+	if !ok {
+		return
+	}
+
+	var req VotePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	poll, err := h.pollService.Vote(c.Request.Context(), roomID, pollID, userID.(uuid.UUID), req.OptionIDs)
+	if err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// Export - результаты опросов в CSV (GET /api/v1/rooms/:id/polls/export)
+func (h *PollHandler) Export(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	data, err := h.pollService.Export(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="polls-`+roomID.String()+`.csv"`)
+	c.Data(http.StatusOK, pollExportContentType, data)
+}
+
+func parsePollParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	pollID, err := uuid.Parse(c.Param("pollId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid poll ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return roomID, pollID, true
+}
+
+func pollErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "poll not found":
+		return http.StatusNotFound
+	case "only host or co-host can manage polls", "not a room participant":
+		return http.StatusForbidden
+	case "room is not available", "poll already opened", "poll is not open":
+		return http.StatusConflict
+	case "failed to create poll", "failed to update poll", "failed to delete poll", "failed to save vote", "failed to export polls":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
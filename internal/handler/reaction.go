@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+type ReactionHandler struct {
+	reactionService service.ReactionService
+	log             logger.Logger
+}
+
+func NewReactionHandler(reactionService service.ReactionService, log logger.Logger) *ReactionHandler {
+	return &ReactionHandler{
+		reactionService: reactionService,
+		log:             log,
+	}
+}
+
+type SendReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+// Send - реакция участника (POST /api/v1/rooms/:id/reactions)
+func (h *ReactionHandler) Send(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req SendReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reaction, err := h.reactionService.Send(c.Request.Context(), roomID, userID.(uuid.UUID), req.Emoji)
+	if err != nil {
+		c.JSON(reactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reaction)
+}
+
+// Stats - реакции комнаты по минутам (GET /api/v1/rooms/:id/stats/reactions)
+func (h *ReactionHandler) Stats(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	stats, err := h.reactionService.Stats(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(reactionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func reactionErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found":
+		return http.StatusNotFound
+	case "not a room participant":
+		return http.StatusForbidden
+	case "room is not available":
+		return http.StatusConflict
+	case "too many reactions":
+		return http.StatusTooManyRequests
+	case "failed to send reaction", "failed to get reaction stats":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+type RecordingHandler struct {
+	recordingService service.RecordingService
+	log              logger.Logger
+}
+
+func NewRecordingHandler(recordingService service.RecordingService, log logger.Logger) *RecordingHandler {
+	return &RecordingHandler{
+		recordingService: recordingService,
+		log:              log,
+	}
+}
+
+type StartRecordingRequest struct {
+	// SID дорожки для записи одной дорожки, пусто - запись всей комнаты
+	TrackSID  string `json:"track_sid,omitempty"`
+	AudioOnly bool   `json:"audio_only"`
+}
+
+// Start - запуск записи (POST /api/v1/rooms/:id/recordings)
+func (h *RecordingHandler) Start(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	var req StartRecordingRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recording, err := h.recordingService.Start(c.Request.Context(), roomID, userID.(uuid.UUID), service.StartRecordingParams{
+		TrackSID:  req.TrackSID,
+		AudioOnly: req.AudioOnly,
+	})
+	if err != nil {
+		c.JSON(recordingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, recording)
+}
+
+// Stop - остановка записи (POST /api/v1/rooms/:id/recordings/:recordingId/stop)
+func (h *RecordingHandler) Stop(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	recordingID, err := uuid.Parse(c.Param("recordingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recording ID"})
+		return
+	}
+
+	recording, err := h.recordingService.Stop(c.Request.Context(), roomID, recordingID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(recordingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recording)
+}
+
+// List - записи комнаты (GET /api/v1/rooms/:id/recordings)
+func (h *RecordingHandler) List(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	recordings, err := h.recordingService.List(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(recordingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recordings)
+}
+
+func recordingErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "recording not found":
+		return http.StatusNotFound
+	case "only host can manage recordings":
+		return http.StatusForbidden
+	case "room is not available", "recording already in progress", "recording is not active":
+		return http.StatusConflict
+	case "failed to start recording", "failed to stop egress":
+		return http.StatusBadGateway
+	default:
+		return http.StatusBadRequest
+	}
+}
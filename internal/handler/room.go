@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"io"
 	"net/http"
+	"time"
 
 	"video_conference/internal/service"
 	"video_conference/pkg/logger"
@@ -11,14 +13,16 @@ import (
 )
 
 type RoomHandler struct {
-	roomService service.RoomService
-	log         logger.Logger
+	roomService      service.RoomService
+	lifecycleService service.RoomLifecycleService
+	log              logger.Logger
 }
 
-func NewRoomHandler(roomService service.RoomService, log logger.Logger) *RoomHandler {
+func NewRoomHandler(roomService service.RoomService, lifecycleService service.RoomLifecycleService, log logger.Logger) *RoomHandler {
 	return &RoomHandler{
-		roomService: roomService,
-		log:         log,
+		roomService:      roomService,
+		lifecycleService: lifecycleService,
+		log:              log,
 	}
 }
 
@@ -26,6 +30,9 @@ type CreateRoomRequest struct {
 	Title           string  `json:"title" binding:"required"`
 	Description     *string `json:"description,omitempty"`
 	MaxParticipants int     `json:"max_participants"`
+	// Расписание встречи (RFC 3339), необязательно
+	ScheduledStartAt *time.Time `json:"scheduled_start_at,omitempty"`
+	ScheduledEndAt   *time.Time `json:"scheduled_end_at,omitempty"`
 }
 
 func (h *RoomHandler) Create(c *gin.Context) {
@@ -36,7 +43,7 @@ func (h *RoomHandler) Create(c *gin.Context) {
 		return
 	}
 
-	room, err := h.roomService.Create(c.Request.Context(), userID.(uuid.UUID), req.Title, req.Description, req.MaxParticipants)
+	room, err := h.roomService.Create(c.Request.Context(), userID.(uuid.UUID), req.Title, req.Description, req.MaxParticipants, req.ScheduledStartAt, req.ScheduledEndAt)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -76,6 +83,13 @@ type UpdateRoomRequest struct {
 	Title           *string `json:"title,omitempty"`
 	Description     *string `json:"description,omitempty"`
 	MaxParticipants *int    `json:"max_participants,omitempty"`
+	// Пустая строка снимает пароль с комнаты
+	Password *string `json:"password,omitempty"`
+	// Частичное обновление настроек, null удаляет ключ
+	Settings map[string]interface{} `json:"settings,omitempty"`
+	// Перенос встречи; начало меняется только до старта
+	ScheduledStartAt *time.Time `json:"scheduled_start_at,omitempty"`
+	ScheduledEndAt   *time.Time `json:"scheduled_end_at,omitempty"`
 }
 
 func (h *RoomHandler) Update(c *gin.Context) {
@@ -92,7 +106,7 @@ func (h *RoomHandler) Update(c *gin.Context) {
 		return
 	}
 
-	room, err := h.roomService.Update(c.Request.Context(), roomID, userID.(uuid.UUID), req.Title, req.Description, req.MaxParticipants)
+	room, err := h.roomService.Update(c.Request.Context(), roomID, userID.(uuid.UUID), req.Title, req.Description, req.MaxParticipants, req.Password, req.Settings, req.ScheduledStartAt, req.ScheduledEndAt)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -117,8 +131,37 @@ func (h *RoomHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Room deleted"})
 }
 
+// Cancel - отмена встречи хостом (POST /api/v1/rooms/:id/cancel)
+func (h *RoomHandler) Cancel(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	room, err := h.lifecycleService.Cancel(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case "room not found":
+			status = http.StatusNotFound
+		case "only host can cancel room":
+			status = http.StatusForbidden
+		case "room is not available":
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, room)
+}
+
 type JoinRoomRequest struct {
 	DisplayName string `json:"display_name" binding:"required"`
+	Password    string `json:"password,omitempty"`
+	InviteToken string `json:"invite_token,omitempty"`
 }
 
 func (h *RoomHandler) Join(c *gin.Context) {
@@ -135,18 +178,21 @@ func (h *RoomHandler) Join(c *gin.Context) {
 		return
 	}
 
-	participant, err := h.roomService.Join(c.Request.Context(), roomID, userID.(uuid.UUID), req.DisplayName)
+	creds := service.JoinCredentials{
+		Password:    req.Password,
+		InviteToken: req.InviteToken,
+		ClientIP:    c.ClientIP(),
+	}
+
+	participant, entry, err := h.roomService.Join(c.Request.Context(), roomID, userID.(uuid.UUID), req.DisplayName, creds)
 	if err != nil {
-		if err.Error() == "waiting for approval" {
-			c.JSON(http.StatusAccepted, gin.H{"message": "Waiting for approval"})
-			return
-		}
-		// Check if room not found - return 404
-		if err.Error() == "room not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
-			return
-		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(roomAccessErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if entry != nil {
+		// Решение хоста придет через GET /rooms/:id/waiting-room/:entryId/events
+		c.JSON(http.StatusAccepted, gin.H{"message": "Waiting for approval", "entry": entry})
 		return
 	}
 
@@ -169,6 +215,12 @@ func (h *RoomHandler) Leave(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Left room"})
 }
 
+type CreateInviteRequest struct {
+	Label     *string    `json:"label,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxUses   *int       `json:"max_uses,omitempty" binding:"omitempty,min=1"`
+}
+
 func (h *RoomHandler) CreateInvite(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	roomID, err := uuid.Parse(c.Param("id"))
@@ -177,8 +229,14 @@ func (h *RoomHandler) CreateInvite(c *gin.Context) {
 		return
 	}
 
-	// TODO: добавить параметры для invite
-	invite, err := h.roomService.CreateInvite(c.Request.Context(), roomID, userID.(uuid.UUID), nil, nil, nil)
+	// Все параметры приглашения опциональны, тело запроса может отсутствовать
+	var req CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invite, err := h.roomService.CreateInvite(c.Request.Context(), roomID, userID.(uuid.UUID), req.Label, req.ExpiresAt, req.MaxUses)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -202,3 +260,69 @@ func (h *RoomHandler) GetParticipants(c *gin.Context) {
 
 	c.JSON(http.StatusOK, participants)
 }
+
+// PromoteCoHost - назначение co-host (POST /api/v1/rooms/:id/participants/:participantId/co-host)
+func (h *RoomHandler) PromoteCoHost(c *gin.Context) {
+	h.setCoHost(c, true)
+}
+
+// DemoteCoHost - снятие co-host (DELETE /api/v1/rooms/:id/participants/:participantId/co-host)
+func (h *RoomHandler) DemoteCoHost(c *gin.Context) {
+	h.setCoHost(c, false)
+}
+
+func (h *RoomHandler) setCoHost(c *gin.Context, promote bool) {
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	participantID, err := uuid.Parse(c.Param("participantId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid participant ID"})
+		return
+	}
+
+	roleFn := h.roomService.DemoteCoHost
+	if promote {
+		roleFn = h.roomService.PromoteCoHost
+	}
+
+	participant, err := roleFn(c.Request.Context(), roomID, participantID, userID.(uuid.UUID))
+	if err != nil {
+		var status int
+		switch err.Error() {
+		case "room not found", "participant not found":
+			status = http.StatusNotFound
+		case "only host can manage co-hosts", "cannot change host role":
+			status = http.StatusForbidden
+		case "participant is not in the room":
+			status = http.StatusConflict
+		default:
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, participant)
+}
+
+// roomAccessErrorStatus - статус ответа при отказе во входе в комнату
+func roomAccessErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found":
+		return http.StatusNotFound
+	case "room password required", "invalid room password", "room is locked", "room has not started yet", "you are banned from this room", "not a room participant",
+		"breakout rooms are joined via breakout token":
+		return http.StatusForbidden
+	case "room is full":
+		return http.StatusConflict
+	case "too many password attempts":
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadRequest
+	}
+}
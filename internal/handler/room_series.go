@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"video_conference/internal/domain"
+	"video_conference/internal/service"
+	"video_conference/pkg/logger"
+)
+
+type RoomSeriesHandler struct {
+	seriesService service.RoomSeriesService
+	log           logger.Logger
+}
+
+func NewRoomSeriesHandler(seriesService service.RoomSeriesService, log logger.Logger) *RoomSeriesHandler {
+	return &RoomSeriesHandler{
+		seriesService: seriesService,
+		log:           log,
+	}
+}
+
+type CreateSeriesRequest struct {
+	Title              string                 `json:"title" binding:"required"`
+	Description        *string                `json:"description,omitempty"`
+	MaxParticipants    int                    `json:"max_participants"`
+	WaitingRoomEnabled *bool                  `json:"waiting_room_enabled,omitempty"`
+	Settings           map[string]interface{} `json:"settings,omitempty"`
+	// Правило повторения, например "FREQ=WEEKLY;BYDAY=MO,WE,FR;UNTIL=20261231"
+	RRule           string    `json:"rrule" binding:"required"`
+	StartAt         time.Time `json:"start_at" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes" binding:"required"`
+	// IANA зона для дней недели и времени начала, по умолчанию UTC
+	Timezone string `json:"timezone,omitempty"`
+}
+
+type SeriesResponse struct {
+	Series      *domain.RoomSeries `json:"series"`
+	Occurrences []*domain.Room     `json:"occurrences"`
+}
+
+// Create - создание серии повторяющихся встреч (POST /api/v1/series)
+func (h *RoomSeriesHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, occurrences, err := h.seriesService.Create(c.Request.Context(), userID.(uuid.UUID), service.CreateSeriesParams{
+		Title:              req.Title,
+		Description:        req.Description,
+		MaxParticipants:    req.MaxParticipants,
+		WaitingRoomEnabled: req.WaitingRoomEnabled,
+		Settings:           req.Settings,
+		RRule:              req.RRule,
+		StartAt:            req.StartAt,
+		DurationMinutes:    req.DurationMinutes,
+		Timezone:           req.Timezone,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newSeriesResponse(series, occurrences))
+}
+
+// GetByID - серия и ее вхождения (GET /api/v1/series/:id)
+func (h *RoomSeriesHandler) GetByID(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	seriesID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid series ID"})
+		return
+	}
+
+	series, occurrences, err := h.seriesService.GetByID(c.Request.Context(), seriesID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(seriesErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newSeriesResponse(series, occurrences))
+}
+
+// Cancel - отмена серии и ее будущих вхождений (DELETE /api/v1/series/:id)
+func (h *RoomSeriesHandler) Cancel(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	seriesID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid series ID"})
+		return
+	}
+
+	if err := h.seriesService.Cancel(c.Request.Context(), seriesID, userID.(uuid.UUID)); err != nil {
+		c.JSON(seriesErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series cancelled"})
+}
+
+// ListUpcoming - предстоящие встречи пользователя (GET /api/v1/series/upcoming?limit=20)
+func (h *RoomSeriesHandler) ListUpcoming(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	rooms, err := h.seriesService.ListUpcoming(c.Request.Context(), userID.(uuid.UUID), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rooms)
+}
+
+func newSeriesResponse(series *domain.RoomSeries, occurrences []*domain.Room) *SeriesResponse {
+	if occurrences == nil {
+		occurrences = []*domain.Room{}
+	}
+	return &SeriesResponse{Series: series, Occurrences: occurrences}
+}
+
+func seriesErrorStatus(err error) int {
+	switch err.Error() {
+	case "series not found":
+		return http.StatusNotFound
+	case "only host can manage series":
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -1,42 +1,222 @@
 package handler
 
 import (
+	"encoding/json"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"video_conference/internal/domain"
 	"video_conference/internal/service"
 	"video_conference/pkg/logger"
 )
 
+// Интервал keep-alive комментариев в SSE потоке
+const sseKeepAliveInterval = 25 * time.Second
+
 type WaitingRoomHandler struct {
-	roomService service.RoomService
-	log         logger.Logger
+	waitingRoomService service.WaitingRoomService
+	realtimeService    service.RealtimeService
+	log                logger.Logger
 }
 
-func NewWaitingRoomHandler(roomService service.RoomService, log logger.Logger) *WaitingRoomHandler {
+func NewWaitingRoomHandler(waitingRoomService service.WaitingRoomService, realtimeService service.RealtimeService, log logger.Logger) *WaitingRoomHandler {
 	return &WaitingRoomHandler{
-		roomService: roomService,
-		log:         log,
+		waitingRoomService: waitingRoomService,
+		realtimeService:    realtimeService,
+		log:                log,
 	}
 }
 
+type WaitingRoomDecisionRequest struct {
+	Reason *string `json:"reason,omitempty"`
+}
+
 func (h *WaitingRoomHandler) List(c *gin.Context) {
-	// TODO: реализовать получение списка ожидающих
-	c.JSON(http.StatusOK, gin.H{"message": "Not implemented"})
+	userID, _ := c.Get("user_id")
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return
+	}
+
+	entries, err := h.waitingRoomService.List(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(waitingRoomErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetEntry возвращает гостю его заявку (для клиентов без SSE)
+func (h *WaitingRoomHandler) GetEntry(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, entryID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	entry, err := h.waitingRoomService.GetOwnEntry(c.Request.Context(), roomID, entryID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(waitingRoomErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
 }
 
 func (h *WaitingRoomHandler) Approve(c *gin.Context) {
-	// TODO: реализовать одобрение входа
-	entryID, _ := uuid.Parse(c.Param("entryId"))
-	_ = entryID
-	c.JSON(http.StatusOK, gin.H{"message": "Not implemented"})
+	userID, _ := c.Get("user_id")
+	roomID, entryID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req WaitingRoomDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, participant, err := h.waitingRoomService.Approve(c.Request.Context(), roomID, entryID, userID.(uuid.UUID), req.Reason)
+	if err != nil {
+		c.JSON(waitingRoomErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entry": entry, "participant": participant})
 }
 
 func (h *WaitingRoomHandler) Reject(c *gin.Context) {
-	// TODO: реализовать отклонение входа
-	entryID, _ := uuid.Parse(c.Param("entryId"))
-	_ = entryID
-	c.JSON(http.StatusOK, gin.H{"message": "Not implemented"})
+	userID, _ := c.Get("user_id")
+	roomID, entryID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req WaitingRoomDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.waitingRoomService.Reject(c.Request.Context(), roomID, entryID, userID.(uuid.UUID), req.Reason)
+	if err != nil {
+		c.JSON(waitingRoomErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
 }
 
+// Events - SSE поток для гостя в waiting room. Отправляет текущее состояние заявки
+// и завершается, как только по ней принято решение (approved, rejected или expired).
+func (h *WaitingRoomHandler) Events(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	roomID, entryID, ok := h.parseIDs(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	entry, err := h.waitingRoomService.GetOwnEntry(ctx, roomID, entryID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(waitingRoomErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Подписываемся до повторной проверки статуса, чтобы не пропустить решение.
+	// События по заявке адресуются ее ID, другие заявки гость не видит.
+	conn, err := h.realtimeService.Listen(ctx, roomID, entryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer h.realtimeService.Disconnect(ctx, conn)
+
+	if entry.Status == domain.WaitingRoomStatusPending {
+		if fresh, err := h.waitingRoomService.GetOwnEntry(ctx, roomID, entryID, userID.(uuid.UUID)); err == nil {
+			entry = fresh
+		}
+	}
+
+	// SSE соединение живет дольше WriteTimeout сервера
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.log.Warn("Failed to reset write deadline for SSE", "error", err)
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent(domain.RoomEventTypeWaitingRoom, entry)
+	c.Writer.Flush()
+
+	if entry.Status != domain.WaitingRoomStatusPending {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-keepAlive.C:
+			// Заявка истекает и без хоста: GetOwnEntry переводит просроченные заявки в expired
+			if fresh, err := h.waitingRoomService.GetOwnEntry(ctx, roomID, entryID, userID.(uuid.UUID)); err == nil && fresh.Status != domain.WaitingRoomStatusPending {
+				c.SSEvent(domain.RoomEventTypeWaitingRoom, fresh)
+				return false
+			}
+			c.SSEvent("ping", gin.H{"time": time.Now()})
+			return true
+		case event, ok := <-conn.Events():
+			if !ok {
+				return false
+			}
+			if event.Type != domain.RoomEventTypeWaitingRoom {
+				return true
+			}
+
+			var update domain.WaitingRoomEntry
+			if err := json.Unmarshal(event.Payload, &update); err != nil || update.ID != entryID {
+				return true
+			}
+
+			c.SSEvent(domain.RoomEventTypeWaitingRoom, &update)
+			return update.Status == domain.WaitingRoomStatusPending
+		}
+	})
+}
+
+func (h *WaitingRoomHandler) parseIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	roomID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid room ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	entryID, err := uuid.Parse(c.Param("entryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entry ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return roomID, entryID, true
+}
+
+func waitingRoomErrorStatus(err error) int {
+	switch err.Error() {
+	case "room not found", "waiting room entry not found":
+		return http.StatusNotFound
+	case "only host or co-host can manage waiting room":
+		return http.StatusForbidden
+	case "waiting room entry expired", "waiting room entry already decided":
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
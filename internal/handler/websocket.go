@@ -1,15 +1,32 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"video_conference/internal/domain"
 	"video_conference/internal/service"
 	"video_conference/pkg/logger"
 )
 
+const (
+	wsWriteWait      = 10 * time.Second
+	wsPongWait       = 60 * time.Second
+	wsPingPeriod     = (wsPongWait * 9) / 10
+	wsMaxMessageSize = 8 * 1024
+)
+
+var (
+	errInvalidPayload   = errors.New("invalid payload")
+	errUnknownFrameType = errors.New("unknown frame type")
+)
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // В продакшене нужно проверять origin
@@ -17,17 +34,44 @@ var upgrader = websocket.Upgrader{
 }
 
 type WebSocketHandler struct {
-	chatService service.ChatService
-	log         logger.Logger
+	chatService     service.ChatService
+	roomService     service.RoomService
+	realtimeService service.RealtimeService
+	log             logger.Logger
 }
 
-func NewWebSocketHandler(chatService service.ChatService, log logger.Logger) *WebSocketHandler {
+func NewWebSocketHandler(chatService service.ChatService, roomService service.RoomService, realtimeService service.RealtimeService, log logger.Logger) *WebSocketHandler {
 	return &WebSocketHandler{
-		chatService: chatService,
-		log:         log,
+		chatService:     chatService,
+		roomService:     roomService,
+		realtimeService: realtimeService,
+		log:             log,
 	}
 }
 
+// wsClientFrame - входящий кадр от клиента
+type wsClientFrame struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type wsMessagePayload struct {
+	Content                string      `json:"content"`
+	ReplyToMessageID       *int64      `json:"reply_to_message_id,omitempty"`
+	AttachmentIDs          []uuid.UUID `json:"attachment_ids,omitempty"`
+	RecipientType          string      `json:"recipient_type,omitempty"`
+	RecipientParticipantID *uuid.UUID  `json:"recipient_participant_id,omitempty"`
+}
+
+type wsEditPayload struct {
+	MessageID int64  `json:"message_id"`
+	Content   string `json:"content"`
+}
+
+type wsTypingPayload struct {
+	IsTyping bool `json:"is_typing"`
+}
+
 func (h *WebSocketHandler) HandleChat(c *gin.Context) {
 	roomID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -35,28 +79,165 @@ func (h *WebSocketHandler) HandleChat(c *gin.Context) {
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	// Подключаться к каналу комнаты могут только ее активные участники
+	participant, err := h.roomService.GetParticipant(c.Request.Context(), roomID, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a room participant"})
+		return
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		h.log.Error("Failed to upgrade connection", "error", err)
 		return
 	}
-	defer conn.Close()
+	defer ws.Close()
 
-	_ = roomID // TODO: использовать roomID для валидации
+	ctx := c.Request.Context()
+	conn, err := h.realtimeService.Connect(ctx, participant)
+	if err != nil {
+		h.log.Error("Failed to connect to room hub", "error", err, "room_id", roomID)
+		ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+		return
+	}
+	// Disconnect закрывает канал событий, после чего writePump завершается
+	defer h.realtimeService.Disconnect(context.Background(), conn)
+
+	go h.writePump(ws, conn)
+	h.readPump(ctx, ws, conn)
+}
+
+// readPump читает кадры клиента и выполняет соответствующие действия
+func (h *WebSocketHandler) readPump(ctx context.Context, ws *websocket.Conn, conn *service.RoomConnection) {
+	ws.SetReadLimit(wsMaxMessageSize)
+	ws.SetReadDeadline(time.Now().Add(wsPongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
 
-	// TODO: реализовать обработку WebSocket сообщений для чата
 	for {
-		messageType, message, err := conn.ReadMessage()
-		if err != nil {
-			h.log.Error("Failed to read message", "error", err)
-			break
+		var frame wsClientFrame
+		if err := ws.ReadJSON(&frame); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				h.log.Warn("WebSocket closed unexpectedly", "error", err, "room_id", conn.RoomID)
+			}
+			return
 		}
 
-		// Echo обратно
-		if err := conn.WriteMessage(messageType, message); err != nil {
-			h.log.Error("Failed to write message", "error", err)
-			break
+		if err := h.handleFrame(ctx, conn, &frame); err != nil {
+			h.replyError(conn, err.Error())
 		}
 	}
 }
 
+func (h *WebSocketHandler) handleFrame(ctx context.Context, conn *service.RoomConnection, frame *wsClientFrame) error {
+	userID := *conn.Participant.UserID
+
+	switch frame.Type {
+	case domain.RoomEventTypeMessage:
+		var payload wsMessagePayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil || (payload.Content == "" && len(payload.AttachmentIDs) == 0) {
+			return errInvalidPayload
+		}
+		_, err := h.chatService.SendMessage(ctx, conn.RoomID, userID, service.SendMessageParams{
+			Content:                payload.Content,
+			ReplyToMessageID:       payload.ReplyToMessageID,
+			AttachmentIDs:          payload.AttachmentIDs,
+			RecipientType:          payload.RecipientType,
+			RecipientParticipantID: payload.RecipientParticipantID,
+		})
+		return err
+
+	case domain.RoomEventTypeEdit:
+		var payload wsEditPayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil || payload.MessageID == 0 || payload.Content == "" {
+			return errInvalidPayload
+		}
+		_, err := h.chatService.EditMessage(ctx, conn.RoomID, payload.MessageID, userID, payload.Content)
+		return err
+
+	case domain.RoomEventTypeDelete:
+		var payload domain.ChatMessageRef
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil || payload.MessageID == 0 {
+			return errInvalidPayload
+		}
+		return h.chatService.DeleteMessage(ctx, payload.MessageID, userID)
+
+	case domain.RoomEventTypeTyping:
+		var payload wsTypingPayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			return errInvalidPayload
+		}
+		return h.realtimeService.Publish(ctx, conn.RoomID, domain.RoomEventTypeTyping, &domain.TypingPayload{
+			ParticipantID: conn.Participant.ID,
+			DisplayName:   conn.Participant.DisplayName,
+			IsTyping:      payload.IsTyping,
+		})
+
+	default:
+		return errUnknownFrameType
+	}
+}
+
+// writePump отправляет клиенту события комнаты и поддерживает соединение ping-ами
+func (h *WebSocketHandler) writePump(ws *websocket.Conn, conn *service.RoomConnection) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		ws.Close()
+	}()
+
+	for {
+		select {
+		case event, ok := <-conn.Events():
+			ws.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				ws.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := ws.WriteJSON(event); err != nil {
+				h.log.Warn("Failed to write event", "error", err, "room_id", conn.RoomID)
+				return
+			}
+			if isKickedEvent(event, conn) {
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "kicked"))
+				return
+			}
+		case <-ticker.C:
+			ws.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// replyError отправляет ошибку только автору кадра
+func (h *WebSocketHandler) replyError(conn *service.RoomConnection, message string) {
+	event, err := service.NewRoomEvent(conn.RoomID, domain.RoomEventTypeError, gin.H{"error": message})
+	if err != nil {
+		return
+	}
+	conn.Deliver(event)
+}
+
+// isKickedEvent - событие об исключении владельца подключения
+func isKickedEvent(event *domain.RoomEvent, conn *service.RoomConnection) bool {
+	if event.Type != domain.RoomEventTypeModeration || conn.Participant == nil {
+		return false
+	}
+
+	var payload domain.ModerationPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return false
+	}
+
+	return payload.Action == domain.ModerationActionKicked &&
+		payload.ParticipantID != nil && *payload.ParticipantID == conn.Participant.ID
+}
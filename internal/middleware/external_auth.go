@@ -59,44 +59,77 @@ func (m *ExternalAuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		m.log.Debug("Parsing JWT token", "token_prefix", tokenString[:20]+"...")
-		
-		claims, err := m.parseToken(tokenString)
-		if err != nil {
-			m.log.Warn("Token validation failed", "error", err.Error())
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
-		}
-		
-		m.log.Debug("Token validated successfully", "user_id", claims.UserID)
+		m.authenticate(c, parts[1])
+	}
+}
 
-		// Парсим user_id как UUID
-		userID, err := uuid.Parse(claims.UserID)
-		if err != nil {
-			m.log.Debug("Invalid user_id in token", "user_id", claims.UserID, "error", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
-			c.Abort()
+// RequireQueryAuth работает как RequireAuth, но также принимает токен из query-параметра token,
+// так как браузерные WebSocket и EventSource не позволяют передать заголовок Authorization
+func (m *ExternalAuthMiddleware) RequireQueryAuth() gin.HandlerFunc {
+	requireAuth := m.RequireAuth()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			requireAuth(c)
 			return
 		}
 
-		// Auto-provisioning: создаем пользователя если его нет
-		if err := m.ensureUserExists(c.Request.Context(), userID, claims.Email, claims.DisplayName); err != nil {
-			m.log.Error("Failed to ensure user exists", "user_id", userID.String(), "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to provision user"})
+		tokenString := c.Query("token")
+		if tokenString == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token required"})
 			c.Abort()
 			return
 		}
 
-		// Устанавливаем user_id в контекст (как uuid.UUID для совместимости с handlers)
-		c.Set("user_id", userID)
-		c.Set("user_id_string", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_display_name", claims.DisplayName)
-		
-		c.Next()
+		m.authenticate(c, tokenString)
+	}
+}
+
+// authenticate валидирует токен, выполняет auto-provisioning и заполняет контекст запроса
+func (m *ExternalAuthMiddleware) authenticate(c *gin.Context, tokenString string) {
+	m.log.Debug("Parsing JWT token", "token_prefix", tokenPrefix(tokenString))
+
+	claims, err := m.parseToken(tokenString)
+	if err != nil {
+		m.log.Warn("Token validation failed", "error", err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return
+	}
+
+	m.log.Debug("Token validated successfully", "user_id", claims.UserID)
+
+	// Парсим user_id как UUID
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		m.log.Debug("Invalid user_id in token", "user_id", claims.UserID, "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
+		c.Abort()
+		return
+	}
+
+	// Auto-provisioning: создаем пользователя если его нет
+	if err := m.ensureUserExists(c.Request.Context(), userID, claims.Email, claims.DisplayName); err != nil {
+		m.log.Error("Failed to ensure user exists", "user_id", userID.String(), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to provision user"})
+		c.Abort()
+		return
+	}
+
+	// Устанавливаем user_id в контекст (как uuid.UUID для совместимости с handlers)
+	c.Set("user_id", userID)
+	c.Set("user_id_string", claims.UserID)
+	c.Set("user_email", claims.Email)
+	c.Set("user_display_name", claims.DisplayName)
+
+	c.Next()
+}
+
+// tokenPrefix возвращает начало токена для логов
+func tokenPrefix(tokenString string) string {
+	if len(tokenString) > 20 {
+		return tokenString[:20] + "..."
 	}
+	return tokenString
 }
 
 // OptionalAuth проверяет токен если он есть, но не требует его
@@ -278,12 +278,12 @@ func (r *anonymousRoomRepository) GetParticipantsByRoom(ctx context.Context, roo
 func (r *anonymousRoomRepository) UpdateParticipant(ctx context.Context, participant *domain.AnonymousParticipant) error {
 	query := `
 		UPDATE anonymous_participants
-		SET left_at = $3
+		SET left_at = $3, livekit_sid = $4
 		WHERE id = $1 AND room_id = $2
 	`
 
 	_, err := r.db.Exec(ctx, query,
-		participant.ID, participant.RoomID, participant.LeftAt,
+		participant.ID, participant.RoomID, participant.LeftAt, participant.LiveKitSID,
 	)
 
 	if err != nil {
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+type BreakoutRepository interface {
+	// Assign распределяет пользователя в зал, заменяя прежнее распределение
+	Assign(ctx context.Context, assignment *domain.BreakoutAssignment) error
+	Unassign(ctx context.Context, parentRoomID, userID uuid.UUID) error
+	// ReplaceAssignments заменяет все распределения основной комнаты одной транзакцией
+	ReplaceAssignments(ctx context.Context, parentRoomID uuid.UUID, assignments []*domain.BreakoutAssignment) error
+	GetAssignment(ctx context.Context, parentRoomID, userID uuid.UUID) (*domain.BreakoutAssignment, error)
+	ListAssignments(ctx context.Context, parentRoomID uuid.UUID) ([]*domain.BreakoutAssignment, error)
+}
+
+type breakoutRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewBreakoutRepository(db *pgxpool.Pool, log logger.Logger) BreakoutRepository {
+	return &breakoutRepository{db: db, log: log}
+}
+
+const assignBreakoutQuery = `
+	INSERT INTO breakout_assignments (parent_room_id, user_id, breakout_room_id, assigned_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (parent_room_id, user_id)
+	DO UPDATE SET breakout_room_id = EXCLUDED.breakout_room_id, assigned_at = EXCLUDED.assigned_at
+`
+
+func (r *breakoutRepository) Assign(ctx context.Context, assignment *domain.BreakoutAssignment) error {
+	_, err := r.db.Exec(ctx, assignBreakoutQuery,
+		assignment.ParentRoomID, assignment.UserID, assignment.BreakoutRoomID, assignment.AssignedAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to assign breakout", "error", err, "room_id", assignment.ParentRoomID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *breakoutRepository) Unassign(ctx context.Context, parentRoomID, userID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM breakout_assignments WHERE parent_room_id = $1 AND user_id = $2`, parentRoomID, userID)
+	if err != nil {
+		r.log.Error("Failed to unassign breakout", "error", err, "room_id", parentRoomID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *breakoutRepository) ReplaceAssignments(ctx context.Context, parentRoomID uuid.UUID, assignments []*domain.BreakoutAssignment) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		r.log.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM breakout_assignments WHERE parent_room_id = $1`, parentRoomID); err != nil {
+		r.log.Error("Failed to clear breakout assignments", "error", err, "room_id", parentRoomID)
+		return err
+	}
+
+	for _, assignment := range assignments {
+		if _, err := tx.Exec(ctx, assignBreakoutQuery,
+			parentRoomID, assignment.UserID, assignment.BreakoutRoomID, assignment.AssignedAt,
+		); err != nil {
+			r.log.Error("Failed to assign breakout", "error", err, "room_id", parentRoomID)
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.log.Error("Failed to commit breakout assignments", "error", err, "room_id", parentRoomID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *breakoutRepository) GetAssignment(ctx context.Context, parentRoomID, userID uuid.UUID) (*domain.BreakoutAssignment, error) {
+	query := `
+		SELECT parent_room_id, user_id, breakout_room_id, assigned_at
+		FROM breakout_assignments
+		WHERE parent_room_id = $1 AND user_id = $2
+	`
+
+	assignment := &domain.BreakoutAssignment{}
+	err := r.db.QueryRow(ctx, query, parentRoomID, userID).Scan(
+		&assignment.ParentRoomID, &assignment.UserID, &assignment.BreakoutRoomID, &assignment.AssignedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("not assigned to a breakout")
+		}
+		r.log.Error("Failed to get breakout assignment", "error", err)
+		return nil, err
+	}
+
+	return assignment, nil
+}
+
+func (r *breakoutRepository) ListAssignments(ctx context.Context, parentRoomID uuid.UUID) ([]*domain.BreakoutAssignment, error) {
+	query := `
+		SELECT parent_room_id, user_id, breakout_room_id, assigned_at
+		FROM breakout_assignments
+		WHERE parent_room_id = $1
+		ORDER BY assigned_at
+	`
+
+	rows, err := r.db.Query(ctx, query, parentRoomID)
+	if err != nil {
+		r.log.Error("Failed to list breakout assignments", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []*domain.BreakoutAssignment
+	for rows.Next() {
+		assignment := &domain.BreakoutAssignment{}
+		if err := rows.Scan(
+			&assignment.ParentRoomID, &assignment.UserID, &assignment.BreakoutRoomID, &assignment.AssignedAt,
+		); err != nil {
+			r.log.Error("Failed to scan breakout assignment", "error", err)
+			return nil, err
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, rows.Err()
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/pkg/logger"
+)
+
+type CalendarRepository interface {
+	// SetFeedToken сохраняет хеш нового токена подписки, заменяя прежний
+	SetFeedToken(ctx context.Context, userID uuid.UUID, tokenHash string) error
+	GetUserIDByFeedToken(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	DeleteFeedToken(ctx context.Context, userID uuid.UUID) error
+}
+
+type calendarRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewCalendarRepository(db *pgxpool.Pool, log logger.Logger) CalendarRepository {
+	return &calendarRepository{db: db, log: log}
+}
+
+func (r *calendarRepository) SetFeedToken(ctx context.Context, userID uuid.UUID, tokenHash string) error {
+	query := `
+		INSERT INTO calendar_feed_tokens (user_id, token_hash, created_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = EXCLUDED.created_at
+	`
+
+	_, err := r.db.Exec(ctx, query, userID, tokenHash)
+	if err != nil {
+		r.log.Error("Failed to save calendar feed token", "error", err, "user_id", userID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *calendarRepository) GetUserIDByFeedToken(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	query := `SELECT user_id FROM calendar_feed_tokens WHERE token_hash = $1`
+
+	var userID uuid.UUID
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, errors.New("calendar feed not found")
+		}
+		r.log.Error("Failed to get calendar feed token", "error", err)
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+func (r *calendarRepository) DeleteFeedToken(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM calendar_feed_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		r.log.Error("Failed to delete calendar feed token", "error", err, "user_id", userID)
+		return err
+	}
+
+	return nil
+}
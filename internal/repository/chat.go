@@ -3,20 +3,40 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"video_conference/internal/domain"
 	"video_conference/pkg/logger"
 )
 
 type ChatRepository interface {
+	// CreateMessage сохраняет сообщение вместе с упоминаниями и привязывает к нему вложения
+	// из message.Attachments. Уже отправленное вложение - ошибка "attachment already sent".
 	CreateMessage(ctx context.Context, message *domain.ChatMessage) error
-	GetMessages(ctx context.Context, roomID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error)
+	// GetMessages возвращает видимую читателю ленту комнаты без ответов в ветках, новые сообщения первыми
+	GetMessages(ctx context.Context, roomID uuid.UUID, viewer domain.ChatViewer, limit, offset int) ([]*domain.ChatMessage, error)
 	GetMessageByID(ctx context.Context, messageID int64) (*domain.ChatMessage, error)
+	// IsVisible - видит ли читатель сообщение (общие сообщения видны всем)
+	IsVisible(ctx context.Context, messageID int64, viewer domain.ChatViewer) (bool, error)
+	// StreamTranscript передает fn все видимые читателю сообщения комнаты по времени отправки,
+	// включая ответы в ветках и удаленные. Сообщения читаются пачками по 500 с пагинацией по ключу,
+	// без загрузки всей истории и без открытого курсора на время работы fn.
+	StreamTranscript(ctx context.Context, roomID uuid.UUID, viewer domain.ChatViewer, fn func(entry *domain.ChatTranscriptEntry) error) error
+	// GetThreadReplies возвращает страницу ответов ветки в порядке отправки
+	GetThreadReplies(ctx context.Context, rootID int64, limit, offset int) ([]*domain.ChatMessage, error)
+	// GetMentions возвращает сообщения комнаты, в которых упомянут пользователь (под любым из его участий)
+	GetMentions(ctx context.Context, roomID, userID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error)
+	// UpdateMessage заменяет текст сообщения, сохраняя прежний в истории правок, и выставляет edited_at
 	UpdateMessage(ctx context.Context, message *domain.ChatMessage) error
-	DeleteMessage(ctx context.Context, messageID int64, deletedByParticipantID uuid.UUID) error
+	// GetRevisions возвращает предыдущие версии сообщения от старых к новым
+	GetRevisions(ctx context.Context, messageID int64) ([]*domain.ChatMessageRevision, error)
+	// ReplaceMentions заменяет упоминания сообщения одной транзакцией
+	ReplaceMentions(ctx context.Context, messageID int64, participantIDs []uuid.UUID) error
+	DeleteMessage(ctx context.Context, messageID int64, deletedByParticipantID *uuid.UUID) error
 }
 
 type chatRepository struct {
@@ -28,113 +48,346 @@ func NewChatRepository(db *pgxpool.Pool, log logger.Logger) ChatRepository {
 	return &chatRepository{db: db, log: log}
 }
 
+// Число ответов считается только по неудаленным сообщениям ветки
+const chatMessageColumns = `m.id, m.room_id, m.sender_participant_id, m.message_type, m.content, m.created_at,
+		       m.edited_at, m.deleted_at, m.deleted_by_participant_id, m.reply_to_message_id, m.thread_root_id,
+		       (SELECT COUNT(*) FROM chat_messages r WHERE r.thread_root_id = m.id AND r.deleted_at IS NULL),
+		       m.recipient_type, m.recipient_participant_id`
+
+// chatVisibleCondition - условие видимости сообщения m: общие видны всем, сообщения для ведущих -
+// ведущим (hostArg), личные - отправителю и адресату под любым их участием в комнате (userArg)
+func chatVisibleCondition(userArg, hostArg string) string {
+	return `(m.recipient_type = 'room'
+		   OR (m.recipient_type = 'hosts' AND ` + hostArg + `::boolean)
+		   OR EXISTS (
+		       SELECT 1 FROM room_participants vp
+		       WHERE vp.user_id = ` + userArg + ` AND vp.id IN (m.sender_participant_id, m.recipient_participant_id)
+		   ))`
+}
+
 func (r *chatRepository) CreateMessage(ctx context.Context, message *domain.ChatMessage) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		r.log.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if message.RecipientType == "" {
+		message.RecipientType = domain.ChatRecipientRoom
+	}
+
 	query := `
-		INSERT INTO chat_messages (room_id, sender_participant_id, message_type, content, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO chat_messages (room_id, sender_participant_id, message_type, content, created_at,
+		                           reply_to_message_id, thread_root_id, recipient_type, recipient_participant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at
 	`
-	
-	err := r.db.QueryRow(ctx, query,
+
+	err = tx.QueryRow(ctx, query,
 		message.RoomID, message.SenderParticipantID, message.MessageType,
-		message.Content, message.CreatedAt,
+		message.Content, message.CreatedAt, message.ReplyToMessageID, message.ThreadRootID,
+		message.RecipientType, message.RecipientParticipantID,
 	).Scan(&message.ID, &message.CreatedAt)
-	
 	if err != nil {
 		r.log.Error("Failed to create message", "error", err)
 		return err
 	}
-	
+
+	if err := insertMentions(ctx, tx, message.ID, message.Mentions); err != nil {
+		r.log.Error("Failed to save message mentions", "error", err, "message_id", message.ID)
+		return err
+	}
+
+	if len(message.Attachments) > 0 {
+		attachmentIDs := make([]uuid.UUID, 0, len(message.Attachments))
+		for _, attachment := range message.Attachments {
+			attachmentIDs = append(attachmentIDs, attachment.ID)
+		}
+
+		// Вложение могли параллельно прикрепить к другому сообщению - тогда оно уже не свободно
+		tag, err := tx.Exec(ctx,
+			`UPDATE chat_attachments SET message_id = $1 WHERE id = ANY($2) AND message_id IS NULL`,
+			message.ID, attachmentIDs,
+		)
+		if err != nil {
+			r.log.Error("Failed to attach files to message", "error", err, "message_id", message.ID)
+			return err
+		}
+		if tag.RowsAffected() != int64(len(attachmentIDs)) {
+			return errors.New("attachment already sent")
+		}
+		for _, attachment := range message.Attachments {
+			attachment.MessageID = &message.ID
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.log.Error("Failed to commit message", "error", err)
+		return err
+	}
+
 	return nil
 }
 
-func (r *chatRepository) GetMessages(ctx context.Context, roomID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error) {
+func (r *chatRepository) GetMessages(ctx context.Context, roomID uuid.UUID, viewer domain.ChatViewer, limit, offset int) ([]*domain.ChatMessage, error) {
 	query := `
-		SELECT id, room_id, sender_participant_id, message_type, content, created_at, edited_at, deleted_at, deleted_by_participant_id
-		FROM chat_messages
-		WHERE room_id = $1 AND deleted_at IS NULL
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		SELECT ` + chatMessageColumns + `
+		FROM chat_messages m
+		WHERE m.room_id = $1 AND m.deleted_at IS NULL AND m.thread_root_id IS NULL
+		  AND ` + chatVisibleCondition("$2", "$3") + `
+		ORDER BY m.created_at DESC
+		LIMIT $4 OFFSET $5
 	`
-	
-	rows, err := r.db.Query(ctx, query, roomID, limit, offset)
+
+	return r.queryMessages(ctx, query, roomID, viewer.UserID, viewer.Host, limit, offset)
+}
+
+func (r *chatRepository) IsVisible(ctx context.Context, messageID int64, viewer domain.ChatViewer) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM chat_messages m WHERE m.id = $1 AND ` + chatVisibleCondition("$2", "$3") + `)`
+
+	var visible bool
+	if err := r.db.QueryRow(ctx, query, messageID, viewer.UserID, viewer.Host).Scan(&visible); err != nil {
+		r.log.Error("Failed to check message visibility", "error", err, "message_id", messageID)
+		return false, err
+	}
+
+	return visible, nil
+}
+
+func (r *chatRepository) GetMessageByID(ctx context.Context, messageID int64) (*domain.ChatMessage, error) {
+	query := `SELECT ` + chatMessageColumns + ` FROM chat_messages m WHERE m.id = $1`
+
+	message, err := scanChatMessage(r.db.QueryRow(ctx, query, messageID))
 	if err != nil {
-		r.log.Error("Failed to get messages", "error", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("message not found")
+		}
+		r.log.Error("Failed to get message", "error", err)
+		return nil, err
+	}
+
+	if err := r.loadMentions(ctx, []*domain.ChatMessage{message}); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// transcriptBatchSize - сколько сообщений выгрузки читается одним запросом
+const transcriptBatchSize = 500
+
+func (r *chatRepository) StreamTranscript(ctx context.Context, roomID uuid.UUID, viewer domain.ChatViewer, fn func(entry *domain.ChatTranscriptEntry) error) error {
+	// Пачки читаются по ключу (created_at, id) последнего сообщения: соединение не держится,
+	// пока fn пишет выгрузку клиенту, а OFFSET не замедляет чтение длинной истории
+	var last *domain.ChatTranscriptEntry
+	for {
+		batch, err := r.transcriptBatch(ctx, roomID, viewer, last)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range batch {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < transcriptBatchSize {
+			return nil
+		}
+		last = batch[len(batch)-1]
+	}
+}
+
+// transcriptBatch читает очередную пачку выгрузки после сообщения after (nil - с начала)
+func (r *chatRepository) transcriptBatch(ctx context.Context, roomID uuid.UUID, viewer domain.ChatViewer, after *domain.ChatTranscriptEntry) ([]*domain.ChatTranscriptEntry, error) {
+	args := []interface{}{roomID, viewer.UserID, viewer.Host, transcriptBatchSize}
+	cursor := ""
+	if after != nil {
+		cursor = "AND (m.created_at, m.id) > ($5, $6)"
+		args = append(args, after.CreatedAt, after.ID)
+	}
+
+	query := `
+		SELECT m.id, m.message_type, COALESCE(sp.display_name, ''), m.recipient_type, COALESCE(rp.display_name, ''),
+		       m.content, m.created_at, m.edited_at, m.deleted_at, m.reply_to_message_id, m.thread_root_id,
+		       COALESCE((SELECT array_agg(a.file_name ORDER BY a.created_at) FROM chat_attachments a WHERE a.message_id = m.id), '{}')
+		FROM chat_messages m
+		LEFT JOIN room_participants sp ON sp.id = m.sender_participant_id
+		LEFT JOIN room_participants rp ON rp.id = m.recipient_participant_id
+		WHERE m.room_id = $1 AND ` + chatVisibleCondition("$2", "$3") + `
+		      ` + cursor + `
+		ORDER BY m.created_at, m.id
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		r.log.Error("Failed to get chat transcript", "error", err, "room_id", roomID)
 		return nil, err
 	}
 	defer rows.Close()
-	
-	var messages []*domain.ChatMessage
+
+	var entries []*domain.ChatTranscriptEntry
 	for rows.Next() {
-		message := &domain.ChatMessage{}
+		entry := &domain.ChatTranscriptEntry{}
 		var editedAt, deletedAt sql.NullTime
-		err := rows.Scan(
-			&message.ID, &message.RoomID, &message.SenderParticipantID, &message.MessageType,
-			&message.Content, &message.CreatedAt, &editedAt, &deletedAt, &message.DeletedByParticipantID,
-		)
-		if err != nil {
-			r.log.Error("Failed to scan message", "error", err)
+		if err := rows.Scan(
+			&entry.ID, &entry.MessageType, &entry.SenderName, &entry.RecipientType, &entry.RecipientName,
+			&entry.Content, &entry.CreatedAt, &editedAt, &deletedAt, &entry.ReplyToMessageID, &entry.ThreadRootID,
+			&entry.Attachments,
+		); err != nil {
+			r.log.Error("Failed to scan transcript message", "error", err)
 			return nil, err
 		}
 		if editedAt.Valid {
-			message.EditedAt = &editedAt.Time
+			entry.EditedAt = &editedAt.Time
+			entry.Edited = true
 		}
 		if deletedAt.Valid {
-			message.DeletedAt = &deletedAt.Time
+			entry.DeletedAt = &deletedAt.Time
+			entry.Deleted = true
 		}
-		messages = append(messages, message)
+		entries = append(entries, entry)
 	}
-	
-	return messages, nil
+
+	return entries, rows.Err()
 }
 
-func (r *chatRepository) GetMessageByID(ctx context.Context, messageID int64) (*domain.ChatMessage, error) {
+func (r *chatRepository) GetThreadReplies(ctx context.Context, rootID int64, limit, offset int) ([]*domain.ChatMessage, error) {
 	query := `
-		SELECT id, room_id, sender_participant_id, message_type, content, created_at, edited_at, deleted_at, deleted_by_participant_id
-		FROM chat_messages
-		WHERE id = $1
+		SELECT ` + chatMessageColumns + `
+		FROM chat_messages m
+		WHERE m.thread_root_id = $1 AND m.deleted_at IS NULL
+		ORDER BY m.created_at, m.id
+		LIMIT $2 OFFSET $3
 	`
-	
-	message := &domain.ChatMessage{}
-	var editedAt, deletedAt sql.NullTime
-	err := r.db.QueryRow(ctx, query, messageID).Scan(
-		&message.ID, &message.RoomID, &message.SenderParticipantID, &message.MessageType,
-		&message.Content, &message.CreatedAt, &editedAt, &deletedAt, &message.DeletedByParticipantID,
-	)
-	
+
+	return r.queryMessages(ctx, query, rootID, limit, offset)
+}
+
+func (r *chatRepository) GetMentions(ctx context.Context, roomID, userID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error) {
+	query := `
+		SELECT ` + chatMessageColumns + `
+		FROM chat_messages m
+		WHERE m.room_id = $1 AND m.deleted_at IS NULL
+		  AND EXISTS (
+		      SELECT 1
+		      FROM chat_message_mentions cm
+		      JOIN room_participants p ON p.id = cm.participant_id
+		      WHERE cm.message_id = m.id AND p.user_id = $2
+		  )
+		ORDER BY m.created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	return r.queryMessages(ctx, query, roomID, userID, limit, offset)
+}
+
+func (r *chatRepository) UpdateMessage(ctx context.Context, message *domain.ChatMessage) error {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		r.log.Error("Failed to get message", "error", err)
-		return nil, err
+		r.log.Error("Failed to begin transaction", "error", err)
+		return err
 	}
-	
-	if editedAt.Valid {
-		message.EditedAt = &editedAt.Time
+	defer tx.Rollback(ctx)
+
+	// Прежний текст берется из БД под блокировкой, чтобы параллельные правки не потеряли версию
+	var previous string
+	var writtenAt time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT content, COALESCE(edited_at, created_at) FROM chat_messages WHERE id = $1 FOR UPDATE`,
+		message.ID,
+	).Scan(&previous, &writtenAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("message not found")
+		}
+		r.log.Error("Failed to lock message", "error", err, "message_id", message.ID)
+		return err
 	}
-	if deletedAt.Valid {
-		message.DeletedAt = &deletedAt.Time
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO chat_message_revisions (message_id, content, created_at, replaced_at) VALUES ($1, $2, $3, $4)`,
+		message.ID, previous, writtenAt, now,
+	); err != nil {
+		r.log.Error("Failed to save message revision", "error", err, "message_id", message.ID)
+		return err
 	}
-	
-	return message, nil
+
+	if _, err := tx.Exec(ctx, `UPDATE chat_messages SET content = $2, edited_at = $3 WHERE id = $1`, message.ID, message.Content, now); err != nil {
+		r.log.Error("Failed to update message", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.log.Error("Failed to commit message update", "error", err, "message_id", message.ID)
+		return err
+	}
+
+	message.EditedAt = &now
+	message.Edited = true
+
+	return nil
 }
 
-func (r *chatRepository) UpdateMessage(ctx context.Context, message *domain.ChatMessage) error {
+func (r *chatRepository) GetRevisions(ctx context.Context, messageID int64) ([]*domain.ChatMessageRevision, error) {
 	query := `
-		UPDATE chat_messages
-		SET content = $2, edited_at = $3
-		WHERE id = $1
-		RETURNING edited_at
+		SELECT id, message_id, content, created_at, replaced_at
+		FROM chat_message_revisions
+		WHERE message_id = $1
+		ORDER BY id
 	`
-	
-	err := r.db.QueryRow(ctx, query, message.ID, message.Content, time.Now()).Scan(&message.EditedAt)
+
+	rows, err := r.db.Query(ctx, query, messageID)
 	if err != nil {
-		r.log.Error("Failed to update message", "error", err)
+		r.log.Error("Failed to get message revisions", "error", err, "message_id", messageID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*domain.ChatMessageRevision{}
+	for rows.Next() {
+		revision := &domain.ChatMessageRevision{}
+		if err := rows.Scan(&revision.ID, &revision.MessageID, &revision.Content, &revision.CreatedAt, &revision.ReplacedAt); err != nil {
+			r.log.Error("Failed to scan message revision", "error", err)
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (r *chatRepository) ReplaceMentions(ctx context.Context, messageID int64, participantIDs []uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		r.log.Error("Failed to begin transaction", "error", err)
 		return err
 	}
-	
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM chat_message_mentions WHERE message_id = $1`, messageID); err != nil {
+		r.log.Error("Failed to delete message mentions", "error", err, "message_id", messageID)
+		return err
+	}
+
+	if err := insertMentions(ctx, tx, messageID, participantIDs); err != nil {
+		r.log.Error("Failed to save message mentions", "error", err, "message_id", messageID)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.log.Error("Failed to commit message mentions", "error", err, "message_id", messageID)
+		return err
+	}
+
 	return nil
 }
 
-func (r *chatRepository) DeleteMessage(ctx context.Context, messageID int64, deletedByParticipantID uuid.UUID) error {
+func (r *chatRepository) DeleteMessage(ctx context.Context, messageID int64, deletedByParticipantID *uuid.UUID) error {
 	query := `
 		UPDATE chat_messages
 		SET deleted_at = $2, deleted_by_participant_id = $3
@@ -150,3 +403,105 @@ func (r *chatRepository) DeleteMessage(ctx context.Context, messageID int64, del
 	return nil
 }
 
+func (r *chatRepository) queryMessages(ctx context.Context, query string, args ...interface{}) ([]*domain.ChatMessage, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		r.log.Error("Failed to get messages", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*domain.ChatMessage
+	for rows.Next() {
+		message, err := scanChatMessage(rows)
+		if err != nil {
+			r.log.Error("Failed to scan message", "error", err)
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("Failed to get messages", "error", err)
+		return nil, err
+	}
+
+	if err := r.loadMentions(ctx, messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// loadMentions заполняет упоминания сообщений одним запросом
+func (r *chatRepository) loadMentions(ctx context.Context, messages []*domain.ChatMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(messages))
+	byID := make(map[int64]*domain.ChatMessage, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+		byID[message.ID] = message
+	}
+
+	rows, err := r.db.Query(ctx,
+		`SELECT message_id, participant_id FROM chat_message_mentions WHERE message_id = ANY($1)`,
+		ids,
+	)
+	if err != nil {
+		r.log.Error("Failed to list message mentions", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID int64
+		var participantID uuid.UUID
+		if err := rows.Scan(&messageID, &participantID); err != nil {
+			r.log.Error("Failed to scan message mention", "error", err)
+			return err
+		}
+		if message, ok := byID[messageID]; ok {
+			message.Mentions = append(message.Mentions, participantID)
+		}
+	}
+
+	return rows.Err()
+}
+
+func insertMentions(ctx context.Context, tx pgx.Tx, messageID int64, participantIDs []uuid.UUID) error {
+	for _, participantID := range participantIDs {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO chat_message_mentions (message_id, participant_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			messageID, participantID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanChatMessage(row pgx.Row) (*domain.ChatMessage, error) {
+	message := &domain.ChatMessage{}
+	var editedAt, deletedAt sql.NullTime
+	err := row.Scan(
+		&message.ID, &message.RoomID, &message.SenderParticipantID, &message.MessageType,
+		&message.Content, &message.CreatedAt, &editedAt, &deletedAt, &message.DeletedByParticipantID,
+		&message.ReplyToMessageID, &message.ThreadRootID, &message.ReplyCount,
+		&message.RecipientType, &message.RecipientParticipantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if editedAt.Valid {
+		message.EditedAt = &editedAt.Time
+		message.Edited = true
+	}
+	if deletedAt.Valid {
+		message.DeletedAt = &deletedAt.Time
+	}
+
+	return message, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+type ChatAttachmentRepository interface {
+	Create(ctx context.Context, attachment *domain.ChatAttachment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.ChatAttachment, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.ChatAttachment, error)
+	// ListByMessages возвращает вложения сообщений в порядке загрузки
+	ListByMessages(ctx context.Context, messageIDs []int64) ([]*domain.ChatAttachment, error)
+	// ListExpired возвращает вложения завершенных комнат, срок хранения которых истек
+	// (attachment_retention_days комнаты или defaultRetention), и так и не отправленные
+	// вложения, загруженные раньше pendingBefore. Отложенные через DeferCleanup пропускаются
+	// до наступления cleanup_retry_at и идут после остальных.
+	ListExpired(ctx context.Context, defaultRetention time.Duration, pendingBefore time.Time, limit int) ([]*domain.ChatAttachment, error)
+	// DeferCleanup откладывает очистку вложения до retryAt после неудачного удаления файлов
+	DeferCleanup(ctx context.Context, id uuid.UUID, retryAt time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type chatAttachmentRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewChatAttachmentRepository(db *pgxpool.Pool, log logger.Logger) ChatAttachmentRepository {
+	return &chatAttachmentRepository{db: db, log: log}
+}
+
+const chatAttachmentColumns = `a.id, a.room_id, a.message_id, a.uploader_user_id, a.file_name, a.content_type,
+		       a.size_bytes, a.storage_key, a.thumbnail_key, a.width, a.height, a.created_at`
+
+func (r *chatAttachmentRepository) Create(ctx context.Context, attachment *domain.ChatAttachment) error {
+	query := `
+		INSERT INTO chat_attachments (id, room_id, uploader_user_id, file_name, content_type, size_bytes,
+		                              storage_key, thumbnail_key, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		attachment.ID, attachment.RoomID, attachment.UploaderUserID, attachment.FileName, attachment.ContentType,
+		attachment.SizeBytes, attachment.StorageKey, attachment.ThumbnailKey, attachment.Width, attachment.Height,
+	).Scan(&attachment.CreatedAt)
+	if err != nil {
+		r.log.Error("Failed to create chat attachment", "error", err, "room_id", attachment.RoomID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *chatAttachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ChatAttachment, error) {
+	query := `SELECT ` + chatAttachmentColumns + ` FROM chat_attachments a WHERE a.id = $1`
+
+	attachment, err := scanChatAttachment(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("attachment not found")
+		}
+		r.log.Error("Failed to get chat attachment", "error", err, "attachment_id", id)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (r *chatAttachmentRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.ChatAttachment, error) {
+	query := `SELECT ` + chatAttachmentColumns + ` FROM chat_attachments a WHERE a.id = ANY($1) ORDER BY a.created_at`
+
+	return r.query(ctx, query, ids)
+}
+
+func (r *chatAttachmentRepository) ListByMessages(ctx context.Context, messageIDs []int64) ([]*domain.ChatAttachment, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT ` + chatAttachmentColumns + ` FROM chat_attachments a WHERE a.message_id = ANY($1) ORDER BY a.created_at`
+
+	return r.query(ctx, query, messageIDs)
+}
+
+func (r *chatAttachmentRepository) ListExpired(ctx context.Context, defaultRetention time.Duration, pendingBefore time.Time, limit int) ([]*domain.ChatAttachment, error) {
+	query := `
+		SELECT ` + chatAttachmentColumns + `
+		FROM chat_attachments a
+		JOIN rooms r ON r.id = a.room_id
+		WHERE ((a.message_id IS NULL AND a.created_at < $2)
+		    OR (r.status IN ('ended', 'cancelled')
+		        AND COALESCE(r.actual_end_at, r.updated_at) + COALESCE(
+		            (r.settings->>'attachment_retention_days')::bigint * interval '1 day',
+		            $1 * interval '1 second'
+		        ) < now()))
+		  AND (a.cleanup_retry_at IS NULL OR a.cleanup_retry_at <= now())
+		ORDER BY a.cleanup_retry_at NULLS FIRST, a.created_at
+		LIMIT $3
+	`
+
+	return r.query(ctx, query, int64(defaultRetention/time.Second), pendingBefore, limit)
+}
+
+func (r *chatAttachmentRepository) DeferCleanup(ctx context.Context, id uuid.UUID, retryAt time.Time) error {
+	if _, err := r.db.Exec(ctx, `UPDATE chat_attachments SET cleanup_retry_at = $2 WHERE id = $1`, id, retryAt); err != nil {
+		r.log.Error("Failed to defer chat attachment cleanup", "error", err, "attachment_id", id)
+		return err
+	}
+	return nil
+}
+
+func (r *chatAttachmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM chat_attachments WHERE id = $1`, id); err != nil {
+		r.log.Error("Failed to delete chat attachment", "error", err, "attachment_id", id)
+		return err
+	}
+	return nil
+}
+
+func (r *chatAttachmentRepository) query(ctx context.Context, query string, args ...interface{}) ([]*domain.ChatAttachment, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		r.log.Error("Failed to list chat attachments", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*domain.ChatAttachment
+	for rows.Next() {
+		attachment, err := scanChatAttachment(rows)
+		if err != nil {
+			r.log.Error("Failed to scan chat attachment", "error", err)
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+func scanChatAttachment(row pgx.Row) (*domain.ChatAttachment, error) {
+	attachment := &domain.ChatAttachment{}
+	err := row.Scan(
+		&attachment.ID, &attachment.RoomID, &attachment.MessageID, &attachment.UploaderUserID,
+		&attachment.FileName, &attachment.ContentType, &attachment.SizeBytes, &attachment.StorageKey,
+		&attachment.ThumbnailKey, &attachment.Width, &attachment.Height, &attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
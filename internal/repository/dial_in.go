@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+type DialInRepository interface {
+	// Create сохраняет номер и PIN комнаты; занятый другой комнатой PIN - ошибка "dial-in pin already in use"
+	Create(ctx context.Context, dialIn *domain.RoomDialIn) error
+	GetByRoom(ctx context.Context, roomID uuid.UUID) (*domain.RoomDialIn, error)
+	PinExists(ctx context.Context, pin string) (bool, error)
+	Delete(ctx context.Context, roomID uuid.UUID) error
+}
+
+type dialInRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewDialInRepository(db *pgxpool.Pool, log logger.Logger) DialInRepository {
+	return &dialInRepository{db: db, log: log}
+}
+
+func (r *dialInRepository) Create(ctx context.Context, dialIn *domain.RoomDialIn) error {
+	query := `
+		INSERT INTO room_dial_ins (room_id, phone_number, pin, sip_dispatch_rule_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		dialIn.RoomID, dialIn.PhoneNumber, dialIn.PIN, dialIn.SIPDispatchRuleID, dialIn.CreatedAt,
+	).Scan(&dialIn.CreatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		// Код 23505 = unique_violation
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "room_dial_ins_pin_key" {
+			return errors.New("dial-in pin already in use")
+		}
+		r.log.Error("Failed to create room dial-in", "error", err, "room_id", dialIn.RoomID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *dialInRepository) GetByRoom(ctx context.Context, roomID uuid.UUID) (*domain.RoomDialIn, error) {
+	query := `
+		SELECT room_id, phone_number, pin, sip_dispatch_rule_id, created_at
+		FROM room_dial_ins
+		WHERE room_id = $1
+	`
+
+	dialIn := &domain.RoomDialIn{}
+	err := r.db.QueryRow(ctx, query, roomID).Scan(
+		&dialIn.RoomID, &dialIn.PhoneNumber, &dialIn.PIN, &dialIn.SIPDispatchRuleID, &dialIn.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("dial-in not found")
+		}
+		r.log.Error("Failed to get room dial-in", "error", err)
+		return nil, err
+	}
+
+	return dialIn, nil
+}
+
+func (r *dialInRepository) PinExists(ctx context.Context, pin string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM room_dial_ins WHERE pin = $1)`, pin).Scan(&exists)
+	if err != nil {
+		r.log.Error("Failed to check dial-in pin", "error", err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *dialInRepository) Delete(ctx context.Context, roomID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM room_dial_ins WHERE room_id = $1`, roomID)
+	if err != nil {
+		r.log.Error("Failed to delete room dial-in", "error", err, "room_id", roomID)
+		return err
+	}
+
+	return nil
+}
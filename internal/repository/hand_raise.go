@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+const (
+	// Очередь - sorted set ID участников по времени поднятия руки, данные руки - hash по ID участника
+	HandQueueKeyPrefix   = "room:%s:hands:queue"
+	HandEntriesKeyPrefix = "room:%s:hands:entries"
+
+	// Очередь забытой комнаты удаляется сама
+	HandQueueTTL = 12 * time.Hour
+)
+
+type HandRaiseRepository interface {
+	// Raise ставит участника в конец очереди; повторный вызов сохраняет его место и возвращает false
+	Raise(ctx context.Context, roomID uuid.UUID, hand *domain.RaisedHand) (bool, error)
+	// Lower убирает участника из очереди, возвращает false, если руки не было
+	Lower(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID) (bool, error)
+	Clear(ctx context.Context, roomID uuid.UUID) error
+	// List возвращает очередь в порядке поднятия рук
+	List(ctx context.Context, roomID uuid.UUID) ([]*domain.RaisedHand, error)
+	// PopNext атомарно забирает первого в очереди, nil - очередь пуста
+	PopNext(ctx context.Context, roomID uuid.UUID) (*domain.RaisedHand, error)
+}
+
+type handRaiseRepository struct {
+	rdb *redis.Client
+	log logger.Logger
+}
+
+func NewHandRaiseRepository(rdb *redis.Client, log logger.Logger) HandRaiseRepository {
+	return &handRaiseRepository{
+		rdb: rdb,
+		log: log,
+	}
+}
+
+func (r *handRaiseRepository) getQueueKey(roomID uuid.UUID) string {
+	return fmt.Sprintf(HandQueueKeyPrefix, roomID.String())
+}
+
+func (r *handRaiseRepository) getEntriesKey(roomID uuid.UUID) string {
+	return fmt.Sprintf(HandEntriesKeyPrefix, roomID.String())
+}
+
+func (r *handRaiseRepository) Raise(ctx context.Context, roomID uuid.UUID, hand *domain.RaisedHand) (bool, error) {
+	handJSON, err := json.Marshal(hand)
+	if err != nil {
+		r.log.Error("Failed to marshal raised hand", "error", err)
+		return false, fmt.Errorf("failed to marshal hand: %w", err)
+	}
+
+	queueKey := r.getQueueKey(roomID)
+	entriesKey := r.getEntriesKey(roomID)
+	member := hand.ParticipantID.String()
+
+	var added *redis.IntCmd
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// NX: повторно поднятая рука не теряет место в очереди
+		added = pipe.ZAddNX(ctx, queueKey, redis.Z{
+			Score:  float64(hand.RaisedAt.UnixMicro()),
+			Member: member,
+		})
+		pipe.HSetNX(ctx, entriesKey, member, handJSON)
+		pipe.Expire(ctx, queueKey, HandQueueTTL)
+		pipe.Expire(ctx, entriesKey, HandQueueTTL)
+		return nil
+	})
+	if err != nil {
+		r.log.Error("Failed to raise hand", "error", err, "room_id", roomID)
+		return false, err
+	}
+
+	return added.Val() > 0, nil
+}
+
+func (r *handRaiseRepository) Lower(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID) (bool, error) {
+	member := participantID.String()
+
+	var removed *redis.IntCmd
+	_, err := r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.ZRem(ctx, r.getQueueKey(roomID), member)
+		pipe.HDel(ctx, r.getEntriesKey(roomID), member)
+		return nil
+	})
+	if err != nil {
+		r.log.Error("Failed to lower hand", "error", err, "room_id", roomID)
+		return false, err
+	}
+
+	return removed.Val() > 0, nil
+}
+
+func (r *handRaiseRepository) Clear(ctx context.Context, roomID uuid.UUID) error {
+	if err := r.rdb.Del(ctx, r.getQueueKey(roomID), r.getEntriesKey(roomID)).Err(); err != nil {
+		r.log.Error("Failed to clear hand queue", "error", err, "room_id", roomID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *handRaiseRepository) List(ctx context.Context, roomID uuid.UUID) ([]*domain.RaisedHand, error) {
+	members, err := r.rdb.ZRange(ctx, r.getQueueKey(roomID), 0, -1).Result()
+	if err != nil {
+		r.log.Error("Failed to get hand queue", "error", err, "room_id", roomID)
+		return nil, err
+	}
+	if len(members) == 0 {
+		return []*domain.RaisedHand{}, nil
+	}
+
+	values, err := r.rdb.HMGet(ctx, r.getEntriesKey(roomID), members...).Result()
+	if err != nil {
+		r.log.Error("Failed to get raised hands", "error", err, "room_id", roomID)
+		return nil, err
+	}
+
+	hands := make([]*domain.RaisedHand, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var hand domain.RaisedHand
+		if err := json.Unmarshal([]byte(data), &hand); err != nil {
+			r.log.Warn("Failed to unmarshal raised hand", "error", err)
+			continue
+		}
+		hands = append(hands, &hand)
+	}
+
+	return hands, nil
+}
+
+func (r *handRaiseRepository) PopNext(ctx context.Context, roomID uuid.UUID) (*domain.RaisedHand, error) {
+	entriesKey := r.getEntriesKey(roomID)
+
+	for {
+		popped, err := r.rdb.ZPopMin(ctx, r.getQueueKey(roomID), 1).Result()
+		if err != nil {
+			r.log.Error("Failed to pop hand queue", "error", err, "room_id", roomID)
+			return nil, err
+		}
+		if len(popped) == 0 {
+			return nil, nil
+		}
+
+		member, _ := popped[0].Member.(string)
+		data, err := r.rdb.HGet(ctx, entriesKey, member).Result()
+		r.rdb.HDel(ctx, entriesKey, member)
+		if err == redis.Nil {
+			// Данные руки истекли отдельно от очереди - берем следующего
+			continue
+		}
+		if err != nil {
+			r.log.Error("Failed to get raised hand", "error", err, "room_id", roomID)
+			return nil, err
+		}
+
+		var hand domain.RaisedHand
+		if err := json.Unmarshal([]byte(data), &hand); err != nil {
+			r.log.Warn("Failed to unmarshal raised hand", "error", err)
+			continue
+		}
+		return &hand, nil
+	}
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+type IngressRepository interface {
+	Create(ctx context.Context, ingress *domain.RoomIngress) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.RoomIngress, error)
+	// ListByRoom возвращает ingress-ы комнаты, новые первыми; activeOnly - без отозванных
+	ListByRoom(ctx context.Context, roomID uuid.UUID, activeOnly bool) ([]*domain.RoomIngress, error)
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
+}
+
+type ingressRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewIngressRepository(db *pgxpool.Pool, log logger.Logger) IngressRepository {
+	return &ingressRepository{db: db, log: log}
+}
+
+const ingressColumns = `id, room_id, ingress_id, input_type, url, stream_key_encrypted, participant_name,
+		       created_by_user_id, created_at, revoked_at`
+
+func (r *ingressRepository) Create(ctx context.Context, ingress *domain.RoomIngress) error {
+	query := `
+		INSERT INTO room_ingresses (id, room_id, ingress_id, input_type, url, stream_key_encrypted, participant_name,
+		                            created_by_user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		ingress.ID, ingress.RoomID, ingress.IngressID, ingress.InputType, ingress.URL, ingress.StreamKeyEncrypted,
+		ingress.ParticipantName, ingress.CreatedByUserID, ingress.CreatedAt,
+	).Scan(&ingress.CreatedAt)
+
+	if err != nil {
+		r.log.Error("Failed to create room ingress", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ingressRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RoomIngress, error) {
+	query := `SELECT ` + ingressColumns + ` FROM room_ingresses WHERE id = $1`
+
+	ingress, err := scanIngress(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("ingress not found")
+		}
+		r.log.Error("Failed to get room ingress", "error", err)
+		return nil, err
+	}
+
+	return ingress, nil
+}
+
+func (r *ingressRepository) ListByRoom(ctx context.Context, roomID uuid.UUID, activeOnly bool) ([]*domain.RoomIngress, error) {
+	query := `
+		SELECT ` + ingressColumns + `
+		FROM room_ingresses
+		WHERE room_id = $1 AND (NOT $2 OR revoked_at IS NULL)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, roomID, activeOnly)
+	if err != nil {
+		r.log.Error("Failed to list room ingresses", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ingresses []*domain.RoomIngress
+	for rows.Next() {
+		ingress, err := scanIngress(rows)
+		if err != nil {
+			r.log.Error("Failed to scan room ingress", "error", err)
+			return nil, err
+		}
+		ingresses = append(ingresses, ingress)
+	}
+
+	return ingresses, rows.Err()
+}
+
+func (r *ingressRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	_, err := r.db.Exec(ctx, `UPDATE room_ingresses SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, revokedAt)
+	if err != nil {
+		r.log.Error("Failed to revoke room ingress", "error", err, "ingress_id", id)
+		return err
+	}
+
+	return nil
+}
+
+func scanIngress(row pgx.Row) (*domain.RoomIngress, error) {
+	ingress := &domain.RoomIngress{}
+	err := row.Scan(
+		&ingress.ID, &ingress.RoomID, &ingress.IngressID, &ingress.InputType, &ingress.URL, &ingress.StreamKeyEncrypted,
+		&ingress.ParticipantName, &ingress.CreatedByUserID, &ingress.CreatedAt, &ingress.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return ingress, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+type LiveStreamRepository interface {
+	Create(ctx context.Context, stream *domain.LiveStream) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.LiveStream, error)
+	GetByEgressID(ctx context.Context, egressID string) (*domain.LiveStream, error)
+	// GetPending возвращает трансляцию комнаты, для которой Egress запускается и egress_id еще не известен.
+	// Для RTMP трансляция выбирается по адресу сервера.
+	GetPending(ctx context.Context, roomID uuid.UUID, protocol string, rtmpURL *string) (*domain.LiveStream, error)
+	// AttachEgress сохраняет egress_id запущенной трансляции; повторная привязка того же egress не ошибка
+	AttachEgress(ctx context.Context, streamID uuid.UUID, egressID string) error
+	// ListByRoom возвращает трансляции комнаты, новые первыми
+	ListByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.LiveStream, error)
+	Update(ctx context.Context, stream *domain.LiveStream) error
+}
+
+type liveStreamRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewLiveStreamRepository(db *pgxpool.Pool, log logger.Logger) LiveStreamRepository {
+	return &liveStreamRepository{db: db, log: log}
+}
+
+const liveStreamColumns = `id, room_id, COALESCE(egress_id, ''), protocol, rtmp_url, stream_key_encrypted, playlist_location, status,
+		       error, started_by_user_id, started_at, ended_at, created_at, updated_at`
+
+func (r *liveStreamRepository) Create(ctx context.Context, stream *domain.LiveStream) error {
+	query := `
+		INSERT INTO live_streams (id, room_id, egress_id, protocol, rtmp_url, stream_key_encrypted, status,
+		                          started_by_user_id, started_at, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		stream.ID, stream.RoomID, stream.EgressID, stream.Protocol, stream.RTMPURL, stream.StreamKeyEncrypted, stream.Status,
+		stream.StartedByUserID, stream.StartedAt, stream.CreatedAt, stream.UpdatedAt,
+	).Scan(&stream.CreatedAt, &stream.UpdatedAt)
+
+	if err != nil {
+		r.log.Error("Failed to create live stream", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *liveStreamRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LiveStream, error) {
+	query := `SELECT ` + liveStreamColumns + ` FROM live_streams WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *liveStreamRepository) GetByEgressID(ctx context.Context, egressID string) (*domain.LiveStream, error) {
+	query := `SELECT ` + liveStreamColumns + ` FROM live_streams WHERE egress_id = $1`
+	return r.getOne(ctx, query, egressID)
+}
+
+func (r *liveStreamRepository) GetPending(ctx context.Context, roomID uuid.UUID, protocol string, rtmpURL *string) (*domain.LiveStream, error) {
+	query := `SELECT ` + liveStreamColumns + ` FROM live_streams
+		WHERE room_id = $1 AND protocol = $2 AND ($3::text IS NULL OR rtmp_url = $3) AND egress_id IS NULL AND status = 'starting'
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	stream, err := scanLiveStream(r.db.QueryRow(ctx, query, roomID, protocol, rtmpURL))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("stream not found")
+		}
+		r.log.Error("Failed to get pending live stream", "error", err)
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+func (r *liveStreamRepository) AttachEgress(ctx context.Context, streamID uuid.UUID, egressID string) error {
+	query := `
+		UPDATE live_streams
+		SET egress_id = $2, updated_at = now()
+		WHERE id = $1 AND (egress_id IS NULL OR egress_id = $2)
+	`
+
+	tag, err := r.db.Exec(ctx, query, streamID, egressID)
+	if err != nil {
+		r.log.Error("Failed to attach egress to live stream", "error", err, "stream_id", streamID)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("stream not found")
+	}
+
+	return nil
+}
+
+func (r *liveStreamRepository) ListByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.LiveStream, error) {
+	query := `SELECT ` + liveStreamColumns + ` FROM live_streams WHERE room_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, roomID)
+	if err != nil {
+		r.log.Error("Failed to list live streams", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var streams []*domain.LiveStream
+	for rows.Next() {
+		stream, err := scanLiveStream(rows)
+		if err != nil {
+			r.log.Error("Failed to scan live stream", "error", err)
+			return nil, err
+		}
+		streams = append(streams, stream)
+	}
+
+	return streams, rows.Err()
+}
+
+func (r *liveStreamRepository) Update(ctx context.Context, stream *domain.LiveStream) error {
+	query := `
+		UPDATE live_streams
+		SET status = $2, playlist_location = $3, error = $4, started_at = $5, ended_at = $6, updated_at = $7
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		stream.ID, stream.Status, stream.PlaylistLocation, stream.Error, stream.StartedAt, stream.EndedAt, stream.UpdatedAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to update live stream", "error", err, "stream_id", stream.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *liveStreamRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.LiveStream, error) {
+	stream, err := scanLiveStream(r.db.QueryRow(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("stream not found")
+		}
+		r.log.Error("Failed to get live stream", "error", err)
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+func scanLiveStream(row pgx.Row) (*domain.LiveStream, error) {
+	stream := &domain.LiveStream{}
+	err := row.Scan(
+		&stream.ID, &stream.RoomID, &stream.EgressID, &stream.Protocol, &stream.RTMPURL, &stream.StreamKeyEncrypted,
+		&stream.PlaylistLocation, &stream.Status, &stream.Error, &stream.StartedByUserID, &stream.StartedAt, &stream.EndedAt,
+		&stream.CreatedAt, &stream.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
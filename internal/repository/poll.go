@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+type PollRepository interface {
+	// Create сохраняет опрос вместе с вариантами ответа
+	Create(ctx context.Context, poll *domain.Poll) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error)
+	// ListByRoom возвращает опросы комнаты с вариантами, в порядке создания
+	ListByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.Poll, error)
+	Update(ctx context.Context, poll *domain.Poll) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// CloseOpenPolls закрывает все открытые опросы комнаты, возвращает их число
+	CloseOpenPolls(ctx context.Context, roomID uuid.UUID, closedAt time.Time) (int, error)
+	// ReplaceVotes заменяет голоса пользователя в опросе одной транзакцией
+	ReplaceVotes(ctx context.Context, pollID, userID uuid.UUID, votes []*domain.PollVote) error
+	ListVotes(ctx context.Context, pollIDs []uuid.UUID) ([]*domain.PollVote, error)
+}
+
+type pollRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewPollRepository(db *pgxpool.Pool, log logger.Logger) PollRepository {
+	return &pollRepository{db: db, log: log}
+}
+
+const pollColumns = `id, room_id, created_by_user_id, question, multiple_choice, anonymous, status,
+		       created_at, opened_at, closed_at`
+
+func (r *pollRepository) Create(ctx context.Context, poll *domain.Poll) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		r.log.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO polls (id, room_id, created_by_user_id, question, multiple_choice, anonymous, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	if _, err := tx.Exec(ctx, query,
+		poll.ID, poll.RoomID, poll.CreatedByUserID, poll.Question, poll.MultipleChoice, poll.Anonymous,
+		poll.Status, poll.CreatedAt,
+	); err != nil {
+		r.log.Error("Failed to create poll", "error", err, "room_id", poll.RoomID)
+		return err
+	}
+
+	for _, option := range poll.Options {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO poll_options (id, poll_id, position, text) VALUES ($1, $2, $3, $4)`,
+			option.ID, poll.ID, option.Position, option.Text,
+		); err != nil {
+			r.log.Error("Failed to create poll option", "error", err, "poll_id", poll.ID)
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.log.Error("Failed to commit poll", "error", err, "poll_id", poll.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *pollRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Poll, error) {
+	query := `SELECT ` + pollColumns + ` FROM polls WHERE id = $1`
+
+	poll, err := scanPoll(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("poll not found")
+		}
+		r.log.Error("Failed to get poll", "error", err)
+		return nil, err
+	}
+
+	if err := r.loadOptions(ctx, []*domain.Poll{poll}); err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+func (r *pollRepository) ListByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.Poll, error) {
+	query := `SELECT ` + pollColumns + ` FROM polls WHERE room_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, roomID)
+	if err != nil {
+		r.log.Error("Failed to list polls", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var polls []*domain.Poll
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			r.log.Error("Failed to scan poll", "error", err)
+			return nil, err
+		}
+		polls = append(polls, poll)
+	}
+	if err := rows.Err(); err != nil {
+		r.log.Error("Failed to iterate polls", "error", err)
+		return nil, err
+	}
+
+	if err := r.loadOptions(ctx, polls); err != nil {
+		return nil, err
+	}
+
+	return polls, nil
+}
+
+func (r *pollRepository) Update(ctx context.Context, poll *domain.Poll) error {
+	query := `
+		UPDATE polls
+		SET status = $2, opened_at = $3, closed_at = $4
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, poll.ID, poll.Status, poll.OpenedAt, poll.ClosedAt); err != nil {
+		r.log.Error("Failed to update poll", "error", err, "poll_id", poll.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *pollRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM polls WHERE id = $1`, id); err != nil {
+		r.log.Error("Failed to delete poll", "error", err, "poll_id", id)
+		return err
+	}
+
+	return nil
+}
+
+func (r *pollRepository) CloseOpenPolls(ctx context.Context, roomID uuid.UUID, closedAt time.Time) (int, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE polls SET status = 'closed', closed_at = $2 WHERE room_id = $1 AND status = 'open'`,
+		roomID, closedAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to close open polls", "error", err, "room_id", roomID)
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (r *pollRepository) ReplaceVotes(ctx context.Context, pollID, userID uuid.UUID, votes []*domain.PollVote) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		r.log.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM poll_votes WHERE poll_id = $1 AND user_id = $2`, pollID, userID); err != nil {
+		r.log.Error("Failed to clear poll votes", "error", err, "poll_id", pollID)
+		return err
+	}
+
+	for _, vote := range votes {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO poll_votes (poll_id, option_id, user_id, display_name, voted_at) VALUES ($1, $2, $3, $4, $5)`,
+			pollID, vote.OptionID, userID, vote.DisplayName, vote.VotedAt,
+		); err != nil {
+			r.log.Error("Failed to save poll vote", "error", err, "poll_id", pollID)
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.log.Error("Failed to commit poll votes", "error", err, "poll_id", pollID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *pollRepository) ListVotes(ctx context.Context, pollIDs []uuid.UUID) ([]*domain.PollVote, error) {
+	if len(pollIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT poll_id, option_id, user_id, display_name, voted_at
+		FROM poll_votes
+		WHERE poll_id = ANY($1)
+		ORDER BY voted_at
+	`
+
+	rows, err := r.db.Query(ctx, query, pollIDs)
+	if err != nil {
+		r.log.Error("Failed to list poll votes", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []*domain.PollVote
+	for rows.Next() {
+		vote := &domain.PollVote{}
+		if err := rows.Scan(&vote.PollID, &vote.OptionID, &vote.UserID, &vote.DisplayName, &vote.VotedAt); err != nil {
+			r.log.Error("Failed to scan poll vote", "error", err)
+			return nil, err
+		}
+		votes = append(votes, vote)
+	}
+
+	return votes, rows.Err()
+}
+
+// loadOptions заполняет варианты ответа опросов одним запросом
+func (r *pollRepository) loadOptions(ctx context.Context, polls []*domain.Poll) error {
+	if len(polls) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(polls))
+	byID := make(map[uuid.UUID]*domain.Poll, len(polls))
+	for _, poll := range polls {
+		poll.Options = []*domain.PollOption{}
+		ids = append(ids, poll.ID)
+		byID[poll.ID] = poll
+	}
+
+	rows, err := r.db.Query(ctx,
+		`SELECT id, poll_id, position, text FROM poll_options WHERE poll_id = ANY($1) ORDER BY poll_id, position`,
+		ids,
+	)
+	if err != nil {
+		r.log.Error("Failed to list poll options", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		option := &domain.PollOption{}
+		var pollID uuid.UUID
+		if err := rows.Scan(&option.ID, &pollID, &option.Position, &option.Text); err != nil {
+			r.log.Error("Failed to scan poll option", "error", err)
+			return err
+		}
+		if poll, ok := byID[pollID]; ok {
+			poll.Options = append(poll.Options, option)
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanPoll(row pgx.Row) (*domain.Poll, error) {
+	poll := &domain.Poll{}
+	err := row.Scan(
+		&poll.ID, &poll.RoomID, &poll.CreatedByUserID, &poll.Question, &poll.MultipleChoice, &poll.Anonymous,
+		&poll.Status, &poll.CreatedAt, &poll.OpenedAt, &poll.ClosedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
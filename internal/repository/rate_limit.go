@@ -11,6 +11,9 @@ import (
 type RateLimitRepository interface {
 	CheckLimit(ctx context.Context, key string, limit int, window time.Duration) (bool, error)
 	Increment(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Acquire атомарно занимает ключ на window (SET NX EX); false - ключ уже занят
+	Acquire(ctx context.Context, key string, window time.Duration) (bool, error)
+	Release(ctx context.Context, key string) error
 }
 
 type rateLimitRepository struct {
@@ -49,3 +52,21 @@ func (r *rateLimitRepository) Increment(ctx context.Context, key string, window
 	return count, nil
 }
 
+func (r *rateLimitRepository) Acquire(ctx context.Context, key string, window time.Duration) (bool, error) {
+	acquired, err := r.redis.SetNX(ctx, key, 1, window).Result()
+	if err != nil {
+		r.log.Error("Failed to acquire rate limit key", "error", err)
+		return false, err
+	}
+
+	return acquired, nil
+}
+
+func (r *rateLimitRepository) Release(ctx context.Context, key string) error {
+	if err := r.redis.Del(ctx, key).Err(); err != nil {
+		r.log.Error("Failed to release rate limit key", "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+type ReactionRepository interface {
+	// IncrementCount увеличивает счетчик реакций вида emoji за минуту minute
+	IncrementCount(ctx context.Context, roomID uuid.UUID, minute time.Time, emoji string) error
+	// ListCounts возвращает счетчики комнаты по минутам, в порядке времени
+	ListCounts(ctx context.Context, roomID uuid.UUID) ([]*domain.ReactionCount, error)
+}
+
+type reactionRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewReactionRepository(db *pgxpool.Pool, log logger.Logger) ReactionRepository {
+	return &reactionRepository{db: db, log: log}
+}
+
+func (r *reactionRepository) IncrementCount(ctx context.Context, roomID uuid.UUID, minute time.Time, emoji string) error {
+	query := `
+		INSERT INTO room_reaction_stats (room_id, minute, emoji, count)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (room_id, minute, emoji) DO UPDATE SET count = room_reaction_stats.count + 1
+	`
+
+	if _, err := r.db.Exec(ctx, query, roomID, minute, emoji); err != nil {
+		r.log.Error("Failed to increment reaction count", "error", err, "room_id", roomID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *reactionRepository) ListCounts(ctx context.Context, roomID uuid.UUID) ([]*domain.ReactionCount, error) {
+	query := `
+		SELECT minute, emoji, count
+		FROM room_reaction_stats
+		WHERE room_id = $1
+		ORDER BY minute, emoji
+	`
+
+	rows, err := r.db.Query(ctx, query, roomID)
+	if err != nil {
+		r.log.Error("Failed to list reaction counts", "error", err, "room_id", roomID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*domain.ReactionCount
+	for rows.Next() {
+		count := &domain.ReactionCount{}
+		if err := rows.Scan(&count.Minute, &count.Emoji, &count.Count); err != nil {
+			r.log.Error("Failed to scan reaction count", "error", err)
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+const (
+	// Канал Redis pub/sub для событий комнаты
+	RoomEventsChannelPrefix = "room:%s:events"
+)
+
+type RealtimeRepository interface {
+	// Опубликовать событие для всех реплик сервера
+	Publish(ctx context.Context, event *domain.RoomEvent) error
+
+	// Подписаться на события комнаты. Канал закрывается после вызова close.
+	Subscribe(ctx context.Context, roomID uuid.UUID) (<-chan *domain.RoomEvent, func() error, error)
+}
+
+type realtimeRepository struct {
+	rdb *redis.Client
+	log logger.Logger
+}
+
+func NewRealtimeRepository(rdb *redis.Client, log logger.Logger) RealtimeRepository {
+	return &realtimeRepository{
+		rdb: rdb,
+		log: log,
+	}
+}
+
+func (r *realtimeRepository) getChannel(roomID uuid.UUID) string {
+	return fmt.Sprintf(RoomEventsChannelPrefix, roomID.String())
+}
+
+func (r *realtimeRepository) Publish(ctx context.Context, event *domain.RoomEvent) error {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		r.log.Error("Failed to marshal room event", "error", err)
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err := r.rdb.Publish(ctx, r.getChannel(event.RoomID), eventJSON).Err(); err != nil {
+		r.log.Error("Failed to publish room event", "error", err, "room_id", event.RoomID, "type", event.Type)
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *realtimeRepository) Subscribe(ctx context.Context, roomID uuid.UUID) (<-chan *domain.RoomEvent, func() error, error) {
+	pubsub := r.rdb.Subscribe(ctx, r.getChannel(roomID))
+
+	// Дожидаемся подтверждения подписки, чтобы не потерять первые события
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		r.log.Error("Failed to subscribe to room events", "error", err, "room_id", roomID)
+		return nil, nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	events := make(chan *domain.RoomEvent)
+	go func() {
+		defer close(events)
+		for msg := range pubsub.Channel() {
+			var event domain.RoomEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				r.log.Warn("Failed to unmarshal room event", "error", err)
+				continue
+			}
+			events <- &event
+		}
+	}()
+
+	return events, pubsub.Close, nil
+}
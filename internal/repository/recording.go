@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+type RecordingRepository interface {
+	Create(ctx context.Context, recording *domain.Recording) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Recording, error)
+	GetByEgressID(ctx context.Context, egressID string) (*domain.Recording, error)
+	// GetPending возвращает запись комнаты, для которой Egress запускается и egress_id еще не известен
+	GetPending(ctx context.Context, roomID uuid.UUID, kind string, trackSID *string) (*domain.Recording, error)
+	// AttachEgress сохраняет egress_id запущенной записи; повторная привязка того же egress не ошибка
+	AttachEgress(ctx context.Context, recordingID uuid.UUID, egressID string) error
+	// ListByRoom возвращает записи комнаты, новые первыми
+	ListByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.Recording, error)
+	Update(ctx context.Context, recording *domain.Recording) error
+}
+
+type recordingRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewRecordingRepository(db *pgxpool.Pool, log logger.Logger) RecordingRepository {
+	return &recordingRepository{db: db, log: log}
+}
+
+const recordingColumns = `id, room_id, COALESCE(egress_id, ''), kind, track_sid, status, file_location, duration_seconds, size_bytes,
+		       error, started_by_user_id, started_at, ended_at, created_at, updated_at`
+
+func (r *recordingRepository) Create(ctx context.Context, recording *domain.Recording) error {
+	query := `
+		INSERT INTO recordings (id, room_id, egress_id, kind, track_sid, status, started_by_user_id, started_at, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		recording.ID, recording.RoomID, recording.EgressID, recording.Kind, recording.TrackSID, recording.Status,
+		recording.StartedByUserID, recording.StartedAt, recording.CreatedAt, recording.UpdatedAt,
+	).Scan(&recording.CreatedAt, &recording.UpdatedAt)
+
+	if err != nil {
+		r.log.Error("Failed to create recording", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *recordingRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Recording, error) {
+	query := `SELECT ` + recordingColumns + ` FROM recordings WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *recordingRepository) GetByEgressID(ctx context.Context, egressID string) (*domain.Recording, error) {
+	query := `SELECT ` + recordingColumns + ` FROM recordings WHERE egress_id = $1`
+	return r.getOne(ctx, query, egressID)
+}
+
+func (r *recordingRepository) GetPending(ctx context.Context, roomID uuid.UUID, kind string, trackSID *string) (*domain.Recording, error) {
+	query := `SELECT ` + recordingColumns + ` FROM recordings
+		WHERE room_id = $1 AND kind = $2 AND track_sid IS NOT DISTINCT FROM $3 AND egress_id IS NULL AND status = 'starting'
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	recording, err := scanRecording(r.db.QueryRow(ctx, query, roomID, kind, trackSID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("recording not found")
+		}
+		r.log.Error("Failed to get pending recording", "error", err)
+		return nil, err
+	}
+
+	return recording, nil
+}
+
+func (r *recordingRepository) AttachEgress(ctx context.Context, recordingID uuid.UUID, egressID string) error {
+	query := `
+		UPDATE recordings
+		SET egress_id = $2, updated_at = now()
+		WHERE id = $1 AND (egress_id IS NULL OR egress_id = $2)
+	`
+
+	tag, err := r.db.Exec(ctx, query, recordingID, egressID)
+	if err != nil {
+		r.log.Error("Failed to attach egress to recording", "error", err, "recording_id", recordingID)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("recording not found")
+	}
+
+	return nil
+}
+
+func (r *recordingRepository) ListByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.Recording, error) {
+	query := `SELECT ` + recordingColumns + ` FROM recordings WHERE room_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, roomID)
+	if err != nil {
+		r.log.Error("Failed to list recordings", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recordings []*domain.Recording
+	for rows.Next() {
+		recording, err := scanRecording(rows)
+		if err != nil {
+			r.log.Error("Failed to scan recording", "error", err)
+			return nil, err
+		}
+		recordings = append(recordings, recording)
+	}
+
+	return recordings, rows.Err()
+}
+
+func (r *recordingRepository) Update(ctx context.Context, recording *domain.Recording) error {
+	query := `
+		UPDATE recordings
+		SET status = $2, file_location = $3, duration_seconds = $4, size_bytes = $5, error = $6,
+		    started_at = $7, ended_at = $8, updated_at = $9
+		WHERE id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		recording.ID, recording.Status, recording.FileLocation, recording.DurationSeconds, recording.SizeBytes, recording.Error,
+		recording.StartedAt, recording.EndedAt, recording.UpdatedAt,
+	)
+	if err != nil {
+		r.log.Error("Failed to update recording", "error", err, "recording_id", recording.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *recordingRepository) getOne(ctx context.Context, query string, arg interface{}) (*domain.Recording, error) {
+	recording, err := scanRecording(r.db.QueryRow(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("recording not found")
+		}
+		r.log.Error("Failed to get recording", "error", err)
+		return nil, err
+	}
+
+	return recording, nil
+}
+
+func scanRecording(row pgx.Row) (*domain.Recording, error) {
+	recording := &domain.Recording{}
+	err := row.Scan(
+		&recording.ID, &recording.RoomID, &recording.EgressID, &recording.Kind, &recording.TrackSID, &recording.Status,
+		&recording.FileLocation, &recording.DurationSeconds, &recording.SizeBytes, &recording.Error,
+		&recording.StartedByUserID, &recording.StartedAt, &recording.EndedAt, &recording.CreatedAt, &recording.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return recording, nil
+}
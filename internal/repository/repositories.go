@@ -9,24 +9,48 @@ import (
 type Repositories struct {
 	User           UserRepository
 	Room           RoomRepository
+	RoomSeries     RoomSeriesRepository
+	Calendar       CalendarRepository
 	AnonymousRoom  AnonymousRoomRepository
 	AnonymousChat  AnonymousChatRepository
 	Chat           ChatRepository
+	ChatAttachment ChatAttachmentRepository
+	Recording      RecordingRepository
+	LiveStream     LiveStreamRepository
+	DialIn         DialInRepository
+	Ingress        IngressRepository
+	Breakout       BreakoutRepository
+	Poll           PollRepository
+	HandRaise      HandRaiseRepository
+	Reaction       ReactionRepository
 	Stats          StatsRepository
 	Audit          AuditRepository
 	RateLimit      RateLimitRepository
+	Realtime       RealtimeRepository
 }
 
 func NewRepositories(db *pgxpool.Pool, redis *redis.Client, log logger.Logger) *Repositories {
 	repos := &Repositories{
 		User:          NewUserRepository(db, log),
 		Room:          NewRoomRepository(db, log),
+		RoomSeries:    NewRoomSeriesRepository(db, log),
+		Calendar:      NewCalendarRepository(db, log),
 		AnonymousRoom: NewAnonymousRoomRepository(db, log),
 		AnonymousChat: NewAnonymousChatRepository(redis, log),
 		Chat:          NewChatRepository(db, log),
+		ChatAttachment: NewChatAttachmentRepository(db, log),
+		Recording:     NewRecordingRepository(db, log),
+		LiveStream:    NewLiveStreamRepository(db, log),
+		DialIn:        NewDialInRepository(db, log),
+		Ingress:       NewIngressRepository(db, log),
+		Breakout:      NewBreakoutRepository(db, log),
+		Poll:          NewPollRepository(db, log),
+		HandRaise:     NewHandRaiseRepository(redis, log),
+		Reaction:      NewReactionRepository(db, log),
 		Stats:         NewStatsRepository(db, log),
 		Audit:         NewAuditRepository(db, log),
 		RateLimit:     NewRateLimitRepository(redis, log),
+		Realtime:      NewRealtimeRepository(redis, log),
 	}
 	
 	if repos.AnonymousRoom != nil {
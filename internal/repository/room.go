@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"video_conference/internal/domain"
 	"video_conference/pkg/logger"
@@ -18,19 +19,45 @@ type RoomRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Room, error)
 	GetByLiveKitRoomName(ctx context.Context, name string) (*domain.Room, error)
 	List(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*domain.Room, error)
+	// Update сохраняет поля комнаты, кроме settings: они меняются только через UpdateSettings и UpdateSettingsList
 	Update(ctx context.Context, room *domain.Room) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	CreateInvite(ctx context.Context, invite *domain.RoomInvite) error
 	GetInviteByToken(ctx context.Context, token string) (*domain.RoomInvite, error)
-	IncrementInviteUsage(ctx context.Context, inviteID uuid.UUID) error
+	RedeemInvite(ctx context.Context, inviteID uuid.UUID, participant *domain.RoomParticipant) error
+	GetInviteByID(ctx context.Context, inviteID uuid.UUID) (*domain.RoomInvite, error)
+	ListInvites(ctx context.Context, roomID uuid.UUID) ([]*domain.RoomInvite, error)
+	RevokeInvite(ctx context.Context, inviteID uuid.UUID) error
 	CreateParticipant(ctx context.Context, participant *domain.RoomParticipant) error
 	GetParticipant(ctx context.Context, roomID, userID uuid.UUID) (*domain.RoomParticipant, error)
 	GetParticipantByID(ctx context.Context, participantID uuid.UUID) (*domain.RoomParticipant, error)
 	GetParticipantsByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.RoomParticipant, error)
 	UpdateParticipant(ctx context.Context, participant *domain.RoomParticipant) error
+	IsBanned(ctx context.Context, roomID, userID uuid.UUID) (bool, error)
+	GetParticipantByLiveKitSID(ctx context.Context, sid string) (*domain.RoomParticipant, error)
+	CloseOpenParticipants(ctx context.Context, roomID uuid.UUID, leftAt time.Time, reason string) (int64, error)
+	ListRoomsToEnd(ctx context.Context, now time.Time, inactiveSince time.Time, limit int) ([]*domain.Room, error)
+	ListSeriesOccurrences(ctx context.Context, seriesID uuid.UUID) ([]*domain.Room, error)
+	ListUpcoming(ctx context.Context, hostUserID uuid.UUID, from time.Time, limit int) ([]*domain.Room, error)
+	ListCalendarRooms(ctx context.Context, userID uuid.UUID, since time.Time, limit int) ([]*domain.Room, error)
+	// UpdateSettings атомарно меняет отдельные ключи settings комнаты: set записывает значения,
+	// unset удаляет ключи; остальные настройки не затрагиваются. Возвращает новые settings.
+	UpdateSettings(ctx context.Context, roomID uuid.UUID, set map[string]interface{}, unset []string) (map[string]interface{}, error)
+	// UpdateSettingsList добавляет строку в список settings[key] или удаляет ее оттуда;
+	// опустевший список удаляется. Возвращает новые settings.
+	UpdateSettingsList(ctx context.Context, roomID uuid.UUID, key string, value string, include bool) (map[string]interface{}, error)
+	// CreateBreakouts создает сессионные залы одной транзакцией: либо все, либо ни одного
+	CreateBreakouts(ctx context.Context, rooms []*domain.Room) error
+	// ListBreakouts возвращает незавершенные сессионные залы комнаты в порядке создания
+	ListBreakouts(ctx context.Context, parentRoomID uuid.UUID) ([]*domain.Room, error)
 	CreateWaitingRoomEntry(ctx context.Context, entry *domain.WaitingRoomEntry) error
 	GetWaitingRoomEntries(ctx context.Context, roomID uuid.UUID, status string) ([]*domain.WaitingRoomEntry, error)
-	UpdateWaitingRoomEntry(ctx context.Context, entry *domain.WaitingRoomEntry) error
+	// DecideWaitingRoomEntry атомарно переводит заявку из pending в решенный статус и,
+	// если передан participant, создает его в той же транзакции
+	DecideWaitingRoomEntry(ctx context.Context, entry *domain.WaitingRoomEntry, participant *domain.RoomParticipant) error
+	GetWaitingRoomEntryByID(ctx context.Context, entryID uuid.UUID) (*domain.WaitingRoomEntry, error)
+	GetPendingWaitingRoomEntry(ctx context.Context, roomID, userID uuid.UUID) (*domain.WaitingRoomEntry, error)
+	ExpireWaitingRoomEntries(ctx context.Context, roomID uuid.UUID, requestedBefore time.Time) ([]*domain.WaitingRoomEntry, error)
 }
 
 type roomRepository struct {
@@ -43,26 +70,53 @@ func NewRoomRepository(db *pgxpool.Pool, log logger.Logger) RoomRepository {
 }
 
 func (r *roomRepository) Create(ctx context.Context, room *domain.Room) error {
+	if err := insertRoom(ctx, r.db, room); err != nil {
+		r.log.Error("Failed to create room", "error", err)
+		return err
+	}
+	
+	return nil
+}
+
+func (r *roomRepository) CreateBreakouts(ctx context.Context, rooms []*domain.Room) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		r.log.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, room := range rooms {
+		if err := insertRoom(ctx, tx, room); err != nil {
+			r.log.Error("Failed to create breakout", "error", err, "parent_room_id", room.ParentRoomID)
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.log.Error("Failed to commit breakouts", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func insertRoom(ctx context.Context, db dbtx, room *domain.Room) error {
 	query := `
 		INSERT INTO rooms (id, livekit_room_name, host_user_id, title, description, status, 
 		                  scheduled_start_at, scheduled_end_at, max_participants, waiting_room_enabled,
-		                  is_locked, password_hash, settings, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		                  is_locked, password_hash, settings, series_id, occurrence_start_at,
+		                  parent_room_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING created_at, updated_at
 	`
 	
-	err := r.db.QueryRow(ctx, query,
+	return db.QueryRow(ctx, query,
 		room.ID, room.LiveKitRoomName, room.HostUserID, room.Title, room.Description, room.Status,
 		room.ScheduledStartAt, room.ScheduledEndAt, room.MaxParticipants, room.WaitingRoomEnabled,
-		room.IsLocked, room.PasswordHash, room.Settings, room.CreatedAt, room.UpdatedAt,
+		room.IsLocked, room.PasswordHash, room.Settings, room.SeriesID, room.OccurrenceStartAt,
+		room.ParentRoomID, room.CreatedAt, room.UpdatedAt,
 	).Scan(&room.CreatedAt, &room.UpdatedAt)
-	
-	if err != nil {
-		r.log.Error("Failed to create room", "error", err)
-		return err
-	}
-	
-	return nil
 }
 
 func (r *roomRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Room, error) {
@@ -70,7 +124,7 @@ func (r *roomRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Roo
 		SELECT id, livekit_room_name, host_user_id, title, description, status,
 		       scheduled_start_at, scheduled_end_at, actual_start_at, actual_end_at,
 		       max_participants, waiting_room_enabled, is_locked, password_hash, settings,
-		       created_at, updated_at
+		       series_id, occurrence_start_at, parent_room_id, created_at, updated_at
 		FROM rooms
 		WHERE id = $1
 	`
@@ -80,7 +134,7 @@ func (r *roomRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Roo
 		&room.ID, &room.LiveKitRoomName, &room.HostUserID, &room.Title, &room.Description, &room.Status,
 		&room.ScheduledStartAt, &room.ScheduledEndAt, &room.ActualStartAt, &room.ActualEndAt,
 		&room.MaxParticipants, &room.WaitingRoomEnabled, &room.IsLocked, &room.PasswordHash, &room.Settings,
-		&room.CreatedAt, &room.UpdatedAt,
+		&room.SeriesID, &room.OccurrenceStartAt, &room.ParentRoomID, &room.CreatedAt, &room.UpdatedAt,
 	)
 	
 	if err != nil {
@@ -99,7 +153,7 @@ func (r *roomRepository) GetByLiveKitRoomName(ctx context.Context, name string)
 		SELECT id, livekit_room_name, host_user_id, title, description, status,
 		       scheduled_start_at, scheduled_end_at, actual_start_at, actual_end_at,
 		       max_participants, waiting_room_enabled, is_locked, password_hash, settings,
-		       created_at, updated_at
+		       series_id, occurrence_start_at, parent_room_id, created_at, updated_at
 		FROM rooms
 		WHERE livekit_room_name = $1
 	`
@@ -109,7 +163,7 @@ func (r *roomRepository) GetByLiveKitRoomName(ctx context.Context, name string)
 		&room.ID, &room.LiveKitRoomName, &room.HostUserID, &room.Title, &room.Description, &room.Status,
 		&room.ScheduledStartAt, &room.ScheduledEndAt, &room.ActualStartAt, &room.ActualEndAt,
 		&room.MaxParticipants, &room.WaitingRoomEnabled, &room.IsLocked, &room.PasswordHash, &room.Settings,
-		&room.CreatedAt, &room.UpdatedAt,
+		&room.SeriesID, &room.OccurrenceStartAt, &room.ParentRoomID, &room.CreatedAt, &room.UpdatedAt,
 	)
 	
 	if err != nil {
@@ -128,9 +182,9 @@ func (r *roomRepository) List(ctx context.Context, userID uuid.UUID, limit, offs
 		SELECT id, livekit_room_name, host_user_id, title, description, status,
 		       scheduled_start_at, scheduled_end_at, actual_start_at, actual_end_at,
 		       max_participants, waiting_room_enabled, is_locked, password_hash, settings,
-		       created_at, updated_at
+		       series_id, occurrence_start_at, parent_room_id, created_at, updated_at
 		FROM rooms
-		WHERE host_user_id = $1
+		WHERE host_user_id = $1 AND parent_room_id IS NULL
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -149,7 +203,7 @@ func (r *roomRepository) List(ctx context.Context, userID uuid.UUID, limit, offs
 			&room.ID, &room.LiveKitRoomName, &room.HostUserID, &room.Title, &room.Description, &room.Status,
 			&room.ScheduledStartAt, &room.ScheduledEndAt, &room.ActualStartAt, &room.ActualEndAt,
 			&room.MaxParticipants, &room.WaitingRoomEnabled, &room.IsLocked, &room.PasswordHash, &room.Settings,
-			&room.CreatedAt, &room.UpdatedAt,
+			&room.SeriesID, &room.OccurrenceStartAt, &room.ParentRoomID, &room.CreatedAt, &room.UpdatedAt,
 		)
 		if err != nil {
 			r.log.Error("Failed to scan room", "error", err)
@@ -167,7 +221,7 @@ func (r *roomRepository) Update(ctx context.Context, room *domain.Room) error {
 		SET title = $2, description = $3, status = $4, scheduled_start_at = $5,
 		    scheduled_end_at = $6, actual_start_at = $7, actual_end_at = $8,
 		    max_participants = $9, waiting_room_enabled = $10, is_locked = $11,
-		    password_hash = $12, settings = $13, updated_at = $14
+		    password_hash = $12, updated_at = $13
 		WHERE id = $1
 		RETURNING updated_at
 	`
@@ -176,7 +230,7 @@ func (r *roomRepository) Update(ctx context.Context, room *domain.Room) error {
 		room.ID, room.Title, room.Description, room.Status,
 		room.ScheduledStartAt, room.ScheduledEndAt, room.ActualStartAt, room.ActualEndAt,
 		room.MaxParticipants, room.WaitingRoomEnabled, room.IsLocked,
-		room.PasswordHash, room.Settings, time.Now(),
+		room.PasswordHash, time.Now(),
 	).Scan(&room.UpdatedAt)
 	
 	if err != nil {
@@ -187,6 +241,60 @@ func (r *roomRepository) Update(ctx context.Context, room *domain.Room) error {
 	return nil
 }
 
+func (r *roomRepository) UpdateSettings(ctx context.Context, roomID uuid.UUID, set map[string]interface{}, unset []string) (map[string]interface{}, error) {
+	if set == nil {
+		set = map[string]interface{}{}
+	}
+	if unset == nil {
+		unset = []string{}
+	}
+
+	query := `
+		UPDATE rooms
+		SET settings = (COALESCE(settings, '{}'::jsonb) - $3::text[]) || $2::jsonb, updated_at = now()
+		WHERE id = $1
+		RETURNING settings
+	`
+
+	var settings map[string]interface{}
+	if err := r.db.QueryRow(ctx, query, roomID, set, unset).Scan(&settings); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("room not found")
+		}
+		r.log.Error("Failed to update room settings", "error", err, "room_id", roomID)
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (r *roomRepository) UpdateSettingsList(ctx context.Context, roomID uuid.UUID, key string, value string, include bool) (map[string]interface{}, error) {
+	// Значение сначала удаляется из списка, поэтому повторное добавление не создает дубликат
+	query := `
+		UPDATE rooms
+		SET settings = CASE
+		        WHEN $4 THEN jsonb_set(COALESCE(settings, '{}'::jsonb), ARRAY[$2::text],
+		                               (COALESCE(settings->$2::text, '[]'::jsonb) - $3::text) || to_jsonb($3::text))
+		        WHEN COALESCE(settings->$2::text, '[]'::jsonb) - $3::text = '[]'::jsonb THEN COALESCE(settings, '{}'::jsonb) - $2::text
+		        ELSE jsonb_set(settings, ARRAY[$2::text], (settings->$2::text) - $3::text)
+		    END,
+		    updated_at = now()
+		WHERE id = $1
+		RETURNING settings
+	`
+
+	var settings map[string]interface{}
+	if err := r.db.QueryRow(ctx, query, roomID, key, value, include).Scan(&settings); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("room not found")
+		}
+		r.log.Error("Failed to update room settings list", "error", err, "room_id", roomID, "key", key)
+		return nil, err
+	}
+
+	return settings, nil
+}
+
 func (r *roomRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM rooms WHERE id = $1`
 	_, err := r.db.Exec(ctx, query, id)
@@ -218,7 +326,7 @@ func (r *roomRepository) CreateInvite(ctx context.Context, invite *domain.RoomIn
 
 func (r *roomRepository) GetInviteByToken(ctx context.Context, token string) (*domain.RoomInvite, error) {
 	query := `
-		SELECT id, room_id, created_by_user_id, link_token, label, expires_at, max_uses, used_count, created_at
+		SELECT id, room_id, created_by_user_id, link_token, label, expires_at, max_uses, used_count, revoked_at, created_at
 		FROM room_invites
 		WHERE link_token = $1
 	`
@@ -226,7 +334,7 @@ func (r *roomRepository) GetInviteByToken(ctx context.Context, token string) (*d
 	invite := &domain.RoomInvite{}
 	err := r.db.QueryRow(ctx, query, token).Scan(
 		&invite.ID, &invite.RoomID, &invite.CreatedByUserID, &invite.LinkToken,
-		&invite.Label, &invite.ExpiresAt, &invite.MaxUses, &invite.UsedCount, &invite.CreatedAt,
+		&invite.Label, &invite.ExpiresAt, &invite.MaxUses, &invite.UsedCount, &invite.RevokedAt, &invite.CreatedAt,
 	)
 	
 	if err != nil {
@@ -240,30 +348,116 @@ func (r *roomRepository) GetInviteByToken(ctx context.Context, token string) (*d
 	return invite, nil
 }
 
-func (r *roomRepository) IncrementInviteUsage(ctx context.Context, inviteID uuid.UUID) error {
-	query := `UPDATE room_invites SET used_count = used_count + 1 WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, inviteID)
+// RedeemInvite в одной транзакции учитывает использование приглашения и создает участника.
+// Проверки срока действия, лимита и отзыва выполняются в том же UPDATE,
+// поэтому параллельные запросы не могут превысить max_uses, а неудачный вход
+// не расходует использование.
+func (r *roomRepository) RedeemInvite(ctx context.Context, inviteID uuid.UUID, participant *domain.RoomParticipant) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		r.log.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE room_invites
+		SET used_count = used_count + 1
+		WHERE id = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > now())
+		  AND (max_uses IS NULL OR used_count < max_uses)
+	`
+	tag, err := tx.Exec(ctx, query, inviteID)
 	if err != nil {
 		r.log.Error("Failed to increment invite usage", "error", err)
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("invite is no longer valid")
+	}
+
+	if err := insertParticipant(ctx, tx, participant); err != nil {
+		r.log.Error("Failed to create participant", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.log.Error("Failed to commit invite redemption", "error", err, "invite_id", inviteID)
+		return err
+	}
+
 	return nil
 }
 
-func (r *roomRepository) CreateParticipant(ctx context.Context, participant *domain.RoomParticipant) error {
+func (r *roomRepository) GetInviteByID(ctx context.Context, inviteID uuid.UUID) (*domain.RoomInvite, error) {
 	query := `
-		INSERT INTO room_participants (id, room_id, user_id, role, display_name, livekit_sid,
-		                              joined_at, initial_muted, client_ip, user_agent)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		SELECT id, room_id, created_by_user_id, link_token, label, expires_at, max_uses, used_count, revoked_at, created_at
+		FROM room_invites
+		WHERE id = $1
 	`
-	
-	_, err := r.db.Exec(ctx, query,
-		participant.ID, participant.RoomID, participant.UserID, participant.Role,
-		participant.DisplayName, participant.LiveKitSID, participant.JoinedAt,
-		participant.InitialMuted, participant.ClientIP, participant.UserAgent,
+
+	invite := &domain.RoomInvite{}
+	err := r.db.QueryRow(ctx, query, inviteID).Scan(
+		&invite.ID, &invite.RoomID, &invite.CreatedByUserID, &invite.LinkToken,
+		&invite.Label, &invite.ExpiresAt, &invite.MaxUses, &invite.UsedCount, &invite.RevokedAt, &invite.CreatedAt,
 	)
-	
+
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("invite not found")
+		}
+		r.log.Error("Failed to get invite by ID", "error", err)
+		return nil, err
+	}
+
+	return invite, nil
+}
+
+func (r *roomRepository) ListInvites(ctx context.Context, roomID uuid.UUID) ([]*domain.RoomInvite, error) {
+	query := `
+		SELECT id, room_id, created_by_user_id, link_token, label, expires_at, max_uses, used_count, revoked_at, created_at
+		FROM room_invites
+		WHERE room_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, roomID)
+	if err != nil {
+		r.log.Error("Failed to list invites", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []*domain.RoomInvite
+	for rows.Next() {
+		invite := &domain.RoomInvite{}
+		err := rows.Scan(
+			&invite.ID, &invite.RoomID, &invite.CreatedByUserID, &invite.LinkToken,
+			&invite.Label, &invite.ExpiresAt, &invite.MaxUses, &invite.UsedCount, &invite.RevokedAt, &invite.CreatedAt,
+		)
+		if err != nil {
+			r.log.Error("Failed to scan invite", "error", err)
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+
+	return invites, nil
+}
+
+func (r *roomRepository) RevokeInvite(ctx context.Context, inviteID uuid.UUID) error {
+	query := `UPDATE room_invites SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+	_, err := r.db.Exec(ctx, query, inviteID)
+	if err != nil {
+		r.log.Error("Failed to revoke invite", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *roomRepository) CreateParticipant(ctx context.Context, participant *domain.RoomParticipant) error {
+	if err := insertParticipant(ctx, r.db, participant); err != nil {
 		r.log.Error("Failed to create participant", "error", err)
 		return err
 	}
@@ -271,10 +465,36 @@ func (r *roomRepository) CreateParticipant(ctx context.Context, participant *dom
 	return nil
 }
 
+// dbtx - общий для пула и транзакции интерфейс выполнения запросов
+type dbtx interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+func insertParticipant(ctx context.Context, db dbtx, participant *domain.RoomParticipant) error {
+	query := `
+		INSERT INTO room_participants (id, room_id, user_id, role, display_name, livekit_sid,
+		                              joined_at, initial_muted, client_ip, user_agent, source, caller_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	if participant.Source == "" {
+		participant.Source = domain.ParticipantSourceWeb
+	}
+	
+	_, err := db.Exec(ctx, query,
+		participant.ID, participant.RoomID, participant.UserID, participant.Role,
+		participant.DisplayName, participant.LiveKitSID, participant.JoinedAt,
+		participant.InitialMuted, participant.ClientIP, participant.UserAgent,
+		participant.Source, participant.CallerID,
+	)
+	return err
+}
+
 func (r *roomRepository) GetParticipant(ctx context.Context, roomID, userID uuid.UUID) (*domain.RoomParticipant, error) {
 	query := `
 		SELECT id, room_id, user_id, role, display_name, livekit_sid, joined_at, left_at,
-		       leave_reason, is_kicked, initial_muted, client_ip, user_agent
+		       leave_reason, is_kicked, initial_muted, client_ip, user_agent, source, caller_id
 		FROM room_participants
 		WHERE room_id = $1 AND user_id = $2 AND left_at IS NULL
 		ORDER BY joined_at DESC
@@ -287,7 +507,7 @@ func (r *roomRepository) GetParticipant(ctx context.Context, roomID, userID uuid
 		&participant.ID, &participant.RoomID, &participant.UserID, &participant.Role,
 		&participant.DisplayName, &participant.LiveKitSID, &participant.JoinedAt, &leftAt,
 		&participant.LeaveReason, &participant.IsKicked, &participant.InitialMuted,
-		&participant.ClientIP, &participant.UserAgent,
+		&participant.ClientIP, &participant.UserAgent, &participant.Source, &participant.CallerID,
 	)
 	
 	if err != nil {
@@ -308,7 +528,7 @@ func (r *roomRepository) GetParticipant(ctx context.Context, roomID, userID uuid
 func (r *roomRepository) GetParticipantByID(ctx context.Context, participantID uuid.UUID) (*domain.RoomParticipant, error) {
 	query := `
 		SELECT id, room_id, user_id, role, display_name, livekit_sid, joined_at, left_at,
-		       leave_reason, is_kicked, initial_muted, client_ip, user_agent
+		       leave_reason, is_kicked, initial_muted, client_ip, user_agent, source, caller_id
 		FROM room_participants
 		WHERE id = $1
 	`
@@ -319,7 +539,7 @@ func (r *roomRepository) GetParticipantByID(ctx context.Context, participantID u
 		&participant.ID, &participant.RoomID, &participant.UserID, &participant.Role,
 		&participant.DisplayName, &participant.LiveKitSID, &participant.JoinedAt, &leftAt,
 		&participant.LeaveReason, &participant.IsKicked, &participant.InitialMuted,
-		&participant.ClientIP, &participant.UserAgent,
+		&participant.ClientIP, &participant.UserAgent, &participant.Source, &participant.CallerID,
 	)
 	
 	if err != nil {
@@ -340,7 +560,7 @@ func (r *roomRepository) GetParticipantByID(ctx context.Context, participantID u
 func (r *roomRepository) GetParticipantsByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.RoomParticipant, error) {
 	query := `
 		SELECT id, room_id, user_id, role, display_name, livekit_sid, joined_at, left_at,
-		       leave_reason, is_kicked, initial_muted, client_ip, user_agent
+		       leave_reason, is_kicked, initial_muted, client_ip, user_agent, source, caller_id
 		FROM room_participants
 		WHERE room_id = $1 AND left_at IS NULL
 		ORDER BY joined_at ASC
@@ -361,7 +581,7 @@ func (r *roomRepository) GetParticipantsByRoom(ctx context.Context, roomID uuid.
 			&participant.ID, &participant.RoomID, &participant.UserID, &participant.Role,
 			&participant.DisplayName, &participant.LiveKitSID, &participant.JoinedAt, &leftAt,
 			&participant.LeaveReason, &participant.IsKicked, &participant.InitialMuted,
-			&participant.ClientIP, &participant.UserAgent,
+			&participant.ClientIP, &participant.UserAgent, &participant.Source, &participant.CallerID,
 		)
 		if err != nil {
 			r.log.Error("Failed to scan participant", "error", err)
@@ -379,13 +599,15 @@ func (r *roomRepository) GetParticipantsByRoom(ctx context.Context, roomID uuid.
 func (r *roomRepository) UpdateParticipant(ctx context.Context, participant *domain.RoomParticipant) error {
 	query := `
 		UPDATE room_participants
-		SET left_at = $3, leave_reason = $4, is_kicked = $5
+		SET left_at = $3, leave_reason = $4, is_kicked = $5, role = $6,
+		    livekit_sid = $7, joined_at = $8
 		WHERE id = $1 AND room_id = $2
 	`
 	
 	_, err := r.db.Exec(ctx, query,
 		participant.ID, participant.RoomID, participant.LeftAt,
-		participant.LeaveReason, participant.IsKicked,
+		participant.LeaveReason, participant.IsKicked, participant.Role,
+		participant.LiveKitSID, participant.JoinedAt,
 	)
 	
 	if err != nil {
@@ -396,6 +618,207 @@ func (r *roomRepository) UpdateParticipant(ctx context.Context, participant *dom
 	return nil
 }
 
+func (r *roomRepository) GetParticipantByLiveKitSID(ctx context.Context, sid string) (*domain.RoomParticipant, error) {
+	query := `
+		SELECT id, room_id, user_id, role, display_name, livekit_sid, joined_at, left_at,
+		       leave_reason, is_kicked, initial_muted, client_ip, user_agent, source, caller_id
+		FROM room_participants
+		WHERE livekit_sid = $1
+	`
+
+	participant := &domain.RoomParticipant{}
+	var leftAt sql.NullTime
+	err := r.db.QueryRow(ctx, query, sid).Scan(
+		&participant.ID, &participant.RoomID, &participant.UserID, &participant.Role,
+		&participant.DisplayName, &participant.LiveKitSID, &participant.JoinedAt, &leftAt,
+		&participant.LeaveReason, &participant.IsKicked, &participant.InitialMuted,
+		&participant.ClientIP, &participant.UserAgent, &participant.Source, &participant.CallerID,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("participant not found")
+		}
+		r.log.Error("Failed to get participant by LiveKit SID", "error", err)
+		return nil, err
+	}
+
+	if leftAt.Valid {
+		participant.LeftAt = &leftAt.Time
+	}
+
+	return participant, nil
+}
+
+// CloseOpenParticipants отмечает выход всех участников, которые еще числятся в комнате
+func (r *roomRepository) CloseOpenParticipants(ctx context.Context, roomID uuid.UUID, leftAt time.Time, reason string) (int64, error) {
+	query := `
+		UPDATE room_participants
+		SET left_at = $2, leave_reason = $3
+		WHERE room_id = $1 AND left_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, roomID, leftAt, reason)
+	if err != nil {
+		r.log.Error("Failed to close open participants", "error", err, "room_id", roomID)
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// ListRoomsToEnd возвращает незавершенные комнаты, у которых прошло scheduled_end_at,
+// и активные комнаты без участников, в которых никого не было с inactiveSince
+func (r *roomRepository) ListRoomsToEnd(ctx context.Context, now time.Time, inactiveSince time.Time, limit int) ([]*domain.Room, error) {
+	query := `
+		SELECT id, livekit_room_name, host_user_id, title, description, status,
+		       scheduled_start_at, scheduled_end_at, actual_start_at, actual_end_at,
+		       max_participants, waiting_room_enabled, is_locked, password_hash, settings,
+		       series_id, occurrence_start_at, parent_room_id, created_at, updated_at
+		FROM rooms r
+		WHERE r.status IN ('scheduled', 'active')
+		  AND (
+		      (r.scheduled_end_at IS NOT NULL AND r.scheduled_end_at < $1)
+		      OR (
+		          r.status = 'active'
+		          AND NOT EXISTS (
+		              SELECT 1 FROM room_participants p
+		              WHERE p.room_id = r.id AND p.left_at IS NULL
+		          )
+		          AND COALESCE(
+		              (SELECT MAX(p.left_at) FROM room_participants p WHERE p.room_id = r.id),
+		              r.actual_start_at, r.updated_at
+		          ) < $2
+		      )
+		  )
+		ORDER BY r.scheduled_end_at NULLS LAST
+		LIMIT $3
+	`
+
+	return r.queryRooms(ctx, query, now, inactiveSince, limit)
+}
+
+// ListSeriesOccurrences возвращает все вхождения серии, включая отмененные
+func (r *roomRepository) ListSeriesOccurrences(ctx context.Context, seriesID uuid.UUID) ([]*domain.Room, error) {
+	query := `
+		SELECT id, livekit_room_name, host_user_id, title, description, status,
+		       scheduled_start_at, scheduled_end_at, actual_start_at, actual_end_at,
+		       max_participants, waiting_room_enabled, is_locked, password_hash, settings,
+		       series_id, occurrence_start_at, parent_room_id, created_at, updated_at
+		FROM rooms
+		WHERE series_id = $1
+		ORDER BY occurrence_start_at
+	`
+
+	return r.queryRooms(ctx, query, seriesID)
+}
+
+// ListUpcoming возвращает запланированные и идущие встречи хоста, которые еще не закончились
+func (r *roomRepository) ListUpcoming(ctx context.Context, hostUserID uuid.UUID, from time.Time, limit int) ([]*domain.Room, error) {
+	query := `
+		SELECT id, livekit_room_name, host_user_id, title, description, status,
+		       scheduled_start_at, scheduled_end_at, actual_start_at, actual_end_at,
+		       max_participants, waiting_room_enabled, is_locked, password_hash, settings,
+		       series_id, occurrence_start_at, parent_room_id, created_at, updated_at
+		FROM rooms
+		WHERE host_user_id = $1
+		  AND parent_room_id IS NULL
+		  AND status IN ('scheduled', 'active')
+		  AND scheduled_start_at IS NOT NULL
+		  AND COALESCE(scheduled_end_at, scheduled_start_at) >= $2
+		ORDER BY scheduled_start_at
+		LIMIT $3
+	`
+
+	return r.queryRooms(ctx, query, hostUserID, from, limit)
+}
+
+// ListCalendarRooms возвращает запланированные встречи, которые пользователь ведет или в которые
+// приглашен (вошел по приглашению или через waiting room и не был забанен), с окончанием после since.
+// Отмененные встречи тоже возвращаются, чтобы отмена дошла до календаря.
+func (r *roomRepository) ListCalendarRooms(ctx context.Context, userID uuid.UUID, since time.Time, limit int) ([]*domain.Room, error) {
+	query := `
+		SELECT id, livekit_room_name, host_user_id, title, description, status,
+		       scheduled_start_at, scheduled_end_at, actual_start_at, actual_end_at,
+		       max_participants, waiting_room_enabled, is_locked, password_hash, settings,
+		       series_id, occurrence_start_at, parent_room_id, created_at, updated_at
+		FROM rooms r
+		WHERE r.scheduled_start_at IS NOT NULL
+		  AND r.parent_room_id IS NULL
+		  AND COALESCE(r.scheduled_end_at, r.scheduled_start_at) >= $2
+		  AND (
+		      r.host_user_id = $1
+		      OR EXISTS (
+		          SELECT 1 FROM room_participants p
+		          WHERE p.room_id = r.id AND p.user_id = $1 AND NOT p.is_kicked
+		      )
+		  )
+		ORDER BY r.scheduled_start_at
+		LIMIT $3
+	`
+
+	return r.queryRooms(ctx, query, userID, since, limit)
+}
+
+func (r *roomRepository) ListBreakouts(ctx context.Context, parentRoomID uuid.UUID) ([]*domain.Room, error) {
+	query := `
+		SELECT id, livekit_room_name, host_user_id, title, description, status,
+		       scheduled_start_at, scheduled_end_at, actual_start_at, actual_end_at,
+		       max_participants, waiting_room_enabled, is_locked, password_hash, settings,
+		       series_id, occurrence_start_at, parent_room_id, created_at, updated_at
+		FROM rooms
+		WHERE parent_room_id = $1 AND status IN ('scheduled', 'active')
+		ORDER BY created_at, title
+	`
+
+	return r.queryRooms(ctx, query, parentRoomID)
+}
+
+func (r *roomRepository) queryRooms(ctx context.Context, query string, args ...interface{}) ([]*domain.Room, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		r.log.Error("Failed to query rooms", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []*domain.Room
+	for rows.Next() {
+		room := &domain.Room{}
+		err := rows.Scan(
+			&room.ID, &room.LiveKitRoomName, &room.HostUserID, &room.Title, &room.Description, &room.Status,
+			&room.ScheduledStartAt, &room.ScheduledEndAt, &room.ActualStartAt, &room.ActualEndAt,
+			&room.MaxParticipants, &room.WaitingRoomEnabled, &room.IsLocked, &room.PasswordHash, &room.Settings,
+			&room.SeriesID, &room.OccurrenceStartAt, &room.ParentRoomID, &room.CreatedAt, &room.UpdatedAt,
+		)
+		if err != nil {
+			r.log.Error("Failed to scan room", "error", err)
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+
+	return rooms, rows.Err()
+}
+
+// IsBanned - был ли пользователь исключен из комнаты с запретом повторного входа
+func (r *roomRepository) IsBanned(ctx context.Context, roomID, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM room_participants
+			WHERE room_id = $1 AND user_id = $2 AND is_kicked = true
+		)
+	`
+
+	var banned bool
+	if err := r.db.QueryRow(ctx, query, roomID, userID).Scan(&banned); err != nil {
+		r.log.Error("Failed to check participant ban", "error", err)
+		return false, err
+	}
+
+	return banned, nil
+}
+
 func (r *roomRepository) CreateWaitingRoomEntry(ctx context.Context, entry *domain.WaitingRoomEntry) error {
 	query := `
 		INSERT INTO waiting_room_entries (id, room_id, user_id, display_name, status, requested_at)
@@ -450,22 +873,140 @@ func (r *roomRepository) GetWaitingRoomEntries(ctx context.Context, roomID uuid.
 	return entries, nil
 }
 
-func (r *roomRepository) UpdateWaitingRoomEntry(ctx context.Context, entry *domain.WaitingRoomEntry) error {
+func (r *roomRepository) DecideWaitingRoomEntry(ctx context.Context, entry *domain.WaitingRoomEntry, participant *domain.RoomParticipant) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		r.log.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Условие на статус не дает двум хостам одновременно принять разные решения
 	query := `
 		UPDATE waiting_room_entries
 		SET status = $2, decided_at = $3, decided_by_user_id = $4, reason = $5
-		WHERE id = $1
+		WHERE id = $1 AND status = 'pending'
 	`
 	
-	_, err := r.db.Exec(ctx, query,
+	tag, err := tx.Exec(ctx, query,
 		entry.ID, entry.Status, entry.DecidedAt, entry.DecidedByUserID, entry.Reason,
 	)
-	
 	if err != nil {
 		r.log.Error("Failed to update waiting room entry", "error", err)
 		return err
 	}
+	if tag.RowsAffected() != 1 {
+		return errors.New("waiting room entry already decided")
+	}
+
+	if participant != nil {
+		if err := insertParticipant(ctx, tx, participant); err != nil {
+			r.log.Error("Failed to create participant", "error", err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.log.Error("Failed to commit waiting room decision", "error", err, "entry_id", entry.ID)
+		return err
+	}
 	
 	return nil
 }
 
+func (r *roomRepository) GetWaitingRoomEntryByID(ctx context.Context, entryID uuid.UUID) (*domain.WaitingRoomEntry, error) {
+	query := `
+		SELECT id, room_id, user_id, display_name, status, requested_at, decided_at, decided_by_user_id, reason
+		FROM waiting_room_entries
+		WHERE id = $1
+	`
+
+	entry := &domain.WaitingRoomEntry{}
+	var decidedAt sql.NullTime
+	err := r.db.QueryRow(ctx, query, entryID).Scan(
+		&entry.ID, &entry.RoomID, &entry.UserID, &entry.DisplayName, &entry.Status,
+		&entry.RequestedAt, &decidedAt, &entry.DecidedByUserID, &entry.Reason,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("waiting room entry not found")
+		}
+		r.log.Error("Failed to get waiting room entry", "error", err)
+		return nil, err
+	}
+
+	if decidedAt.Valid {
+		entry.DecidedAt = &decidedAt.Time
+	}
+
+	return entry, nil
+}
+
+func (r *roomRepository) GetPendingWaitingRoomEntry(ctx context.Context, roomID, userID uuid.UUID) (*domain.WaitingRoomEntry, error) {
+	query := `
+		SELECT id, room_id, user_id, display_name, status, requested_at, decided_at, decided_by_user_id, reason
+		FROM waiting_room_entries
+		WHERE room_id = $1 AND user_id = $2 AND status = $3
+		ORDER BY requested_at DESC
+		LIMIT 1
+	`
+
+	entry := &domain.WaitingRoomEntry{}
+	var decidedAt sql.NullTime
+	err := r.db.QueryRow(ctx, query, roomID, userID, domain.WaitingRoomStatusPending).Scan(
+		&entry.ID, &entry.RoomID, &entry.UserID, &entry.DisplayName, &entry.Status,
+		&entry.RequestedAt, &decidedAt, &entry.DecidedByUserID, &entry.Reason,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("waiting room entry not found")
+		}
+		r.log.Error("Failed to get pending waiting room entry", "error", err)
+		return nil, err
+	}
+
+	if decidedAt.Valid {
+		entry.DecidedAt = &decidedAt.Time
+	}
+
+	return entry, nil
+}
+
+// ExpireWaitingRoomEntries переводит просроченные pending-заявки в expired и возвращает их
+func (r *roomRepository) ExpireWaitingRoomEntries(ctx context.Context, roomID uuid.UUID, requestedBefore time.Time) ([]*domain.WaitingRoomEntry, error) {
+	query := `
+		UPDATE waiting_room_entries
+		SET status = $3, decided_at = now()
+		WHERE room_id = $1 AND status = $4 AND requested_at < $2
+		RETURNING id, room_id, user_id, display_name, status, requested_at, decided_at, decided_by_user_id, reason
+	`
+
+	rows, err := r.db.Query(ctx, query, roomID, requestedBefore, domain.WaitingRoomStatusExpired, domain.WaitingRoomStatusPending)
+	if err != nil {
+		r.log.Error("Failed to expire waiting room entries", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.WaitingRoomEntry
+	for rows.Next() {
+		entry := &domain.WaitingRoomEntry{}
+		var decidedAt sql.NullTime
+		err := rows.Scan(
+			&entry.ID, &entry.RoomID, &entry.UserID, &entry.DisplayName, &entry.Status,
+			&entry.RequestedAt, &decidedAt, &entry.DecidedByUserID, &entry.Reason,
+		)
+		if err != nil {
+			r.log.Error("Failed to scan expired waiting room entry", "error", err)
+			return nil, err
+		}
+		if decidedAt.Valid {
+			entry.DecidedAt = &decidedAt.Time
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
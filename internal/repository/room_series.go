@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"video_conference/internal/domain"
+	"video_conference/pkg/logger"
+)
+
+type RoomSeriesRepository interface {
+	Create(ctx context.Context, series *domain.RoomSeries) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.RoomSeries, error)
+	// ListActiveByHost возвращает неотмененные серии хоста
+	ListActiveByHost(ctx context.Context, hostUserID uuid.UUID) ([]*domain.RoomSeries, error)
+	// ListActive возвращает все неотмененные серии (для фонового продления горизонта)
+	ListActive(ctx context.Context) ([]*domain.RoomSeries, error)
+	Cancel(ctx context.Context, id uuid.UUID, cancelledAt time.Time) error
+}
+
+type roomSeriesRepository struct {
+	db  *pgxpool.Pool
+	log logger.Logger
+}
+
+func NewRoomSeriesRepository(db *pgxpool.Pool, log logger.Logger) RoomSeriesRepository {
+	return &roomSeriesRepository{db: db, log: log}
+}
+
+func (r *roomSeriesRepository) Create(ctx context.Context, series *domain.RoomSeries) error {
+	query := `
+		INSERT INTO room_series (id, host_user_id, title, description, max_participants, waiting_room_enabled,
+		                         settings, rrule, start_at, duration_minutes, timezone, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		series.ID, series.HostUserID, series.Title, series.Description, series.MaxParticipants, series.WaitingRoomEnabled,
+		series.Settings, series.RRule, series.StartAt, series.DurationMinutes, series.Timezone, series.CreatedAt, series.UpdatedAt,
+	).Scan(&series.CreatedAt, &series.UpdatedAt)
+
+	if err != nil {
+		r.log.Error("Failed to create room series", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *roomSeriesRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RoomSeries, error) {
+	query := `
+		SELECT id, host_user_id, title, description, max_participants, waiting_room_enabled,
+		       settings, rrule, start_at, duration_minutes, timezone, cancelled_at, created_at, updated_at
+		FROM room_series
+		WHERE id = $1
+	`
+
+	series := &domain.RoomSeries{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&series.ID, &series.HostUserID, &series.Title, &series.Description, &series.MaxParticipants, &series.WaitingRoomEnabled,
+		&series.Settings, &series.RRule, &series.StartAt, &series.DurationMinutes, &series.Timezone, &series.CancelledAt,
+		&series.CreatedAt, &series.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("series not found")
+		}
+		r.log.Error("Failed to get room series", "error", err)
+		return nil, err
+	}
+
+	return series, nil
+}
+
+func (r *roomSeriesRepository) ListActiveByHost(ctx context.Context, hostUserID uuid.UUID) ([]*domain.RoomSeries, error) {
+	query := `
+		SELECT id, host_user_id, title, description, max_participants, waiting_room_enabled,
+		       settings, rrule, start_at, duration_minutes, timezone, cancelled_at, created_at, updated_at
+		FROM room_series
+		WHERE host_user_id = $1 AND cancelled_at IS NULL
+		ORDER BY start_at
+	`
+
+	return r.list(ctx, query, hostUserID)
+}
+
+func (r *roomSeriesRepository) ListActive(ctx context.Context) ([]*domain.RoomSeries, error) {
+	query := `
+		SELECT id, host_user_id, title, description, max_participants, waiting_room_enabled,
+		       settings, rrule, start_at, duration_minutes, timezone, cancelled_at, created_at, updated_at
+		FROM room_series
+		WHERE cancelled_at IS NULL
+		ORDER BY start_at
+	`
+
+	return r.list(ctx, query)
+}
+
+func (r *roomSeriesRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.RoomSeries, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		r.log.Error("Failed to list room series", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seriesList []*domain.RoomSeries
+	for rows.Next() {
+		series := &domain.RoomSeries{}
+		err := rows.Scan(
+			&series.ID, &series.HostUserID, &series.Title, &series.Description, &series.MaxParticipants, &series.WaitingRoomEnabled,
+			&series.Settings, &series.RRule, &series.StartAt, &series.DurationMinutes, &series.Timezone, &series.CancelledAt,
+			&series.CreatedAt, &series.UpdatedAt,
+		)
+		if err != nil {
+			r.log.Error("Failed to scan room series", "error", err)
+			return nil, err
+		}
+		seriesList = append(seriesList, series)
+	}
+
+	return seriesList, rows.Err()
+}
+
+func (r *roomSeriesRepository) Cancel(ctx context.Context, id uuid.UUID, cancelledAt time.Time) error {
+	query := `
+		UPDATE room_series
+		SET cancelled_at = $2, updated_at = $2
+		WHERE id = $1 AND cancelled_at IS NULL
+	`
+
+	_, err := r.db.Exec(ctx, query, id, cancelledAt)
+	if err != nil {
+		r.log.Error("Failed to cancel room series", "error", err, "series_id", id)
+		return err
+	}
+
+	return nil
+}
@@ -71,13 +71,7 @@ func (s *anonymousMediaService) GetToken(ctx context.Context, roomID uuid.UUID,
 		CanSubscribe: &canSubscribe,
 	}
 
-	// Используем participant_id как identity (должен быть валидным UUID)
-	// Если participantID не валидный UUID, используем его как есть (LiveKit поддерживает строки)
-	identity := participantID
-	if _, err := uuid.Parse(participantID); err != nil {
-		// Если не UUID, генерируем UUID на основе participantID для consistency
-		identity = uuid.NewSHA1(uuid.NameSpaceOID, []byte(participantID)).String()
-	}
+	identity := anonymousIdentity(participantID)
 
 	at.AddGrant(grant).
 		SetIdentity(identity).
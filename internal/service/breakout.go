@@ -0,0 +1,596 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// Ограничения сессионных залов
+const (
+	maxBreakoutRooms         = 50
+	maxBreakoutCountdown     = 10 * time.Minute
+	maxBreakoutMessageLength = 2000
+)
+
+// CreateBreakoutsParams - число залов и необязательные названия (по порядку)
+type CreateBreakoutsParams struct {
+	Count  int
+	Titles []string
+}
+
+// BreakoutService управляет сессионными залами: дочерними комнатами основной комнаты
+// со своими комнатами LiveKit и чатами. Хост и co-host создают залы, распределяют
+// участников, открывают и закрывают залы; участники получают токен зала через MediaService.
+type BreakoutService interface {
+	Create(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params CreateBreakoutsParams) ([]*domain.BreakoutRoom, error)
+	List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.BreakoutRoom, error)
+	// Assign распределяет пользователей по залам вручную; nil вместо зала снимает распределение
+	Assign(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, assignments map[uuid.UUID]*uuid.UUID) ([]*domain.BreakoutRoom, error)
+	// AssignRandom поровну распределяет по залам всех участников основной комнаты, кроме ведущих
+	AssignRandom(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.BreakoutRoom, error)
+	Open(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.BreakoutRoom, error)
+	// Close закрывает открытые залы через countdown; нулевой countdown - то же, что Recall
+	Close(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, countdown time.Duration) ([]*domain.BreakoutRoom, error)
+	// Recall сразу закрывает все залы и возвращает участников в основную комнату
+	Recall(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error
+	// Broadcast отправляет служебное сообщение в чат каждого открытого зала
+	Broadcast(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, message string) error
+	// Stop отменяет запланированные закрытия залов (при остановке сервера)
+	Stop()
+}
+
+type breakoutService struct {
+	roomRepo     repository.RoomRepository
+	breakoutRepo repository.BreakoutRepository
+	auditRepo    repository.AuditRepository
+	realtime     RealtimeService
+	chat         ChatService
+	lifecycle    RoomLifecycleService
+	perms        *roomPermissions
+	log          logger.Logger
+
+	// Таймеры закрытия по обратному отсчету, по основной комнате
+	mu     sync.Mutex
+	timers map[uuid.UUID]*time.Timer
+}
+
+func NewBreakoutService(roomRepo repository.RoomRepository, breakoutRepo repository.BreakoutRepository, auditRepo repository.AuditRepository, realtime RealtimeService, chat ChatService, lifecycle RoomLifecycleService, log logger.Logger) BreakoutService {
+	return &breakoutService{
+		roomRepo:     roomRepo,
+		breakoutRepo: breakoutRepo,
+		auditRepo:    auditRepo,
+		realtime:     realtime,
+		chat:         chat,
+		lifecycle:    lifecycle,
+		perms:        newRoomPermissions(roomRepo),
+		log:          log,
+		timers:       make(map[uuid.UUID]*time.Timer),
+	}
+}
+
+func (s *breakoutService) Create(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params CreateBreakoutsParams) ([]*domain.BreakoutRoom, error) {
+	parent, err := s.authorizeParent(ctx, roomID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Count < 1 || params.Count > maxBreakoutRooms {
+		return nil, fmt.Errorf("count must be between 1 and %d", maxBreakoutRooms)
+	}
+	if len(params.Titles) > params.Count {
+		return nil, errors.New("too many titles")
+	}
+
+	existing, err := s.roomRepo.ListBreakouts(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, errors.New("breakouts already exist")
+	}
+
+	// Распределения прошлых залов больше не нужны
+	if err := s.breakoutRepo.ReplaceAssignments(ctx, roomID, nil); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rooms := make([]*domain.Room, 0, params.Count)
+	for i := 0; i < params.Count; i++ {
+		title := fmt.Sprintf("Комната %d", i+1)
+		if i < len(params.Titles) && strings.TrimSpace(params.Titles[i]) != "" {
+			title = strings.TrimSpace(params.Titles[i])
+		}
+
+		room := &domain.Room{
+			ID:              uuid.New(),
+			LiveKitRoomName: uuid.New().String(),
+			HostUserID:      parent.HostUserID,
+			Title:           title,
+			Status:          domain.RoomStatusScheduled,
+			MaxParticipants: parent.MaxParticipants,
+			Settings:        breakoutSettings(parent),
+			ParentRoomID:    &parent.ID,
+			// Залы создаются по очереди, чтобы порядок в списке совпадал с нумерацией
+			CreatedAt: now.Add(time.Duration(i) * time.Microsecond),
+			UpdatedAt: now,
+		}
+		rooms = append(rooms, room)
+	}
+	if err := s.roomRepo.CreateBreakouts(ctx, rooms); err != nil {
+		return nil, errors.New("failed to create breakout")
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   now,
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, parent, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeBreakoutsCreated,
+		Payload:     map[string]interface{}{"count": params.Count},
+	})
+
+	breakouts := toBreakoutRooms(rooms, nil)
+	s.publish(ctx, roomID, &domain.BreakoutPayload{Action: domain.BreakoutActionCreated, Breakouts: breakouts})
+
+	return breakouts, nil
+}
+
+func (s *breakoutService) List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.BreakoutRoom, error) {
+	parent, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Список залов и распределение видят все участники основной комнаты
+	if s.perms.Role(ctx, parent, userID) == "" {
+		return nil, errors.New("not a room participant")
+	}
+
+	return s.breakoutRooms(ctx, roomID)
+}
+
+func (s *breakoutService) Assign(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, assignments map[uuid.UUID]*uuid.UUID) ([]*domain.BreakoutRoom, error) {
+	parent, err := s.authorizeParent(ctx, roomID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms, err := s.roomRepo.ListBreakouts(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	breakoutIDs := make(map[uuid.UUID]bool, len(rooms))
+	for _, room := range rooms {
+		breakoutIDs[room.ID] = true
+	}
+
+	// Сначала проверяем все распределения, чтобы не применить запрос частично
+	for assigneeID, breakoutID := range assignments {
+		if breakoutID == nil {
+			continue
+		}
+		if !breakoutIDs[*breakoutID] {
+			return nil, errors.New("breakout not found")
+		}
+		if assigneeID != parent.HostUserID {
+			if _, err := s.roomRepo.GetParticipant(ctx, roomID, assigneeID); err != nil {
+				return nil, errors.New("user is not a room participant")
+			}
+		}
+	}
+
+	now := time.Now()
+	for assigneeID, breakoutID := range assignments {
+		if breakoutID == nil {
+			err = s.breakoutRepo.Unassign(ctx, roomID, assigneeID)
+		} else {
+			err = s.breakoutRepo.Assign(ctx, &domain.BreakoutAssignment{
+				ParentRoomID:   roomID,
+				UserID:         assigneeID,
+				BreakoutRoomID: *breakoutID,
+				AssignedAt:     now,
+			})
+		}
+		if err != nil {
+			return nil, errors.New("failed to assign breakout")
+		}
+	}
+
+	return s.publishAssignments(ctx, roomID)
+}
+
+func (s *breakoutService) AssignRandom(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.BreakoutRoom, error) {
+	if _, err := s.authorizeParent(ctx, roomID, userID); err != nil {
+		return nil, err
+	}
+
+	rooms, err := s.roomRepo.ListBreakouts(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rooms) == 0 {
+		return nil, errors.New("no breakouts")
+	}
+
+	participants, err := s.roomRepo.GetParticipantsByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ведущие остаются свободными и могут заходить в любой зал; звонящие по телефону
+	// и внешние трансляции привязаны к основной комнате LiveKit
+	var userIDs []uuid.UUID
+	for _, participant := range participants {
+		if participant.UserID == nil || participant.Role != domain.ParticipantRoleParticipant || participant.Source != domain.ParticipantSourceWeb {
+			continue
+		}
+		userIDs = append(userIDs, *participant.UserID)
+	}
+	rand.Shuffle(len(userIDs), func(i, j int) { userIDs[i], userIDs[j] = userIDs[j], userIDs[i] })
+
+	now := time.Now()
+	assignments := make([]*domain.BreakoutAssignment, 0, len(userIDs))
+	for i, assigneeID := range userIDs {
+		assignments = append(assignments, &domain.BreakoutAssignment{
+			ParentRoomID:   roomID,
+			UserID:         assigneeID,
+			BreakoutRoomID: rooms[i%len(rooms)].ID,
+			AssignedAt:     now,
+		})
+	}
+
+	if err := s.breakoutRepo.ReplaceAssignments(ctx, roomID, assignments); err != nil {
+		return nil, errors.New("failed to assign breakout")
+	}
+
+	return s.publishAssignments(ctx, roomID)
+}
+
+func (s *breakoutService) Open(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.BreakoutRoom, error) {
+	parent, err := s.authorizeParent(ctx, roomID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms, err := s.roomRepo.ListBreakouts(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rooms) == 0 {
+		return nil, errors.New("no breakouts")
+	}
+
+	now := time.Now()
+	for _, room := range rooms {
+		if room.Status == domain.RoomStatusActive {
+			continue
+		}
+		room.Status = domain.RoomStatusActive
+		room.ActualStartAt = &now
+		room.UpdatedAt = now
+		if err := s.roomRepo.Update(ctx, room); err != nil {
+			return nil, errors.New("failed to update breakout")
+		}
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   now,
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, parent, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeBreakoutsOpened,
+		Payload:     map[string]interface{}{"count": len(rooms)},
+	})
+
+	breakouts, err := s.breakoutRooms(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Клиенты основной комнаты по этому событию запрашивают токен своего зала
+	s.publish(ctx, roomID, &domain.BreakoutPayload{Action: domain.BreakoutActionOpened, Breakouts: breakouts})
+
+	return breakouts, nil
+}
+
+func (s *breakoutService) Close(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, countdown time.Duration) ([]*domain.BreakoutRoom, error) {
+	if countdown < 0 || countdown > maxBreakoutCountdown {
+		return nil, fmt.Errorf("countdown must be between 0 and %d seconds", int(maxBreakoutCountdown/time.Second))
+	}
+	if countdown == 0 {
+		return []*domain.BreakoutRoom{}, s.Recall(ctx, roomID, userID)
+	}
+
+	if _, err := s.authorizeParent(ctx, roomID, userID); err != nil {
+		return nil, err
+	}
+
+	rooms, err := s.roomRepo.ListBreakouts(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Залы закрывает таймер; scheduled_end_at сохраняется, чтобы после перезапуска сервера
+	// их закрыло фоновое автозавершение
+	closesAt := time.Now().Add(countdown)
+	opened := 0
+	for _, room := range rooms {
+		if room.Status != domain.RoomStatusActive {
+			continue
+		}
+		room.ScheduledEndAt = &closesAt
+		room.UpdatedAt = time.Now()
+		if err := s.roomRepo.Update(ctx, room); err != nil {
+			return nil, errors.New("failed to update breakout")
+		}
+		s.publish(ctx, room.ID, &domain.BreakoutPayload{Action: domain.BreakoutActionClosing, ClosesAt: &closesAt})
+		opened++
+	}
+	if opened == 0 {
+		return nil, errors.New("breakouts are not open")
+	}
+
+	s.scheduleClose(roomID, countdown)
+
+	breakouts, err := s.breakoutRooms(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, roomID, &domain.BreakoutPayload{Action: domain.BreakoutActionClosing, Breakouts: breakouts, ClosesAt: &closesAt})
+
+	return breakouts, nil
+}
+
+func (s *breakoutService) Recall(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error {
+	parent, err := s.authorizeParent(ctx, roomID, userID)
+	if err != nil {
+		return err
+	}
+
+	rooms, err := s.roomRepo.ListBreakouts(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	if len(rooms) == 0 {
+		return errors.New("no breakouts")
+	}
+
+	s.cancelClose(roomID)
+
+	// Каждый зал получает room_status, и клиенты возвращаются в основную комнату
+	for _, room := range rooms {
+		if err := s.lifecycle.EndRoom(ctx, room, RoomEndReasonBreakoutClosed); err != nil {
+			return err
+		}
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, parent, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeBreakoutsClosed,
+		Payload:     map[string]interface{}{"count": len(rooms)},
+	})
+
+	s.publish(ctx, roomID, &domain.BreakoutPayload{Action: domain.BreakoutActionClosed})
+
+	return nil
+}
+
+func (s *breakoutService) Broadcast(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, message string) error {
+	if _, err := s.authorizeParent(ctx, roomID, userID); err != nil {
+		return err
+	}
+
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return errors.New("message is required")
+	}
+	if len([]rune(message)) > maxBreakoutMessageLength {
+		return errors.New("message is too long")
+	}
+
+	rooms, err := s.roomRepo.ListBreakouts(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, room := range rooms {
+		if room.Status != domain.RoomStatusActive {
+			continue
+		}
+		if _, err := s.chat.PostSystemMessage(ctx, room.ID, message); err != nil {
+			s.log.Warn("Failed to post breakout broadcast", "error", err, "room_id", room.ID)
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return errors.New("breakouts are not open")
+	}
+
+	return nil
+}
+
+func (s *breakoutService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for roomID, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, roomID)
+	}
+}
+
+// scheduleClose заводит таймер закрытия залов; повторный Close заменяет прежний отсчет
+func (s *breakoutService) scheduleClose(roomID uuid.UUID, countdown time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.timers[roomID]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(countdown, func() {
+		s.mu.Lock()
+		current := s.timers[roomID] == timer
+		if current {
+			delete(s.timers, roomID)
+		}
+		s.mu.Unlock()
+
+		if current {
+			s.closeDue(context.Background(), roomID)
+		}
+	})
+	s.timers[roomID] = timer
+}
+
+func (s *breakoutService) cancelClose(roomID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.timers[roomID]; ok {
+		timer.Stop()
+		delete(s.timers, roomID)
+	}
+}
+
+// closeDue закрывает залы, у которых истек обратный отсчет
+func (s *breakoutService) closeDue(ctx context.Context, roomID uuid.UUID) {
+	rooms, err := s.roomRepo.ListBreakouts(ctx, roomID)
+	if err != nil {
+		s.log.Warn("Failed to list breakouts to close", "error", err, "room_id", roomID)
+		return
+	}
+
+	now := time.Now()
+	closed := 0
+	for _, room := range rooms {
+		if room.ScheduledEndAt == nil || room.ScheduledEndAt.After(now) {
+			continue
+		}
+		if err := s.lifecycle.EndRoom(ctx, room, RoomEndReasonBreakoutClosed); err != nil {
+			s.log.Warn("Failed to close breakout", "error", err, "room_id", room.ID)
+			continue
+		}
+		closed++
+	}
+	if closed == 0 {
+		return
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime: now,
+		ActorRole: domain.ActorRoleSystem,
+		RoomID:    &roomID,
+		EventType: domain.EventTypeBreakoutsClosed,
+		Payload:   map[string]interface{}{"count": closed, "countdown": true},
+	})
+
+	s.publish(ctx, roomID, &domain.BreakoutPayload{Action: domain.BreakoutActionClosed})
+}
+
+// authorizeParent загружает основную комнату и проверяет право управлять залами
+func (s *breakoutService) authorizeParent(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.Room, error) {
+	parent, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, parent, userID, ActionManageBreakouts); err != nil {
+		return nil, err
+	}
+
+	if parent.ParentRoomID != nil {
+		return nil, errors.New("breakout room cannot have breakouts")
+	}
+	if parent.Status != domain.RoomStatusScheduled && parent.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	return parent, nil
+}
+
+// breakoutRooms собирает незавершенные залы комнаты с распределенными в них пользователями
+func (s *breakoutService) breakoutRooms(ctx context.Context, roomID uuid.UUID) ([]*domain.BreakoutRoom, error) {
+	rooms, err := s.roomRepo.ListBreakouts(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.breakoutRepo.ListAssignments(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toBreakoutRooms(rooms, assignments), nil
+}
+
+func (s *breakoutService) publishAssignments(ctx context.Context, roomID uuid.UUID) ([]*domain.BreakoutRoom, error) {
+	breakouts, err := s.breakoutRooms(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, roomID, &domain.BreakoutPayload{Action: domain.BreakoutActionAssigned, Breakouts: breakouts})
+
+	return breakouts, nil
+}
+
+func (s *breakoutService) publish(ctx context.Context, roomID uuid.UUID, payload *domain.BreakoutPayload) {
+	if err := s.realtime.Publish(ctx, roomID, domain.RoomEventTypeBreakout, payload); err != nil {
+		s.log.Warn("Failed to publish breakout event", "error", err, "room_id", roomID, "action", payload.Action)
+	}
+}
+
+func toBreakoutRooms(rooms []*domain.Room, assignments []*domain.BreakoutAssignment) []*domain.BreakoutRoom {
+	breakouts := make([]*domain.BreakoutRoom, 0, len(rooms))
+	byID := make(map[uuid.UUID]*domain.BreakoutRoom, len(rooms))
+	for _, room := range rooms {
+		breakout := &domain.BreakoutRoom{
+			ID:              room.ID,
+			Title:           room.Title,
+			Status:          room.Status,
+			ClosesAt:        room.ScheduledEndAt,
+			AssignedUserIDs: []uuid.UUID{},
+		}
+		breakouts = append(breakouts, breakout)
+		byID[room.ID] = breakout
+	}
+
+	for _, assignment := range assignments {
+		if breakout, ok := byID[assignment.BreakoutRoomID]; ok {
+			breakout.AssignedUserIDs = append(breakout.AssignedUserIDs, assignment.UserID)
+		}
+	}
+
+	return breakouts
+}
+
+// breakoutSettings - настройки основной комнаты для зала, без служебных настроек входа по телефону
+func breakoutSettings(parent *domain.Room) map[string]interface{} {
+	settings := make(map[string]interface{}, len(parent.Settings))
+	for key, value := range parent.Settings {
+		if readOnlyRoomSettings[key] {
+			continue
+		}
+		settings[key] = value
+	}
+	return settings
+}
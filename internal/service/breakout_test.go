@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+)
+
+type fakeBreakoutRepo struct {
+	repository.BreakoutRepository
+}
+
+func (r *fakeBreakoutRepo) ReplaceAssignments(ctx context.Context, parentRoomID uuid.UUID, assignments []*domain.BreakoutAssignment) error {
+	return nil
+}
+
+func (r *fakeBreakoutRepo) ListAssignments(ctx context.Context, parentRoomID uuid.UUID) ([]*domain.BreakoutAssignment, error) {
+	return nil, nil
+}
+
+// fakeLifecycle завершает залы и сообщает о каждом завершении в канал ended
+type fakeLifecycle struct {
+	RoomLifecycleService
+
+	mu    sync.Mutex
+	ended chan uuid.UUID
+}
+
+func (l *fakeLifecycle) EndRoom(ctx context.Context, room *domain.Room, reason string) error {
+	l.mu.Lock()
+	room.Status = domain.RoomStatusEnded
+	l.mu.Unlock()
+
+	l.ended <- room.ID
+	return nil
+}
+
+type breakoutFixture struct {
+	*roomFixture
+	service   BreakoutService
+	lifecycle *fakeLifecycle
+}
+
+func newBreakoutFixture(t *testing.T) *breakoutFixture {
+	t.Helper()
+
+	f := &breakoutFixture{
+		roomFixture: newRoomFixture(t),
+		lifecycle:   &fakeLifecycle{ended: make(chan uuid.UUID, maxBreakoutRooms)},
+	}
+	svc := NewBreakoutService(f.roomRepo, &fakeBreakoutRepo{}, f.audit, f.realtime, nil, f.lifecycle, nopLogger{})
+	t.Cleanup(svc.Stop)
+	f.service = svc
+
+	return f
+}
+
+// openBreakouts создает залы и открывает их, как это сделал бы хост
+func (f *breakoutFixture) openBreakouts(t *testing.T, count int) {
+	t.Helper()
+
+	if _, err := f.service.Create(context.Background(), f.room.ID, f.room.HostUserID, CreateBreakoutsParams{Count: count}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for _, room := range f.roomRepo.rooms {
+		if room.ParentRoomID != nil {
+			room.Status = domain.RoomStatusActive
+		}
+	}
+}
+
+func TestBreakoutCreateUsesSingleBatch(t *testing.T) {
+	f := newBreakoutFixture(t)
+
+	breakouts, err := f.service.Create(context.Background(), f.room.ID, f.room.HostUserID, CreateBreakoutsParams{Count: 3, Titles: []string{"Alpha"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if f.roomRepo.breakoutBatches != 1 {
+		t.Errorf("CreateBreakouts calls = %d, want 1", f.roomRepo.breakoutBatches)
+	}
+	if len(breakouts) != 3 || breakouts[0].Title != "Alpha" || breakouts[2].Title != "Комната 3" {
+		t.Errorf("breakouts = %+v", breakouts)
+	}
+}
+
+func TestBreakoutCloseEndsRoomsAfterCountdown(t *testing.T) {
+	f := newBreakoutFixture(t)
+	f.openBreakouts(t, 2)
+
+	if _, err := f.service.Close(context.Background(), f.room.ID, f.room.HostUserID, 20*time.Millisecond); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-f.lifecycle.ended:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("breakouts ended = %d, want 2", i)
+		}
+	}
+}
+
+func TestBreakoutRecallCancelsCountdown(t *testing.T) {
+	f := newBreakoutFixture(t)
+	f.openBreakouts(t, 2)
+
+	if _, err := f.service.Close(context.Background(), f.room.ID, f.room.HostUserID, 20*time.Millisecond); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := f.service.Recall(context.Background(), f.room.ID, f.room.HostUserID); err != nil {
+		t.Fatalf("Recall: %v", err)
+	}
+	if len(f.lifecycle.ended) != 2 {
+		t.Fatalf("breakouts ended by Recall = %d, want 2", len(f.lifecycle.ended))
+	}
+
+	svc := f.service.(*breakoutService)
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if len(svc.timers) != 0 {
+		t.Errorf("countdown timers = %d after Recall, want 0", len(svc.timers))
+	}
+}
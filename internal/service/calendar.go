@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/ical"
+	"video_conference/pkg/logger"
+)
+
+const (
+	calendarProdID = "-//Video Conference//Meetings//RU"
+	// Длительность события, если у встречи нет scheduled_end_at
+	calendarDefaultDuration = time.Hour
+	// Прошедшие и отмененные встречи остаются в подписке неделю, чтобы клиенты успели их обновить
+	calendarFeedLookback = 7 * 24 * time.Hour
+	calendarFeedLimit    = 500
+	calendarFeedRefresh  = 15 * time.Minute
+)
+
+// CalendarService выгружает запланированные встречи в формате iCalendar
+type CalendarService interface {
+	// RoomICS возвращает .ics с одной встречей
+	RoomICS(ctx context.Context, roomID uuid.UUID) ([]byte, error)
+	// IssueFeedToken выпускает новую ссылку подписки (прежняя перестает работать) и возвращает ее токен
+	IssueFeedToken(ctx context.Context, userID uuid.UUID) (string, error)
+	RevokeFeedToken(ctx context.Context, userID uuid.UUID) error
+	// Feed возвращает календарь встреч владельца токена
+	Feed(ctx context.Context, token string) ([]byte, error)
+	// FeedURL - адрес подписки для токена
+	FeedURL(token string) string
+}
+
+type calendarService struct {
+	roomRepo     repository.RoomRepository
+	calendarRepo repository.CalendarRepository
+	publicURL    string
+	log          logger.Logger
+}
+
+func NewCalendarService(roomRepo repository.RoomRepository, calendarRepo repository.CalendarRepository, cfg config.ServerConfig, log logger.Logger) CalendarService {
+	return &calendarService{
+		roomRepo:     roomRepo,
+		calendarRepo: calendarRepo,
+		publicURL:    cfg.PublicURL,
+		log:          log,
+	}
+}
+
+func (s *calendarService) RoomICS(ctx context.Context, roomID uuid.UUID) ([]byte, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room.ScheduledStartAt == nil {
+		return nil, errors.New("room is not scheduled")
+	}
+
+	calendar := &ical.Calendar{
+		ProdID: calendarProdID,
+		Events: []ical.Event{s.roomEvent(room)},
+	}
+	return calendar.Bytes(), nil
+}
+
+func (s *calendarService) IssueFeedToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.New("failed to generate token")
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := s.calendarRepo.SetFeedToken(ctx, userID, hashToken(token)); err != nil {
+		return "", errors.New("failed to save calendar feed token")
+	}
+
+	return token, nil
+}
+
+func (s *calendarService) RevokeFeedToken(ctx context.Context, userID uuid.UUID) error {
+	return s.calendarRepo.DeleteFeedToken(ctx, userID)
+}
+
+func (s *calendarService) Feed(ctx context.Context, token string) ([]byte, error) {
+	userID, err := s.calendarRepo.GetUserIDByFeedToken(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	rooms, err := s.roomRepo.ListCalendarRooms(ctx, userID, time.Now().Add(-calendarFeedLookback), calendarFeedLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &ical.Calendar{
+		ProdID:          calendarProdID,
+		Name:            "Встречи",
+		RefreshInterval: calendarFeedRefresh,
+	}
+	for _, room := range rooms {
+		calendar.Events = append(calendar.Events, s.roomEvent(room))
+	}
+
+	return calendar.Bytes(), nil
+}
+
+func (s *calendarService) FeedURL(token string) string {
+	return s.publicURL + "/api/v1/calendar/feeds/" + token + ".ics"
+}
+
+// roomEvent строит событие встречи. UID привязан к комнате, поэтому перенос и отмена
+// обновляют уже импортированное событие. SEQUENCE - секунды от создания до последнего
+// изменения комнаты: растет при каждом обновлении и не требует отдельного счетчика.
+func (s *calendarService) roomEvent(room *domain.Room) ical.Event {
+	start := *room.ScheduledStartAt
+	end := start.Add(calendarDefaultDuration)
+	if room.ScheduledEndAt != nil {
+		end = *room.ScheduledEndAt
+	}
+
+	joinURL := s.publicURL + "/room.html?room=" + room.ID.String()
+	description := "Присоединиться: " + joinURL
+	if room.Description != nil && strings.TrimSpace(*room.Description) != "" {
+		description = *room.Description + "\n\n" + description
+	}
+
+	status := ical.StatusConfirmed
+	if room.Status == domain.RoomStatusCancelled {
+		status = ical.StatusCancelled
+	}
+
+	sequence := int(room.UpdatedAt.Sub(room.CreatedAt) / time.Second)
+	if sequence < 0 {
+		sequence = 0
+	}
+
+	return ical.Event{
+		UID:          room.ID.String() + "@video-conference",
+		Sequence:     sequence,
+		Start:        start,
+		End:          end,
+		Summary:      room.Title,
+		Description:  description,
+		Location:     joinURL,
+		URL:          joinURL,
+		Status:       status,
+		Created:      room.CreatedAt,
+		LastModified: room.UpdatedAt,
+	}
+}
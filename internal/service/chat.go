@@ -3,59 +3,131 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"video_conference/internal/config"
 	"video_conference/internal/domain"
 	"video_conference/internal/repository"
 	"video_conference/pkg/logger"
 )
 
+// Размер страницы истории чата по умолчанию и максимальный
+const (
+	defaultChatPageSize = 50
+	maxChatPageSize     = 100
+	// Сколько вложений можно прикрепить к одному сообщению
+	maxMessageAttachments = 10
+	// Максимальный интервал медленного режима чата
+	maxChatSlowModeSeconds = 3600
+)
+
+// SendMessageParams - текст сообщения; ReplyToMessageID задается для ответа в ветке,
+// AttachmentIDs - загруженные заранее вложения. Текст может быть пустым, если есть вложения.
+// RecipientType (по умолчанию вся комната) и RecipientParticipantID задают личное сообщение.
+type SendMessageParams struct {
+	Content                string
+	ReplyToMessageID       *int64
+	AttachmentIDs          []uuid.UUID
+	RecipientType          string
+	RecipientParticipantID *uuid.UUID
+}
+
+// UpdateChatControlsParams - изменение ограничений чата; nil-поля не меняются
+type UpdateChatControlsParams struct {
+	Enabled         *bool
+	SlowModeSeconds *int
+}
+
 type ChatService interface {
-	SendMessage(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, content string) (*domain.ChatMessage, error)
-	GetMessages(ctx context.Context, roomID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error)
-	EditMessage(ctx context.Context, messageID int64, userID uuid.UUID, content string) (*domain.ChatMessage, error)
+	// SendMessage сохраняет сообщение; упоминания @Имя в тексте разрешаются в участников комнаты,
+	// и упомянутым приходит адресное событие mention. Личное сообщение получают только
+	// подключения отправителя и адресатов.
+	SendMessage(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params SendMessageParams) (*domain.ChatMessage, error)
+	// GetMessages возвращает видимую пользователю ленту комнаты; ответы в ветках в нее не входят
+	GetMessages(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error)
+	// GetThread возвращает ветку сообщения messageID (можно передать и ответ из ветки)
+	GetThread(ctx context.Context, roomID uuid.UUID, messageID int64, userID uuid.UUID, limit, offset int) (*domain.ChatThread, error)
+	// GetMentions возвращает сообщения комнаты, в которых упомянут пользователь
+	GetMentions(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error)
+	// EditMessage меняет текст своего сообщения в пределах CHAT_EDIT_WINDOW после отправки;
+	// прежний текст сохраняется в истории правок
+	EditMessage(ctx context.Context, roomID uuid.UUID, messageID int64, userID uuid.UUID, content string) (*domain.ChatMessage, error)
+	// GetHistory возвращает сообщение и его предыдущие версии; доступно ведущим комнаты
+	GetHistory(ctx context.Context, roomID uuid.UUID, messageID int64, userID uuid.UUID) (*domain.ChatMessageHistory, error)
 	DeleteMessage(ctx context.Context, messageID int64, userID uuid.UUID) error
+	// PostSystemMessage публикует в чат комнаты служебное сообщение без отправителя
+	PostSystemMessage(ctx context.Context, roomID uuid.UUID, content string) (*domain.ChatMessage, error)
+	// GetControls возвращает ограничения чата комнаты
+	GetControls(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.ChatControls, error)
+	// UpdateControls включает и отключает чат комнаты и медленный режим
+	UpdateControls(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params UpdateChatControlsParams) (*domain.ChatControls, error)
+	// SetParticipantChat отключает или снова включает чат участнику комнаты. Ограничение
+	// хранится по пользователю и действует и после его повторного входа.
+	SetParticipantChat(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID, enabled bool) (*domain.ChatControls, error)
+	// ExportTranscript проверяет права и готовит выгрузку всей истории чата в формате json, markdown
+	// или text. Личная переписка других участников в выгрузку не попадает.
+	ExportTranscript(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, format string) (*ChatTranscript, error)
 }
 
 type chatService struct {
-	chatRepo  repository.ChatRepository
-	roomRepo  repository.RoomRepository
-	auditRepo repository.AuditRepository
-	log       logger.Logger
+	chatRepo    repository.ChatRepository
+	roomRepo    repository.RoomRepository
+	auditRepo   repository.AuditRepository
+	realtime    RealtimeService
+	rateLimit   RateLimitService
+	attachments ChatAttachmentService
+	cfg         config.ChatConfig
+	perms       *roomPermissions
+	log         logger.Logger
 }
 
-func NewChatService(chatRepo repository.ChatRepository, roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, log logger.Logger) ChatService {
+func NewChatService(chatRepo repository.ChatRepository, roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, realtime RealtimeService, rateLimit RateLimitService, attachments ChatAttachmentService, cfg config.ChatConfig, log logger.Logger) ChatService {
 	return &chatService{
-		chatRepo:  chatRepo,
-		roomRepo:  roomRepo,
-		auditRepo: auditRepo,
-		log:       log,
+		chatRepo:    chatRepo,
+		roomRepo:    roomRepo,
+		auditRepo:   auditRepo,
+		realtime:    realtime,
+		rateLimit:   rateLimit,
+		attachments: attachments,
+		cfg:         cfg,
+		perms:       newRoomPermissions(roomRepo),
+		log:         log,
 	}
 }
 
-func (s *chatService) SendMessage(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, content string) (*domain.ChatMessage, error) {
+func (s *chatService) SendMessage(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params SendMessageParams) (*domain.ChatMessage, error) {
+	if strings.TrimSpace(params.Content) == "" && len(params.AttachmentIDs) == 0 {
+		return nil, errors.New("message is empty")
+	}
+	if len(params.AttachmentIDs) > maxMessageAttachments {
+		return nil, errors.New("too many attachments")
+	}
+
 	// Проверка существования комнаты
-	_, err := s.roomRepo.GetByID(ctx, roomID)
+	room, err := s.roomRepo.GetByID(ctx, roomID)
 	if err != nil {
 		return nil, errors.New("room not found")
 	}
 
-	// Получаем или создаем participant
+	// Писать в чат может только активный участник: вход в комнату проходит через Join,
+	// где проверяются waiting room, пароль, блокировка и бан
 	participant, err := s.roomRepo.GetParticipant(ctx, roomID, userID)
-	if err != nil {
-		// Если участник не найден, создаем его
-		participant = &domain.RoomParticipant{
-			ID:           uuid.New(),
-			RoomID:       roomID,
-			UserID:       &userID,
-			Role:         domain.ParticipantRoleParticipant,
-			DisplayName:  "User",
-			JoinedAt:     time.Now(),
-			InitialMuted: false,
-		}
-		if err := s.roomRepo.CreateParticipant(ctx, participant); err != nil {
-			return nil, errors.New("failed to create participant")
+	if err != nil || participant.IsKicked {
+		return nil, errors.New("not a room participant")
+	}
+
+	// Ограничения чата на хоста и co-host не действуют
+	isModerator := s.perms.Can(ctx, room, userID, ActionManageChat)
+	if !isModerator {
+		if err := checkChatAllowed(room, userID); err != nil {
+			return nil, err
 		}
 	}
 
@@ -63,29 +135,184 @@ func (s *chatService) SendMessage(ctx context.Context, roomID uuid.UUID, userID
 		RoomID:              roomID,
 		SenderParticipantID: &participant.ID,
 		MessageType:         domain.MessageTypeUser,
-		Content:             content,
+		Content:             params.Content,
 		CreatedAt:           time.Now(),
 	}
 
+	if err := s.resolveRecipient(ctx, message, userID, params); err != nil {
+		return nil, err
+	}
+	// Упоминание в личном сообщении раскрыло бы его тем, кому оно не адресовано
+	if !message.IsPrivate() {
+		message.Mentions = s.resolveMentions(ctx, roomID, params.Content)
+	}
+
+	if params.ReplyToMessageID != nil {
+		// Ветки общие для комнаты, поэтому личные сообщения в них не участвуют
+		if message.IsPrivate() {
+			return nil, errors.New("private message cannot be a reply")
+		}
+		parent, err := s.chatRepo.GetMessageByID(ctx, *params.ReplyToMessageID)
+		if err != nil || parent.RoomID != roomID || parent.DeletedAt != nil || parent.IsPrivate() {
+			return nil, errors.New("reply target not found")
+		}
+		// Ветки одноуровневые: ответ на ответ попадает в ветку исходного сообщения
+		rootID := parent.ID
+		if parent.ThreadRootID != nil {
+			rootID = *parent.ThreadRootID
+		}
+		message.ReplyToMessageID = &parent.ID
+		message.ThreadRootID = &rootID
+	}
+
+	if len(params.AttachmentIDs) > 0 {
+		if message.Attachments, err = s.attachments.Pending(ctx, roomID, userID, params.AttachmentIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	var slowModeKey string
+	if !isModerator {
+		if slowModeKey, err = s.claimSlowMode(ctx, room, participant.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.chatRepo.CreateMessage(ctx, message); err != nil {
+		// Несохраненное сообщение не занимает интервал медленного режима
+		s.releaseSlowMode(ctx, slowModeKey)
 		return nil, err
 	}
+	s.populate(ctx, message)
+
+	s.deliver(ctx, message, domain.RoomEventTypeMessage, message)
+	s.notifyMentions(ctx, message, message.Mentions)
 
 	return message, nil
 }
 
-func (s *chatService) GetMessages(ctx context.Context, roomID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 50
+// resolveRecipient проверяет адресата личного сообщения и заполняет его в message
+func (s *chatService) resolveRecipient(ctx context.Context, message *domain.ChatMessage, userID uuid.UUID, params SendMessageParams) error {
+	switch params.RecipientType {
+	case "", domain.ChatRecipientRoom, domain.ChatRecipientHosts:
+		if params.RecipientParticipantID != nil {
+			return errors.New("invalid recipient")
+		}
+		message.RecipientType = params.RecipientType
+		if message.RecipientType == "" {
+			message.RecipientType = domain.ChatRecipientRoom
+		}
+		return nil
+
+	case domain.ChatRecipientParticipant:
+		if params.RecipientParticipantID == nil {
+			return errors.New("recipient is required")
+		}
+		// Адресат должен быть в комнате сейчас и иметь аккаунт: у участников по телефону чата нет
+		recipient, err := s.roomRepo.GetParticipantByID(ctx, *params.RecipientParticipantID)
+		if err != nil || recipient.RoomID != message.RoomID || recipient.LeftAt != nil || recipient.IsKicked || recipient.UserID == nil {
+			return errors.New("recipient not found")
+		}
+		if *recipient.UserID == userID {
+			return errors.New("cannot send private message to yourself")
+		}
+		message.RecipientType = domain.ChatRecipientParticipant
+		message.RecipientParticipantID = &recipient.ID
+		return nil
+
+	default:
+		return errors.New("invalid recipient")
+	}
+}
+
+func (s *chatService) GetMessages(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+
+	messages, err := s.chatRepo.GetMessages(ctx, roomID, s.viewer(ctx, room, userID), chatPageSize(limit), offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachments.Populate(ctx, messages); err != nil {
+		return nil, err
 	}
-	return s.chatRepo.GetMessages(ctx, roomID, limit, offset)
+
+	return messages, nil
 }
 
-func (s *chatService) EditMessage(ctx context.Context, messageID int64, userID uuid.UUID, content string) (*domain.ChatMessage, error) {
-	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
+func (s *chatService) GetThread(ctx context.Context, roomID uuid.UUID, messageID int64, userID uuid.UUID, limit, offset int) (*domain.ChatThread, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+	if s.perms.Role(ctx, room, userID) == "" {
+		return nil, errors.New("not a room participant")
+	}
+
+	root, err := s.chatRepo.GetMessageByID(ctx, messageID)
+	if err != nil || root.RoomID != roomID {
+		return nil, errors.New("message not found")
+	}
+
+	// Личное сообщение веток не имеет, но открыть его как ветку может только тот, кому оно видно
+	if root.IsPrivate() && !s.canView(ctx, root, s.viewer(ctx, room, userID)) {
+		return nil, errors.New("message not found")
+	}
+
+	if root.ThreadRootID != nil {
+		root, err = s.chatRepo.GetMessageByID(ctx, *root.ThreadRootID)
+		if err != nil {
+			return nil, errors.New("message not found")
+		}
+	}
+	if root.DeletedAt != nil {
+		return nil, errors.New("message not found")
+	}
+
+	replies, err := s.chatRepo.GetThreadReplies(ctx, root.ID, chatPageSize(limit), offset)
 	if err != nil {
 		return nil, err
 	}
+	if replies == nil {
+		replies = []*domain.ChatMessage{}
+	}
+
+	if err := s.attachments.Populate(ctx, append([]*domain.ChatMessage{root}, replies...)); err != nil {
+		return nil, err
+	}
+
+	return &domain.ChatThread{Root: root, Replies: replies}, nil
+}
+
+func (s *chatService) GetMentions(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, limit, offset int) ([]*domain.ChatMessage, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+	if s.perms.Role(ctx, room, userID) == "" {
+		return nil, errors.New("not a room participant")
+	}
+
+	messages, err := s.chatRepo.GetMentions(ctx, roomID, userID, chatPageSize(limit), offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachments.Populate(ctx, messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (s *chatService) EditMessage(ctx context.Context, roomID uuid.UUID, messageID int64, userID uuid.UUID, content string) (*domain.ChatMessage, error) {
+	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
+	if err != nil || message.RoomID != roomID || message.DeletedAt != nil {
+		return nil, errors.New("message not found")
+	}
 
 	if message.SenderParticipantID == nil {
 		return nil, errors.New("message has no sender")
@@ -97,14 +324,85 @@ func (s *chatService) EditMessage(ctx context.Context, messageID int64, userID u
 		return nil, errors.New("only sender can edit message")
 	}
 
+	// Отключенный чат запрещает и правку: иначе через нее можно было бы продолжать писать
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+	if !s.perms.Can(ctx, room, userID, ActionManageChat) {
+		if err := checkChatAllowed(room, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.cfg.EditWindow > 0 && time.Since(message.CreatedAt) > s.cfg.EditWindow {
+		return nil, errors.New("edit window has expired")
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("message is empty")
+	}
+
+	// Тот же текст новой версией не считается
+	if content == message.Content {
+		s.populate(ctx, message)
+		return message, nil
+	}
+
 	message.Content = content
 	if err := s.chatRepo.UpdateMessage(ctx, message); err != nil {
 		return nil, err
 	}
 
+	// Уведомление получают только участники, упомянутые при редактировании впервые.
+	// В личных сообщениях упоминания не разбираются.
+	previous := message.Mentions
+	if !message.IsPrivate() {
+		message.Mentions = s.resolveMentions(ctx, message.RoomID, content)
+		if err := s.chatRepo.ReplaceMentions(ctx, message.ID, message.Mentions); err != nil {
+			s.log.Warn("Failed to update message mentions", "error", err, "message_id", message.ID)
+		}
+	}
+	s.populate(ctx, message)
+
+	s.deliver(ctx, message, domain.RoomEventTypeEdit, message)
+
+	var added []uuid.UUID
+	for _, participantID := range message.Mentions {
+		if !slices.Contains(previous, participantID) {
+			added = append(added, participantID)
+		}
+	}
+	s.notifyMentions(ctx, message, added)
+
 	return message, nil
 }
 
+func (s *chatService) GetHistory(ctx context.Context, roomID uuid.UUID, messageID int64, userID uuid.UUID) (*domain.ChatMessageHistory, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionViewMessageHistory); err != nil {
+		return nil, err
+	}
+
+	// Историю удаленного сообщения ведущие тоже видят - она нужна для модерации.
+	// Личную переписку других участников ведущие не видят.
+	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
+	if err != nil || message.RoomID != roomID || !s.canView(ctx, message, s.viewer(ctx, room, userID)) {
+		return nil, errors.New("message not found")
+	}
+
+	revisions, err := s.chatRepo.GetRevisions(ctx, messageID)
+	if err != nil {
+		return nil, errors.New("failed to get message history")
+	}
+	s.populate(ctx, message)
+
+	return &domain.ChatMessageHistory{Message: message, Revisions: revisions}, nil
+}
+
 func (s *chatService) DeleteMessage(ctx context.Context, messageID int64, userID uuid.UUID) error {
 	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
 	if err != nil {
@@ -115,12 +413,408 @@ func (s *chatService) DeleteMessage(ctx context.Context, messageID int64, userID
 		return errors.New("message has no sender")
 	}
 
-	// Получаем participant по ID
-	participant, err := s.roomRepo.GetParticipantByID(ctx, *message.SenderParticipantID)
-	if err != nil || participant.UserID == nil || *participant.UserID != userID {
-		return errors.New("only sender can delete message")
+	deletedBy := message.SenderParticipantID
+
+	// Чужие сообщения может удалять модератор комнаты
+	var room *domain.Room
+	sender, err := s.roomRepo.GetParticipantByID(ctx, *message.SenderParticipantID)
+	if err != nil || sender.UserID == nil || *sender.UserID != userID {
+		room, err = s.roomRepo.GetByID(ctx, message.RoomID)
+		if err != nil {
+			return err
+		}
+		if err := s.perms.Authorize(ctx, room, userID, ActionDeleteOthersMessage); err != nil {
+			return err
+		}
+		if !s.canView(ctx, message, s.viewer(ctx, room, userID)) {
+			return errors.New("message not found")
+		}
+
+		// Хост может удалять сообщения, не находясь в комнате
+		deletedBy = nil
+		if moderator, err := s.roomRepo.GetParticipant(ctx, message.RoomID, userID); err == nil {
+			deletedBy = &moderator.ID
+		}
+	}
+
+	if err := s.chatRepo.DeleteMessage(ctx, messageID, deletedBy); err != nil {
+		return err
+	}
+
+	// Удаление своего сообщения не аудируется, удаление модератором - да
+	if room != nil && (deletedBy == nil || *deletedBy != *message.SenderParticipantID) {
+		s.audit(ctx, room, userID, domain.EventTypeChatMessageDeleted, map[string]interface{}{
+			"message_id":            message.ID,
+			"sender_participant_id": *message.SenderParticipantID,
+		})
+	}
+
+	s.deliver(ctx, message, domain.RoomEventTypeDelete, &domain.ChatMessageRef{MessageID: messageID})
+
+	return nil
+}
+
+func (s *chatService) PostSystemMessage(ctx context.Context, roomID uuid.UUID, content string) (*domain.ChatMessage, error) {
+	message := &domain.ChatMessage{
+		RoomID:      roomID,
+		MessageType: domain.MessageTypeSystem,
+		Content:     content,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := s.chatRepo.CreateMessage(ctx, message); err != nil {
+		return nil, err
 	}
 
-	return s.chatRepo.DeleteMessage(ctx, messageID, *message.SenderParticipantID)
+	s.broadcast(ctx, roomID, domain.RoomEventTypeMessage, message)
+
+	return message, nil
+}
+
+func (s *chatService) GetControls(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.ChatControls, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+
+	if s.perms.Role(ctx, room, userID) == "" {
+		return nil, errors.New("not a room participant")
+	}
+
+	return room.ChatControls(), nil
+}
+
+func (s *chatService) UpdateControls(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params UpdateChatControlsParams) (*domain.ChatControls, error) {
+	if params.SlowModeSeconds != nil && (*params.SlowModeSeconds < 0 || *params.SlowModeSeconds > maxChatSlowModeSeconds) {
+		return nil, errors.New("invalid slow mode interval")
+	}
+
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageChat); err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]interface{})
+	var unset []string
+	if params.Enabled != nil {
+		set[domain.RoomSettingChatEnabled] = *params.Enabled
+	}
+	if params.SlowModeSeconds != nil {
+		if *params.SlowModeSeconds == 0 {
+			unset = append(unset, domain.RoomSettingChatSlowModeSeconds)
+		} else {
+			set[domain.RoomSettingChatSlowModeSeconds] = *params.SlowModeSeconds
+		}
+	}
+
+	// Меняются только ключи чата, чтобы не затереть настройки, измененные параллельно
+	room.Settings, err = s.roomRepo.UpdateSettings(ctx, roomID, set, unset)
+	if err != nil {
+		return nil, errors.New("failed to update room")
+	}
+
+	controls := room.ChatControls()
+	s.audit(ctx, room, userID, domain.EventTypeChatControlsUpdated, map[string]interface{}{
+		"enabled":           controls.Enabled,
+		"slow_mode_seconds": controls.SlowModeSeconds,
+	})
+	s.broadcast(ctx, roomID, domain.RoomEventTypeChatControls, controls)
+
+	return controls, nil
+}
+
+func (s *chatService) SetParticipantChat(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID, enabled bool) (*domain.ChatControls, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageChat); err != nil {
+		return nil, err
+	}
+
+	target, err := s.roomRepo.GetParticipantByID(ctx, participantID)
+	if err != nil || target.RoomID != roomID {
+		return nil, errors.New("participant not found")
+	}
+	// Чат доступен только пользователям с аккаунтом, ограничивать участников по телефону нечего
+	if target.UserID == nil {
+		return nil, errors.New("participant cannot use chat")
+	}
+	if roleCan(s.perms.Role(ctx, room, *target.UserID), ActionManageChat) {
+		return nil, errors.New("cannot restrict host or co-host chat")
+	}
+
+	// Список меняется в базе одним запросом, так что параллельные ограничения разных участников не теряются
+	room.Settings, err = s.roomRepo.UpdateSettingsList(ctx, roomID, domain.RoomSettingChatDisabledUsers, target.UserID.String(), !enabled)
+	if err != nil {
+		return nil, errors.New("failed to update room")
+	}
+
+	s.audit(ctx, room, userID, domain.EventTypeChatControlsUpdated, map[string]interface{}{
+		"participant_id": target.ID,
+		"user_id":        *target.UserID,
+		"chat_enabled":   enabled,
+	})
+
+	controls := room.ChatControls()
+	s.broadcast(ctx, roomID, domain.RoomEventTypeChatControls, controls)
+
+	return controls, nil
+}
+
+func (s *chatService) ExportTranscript(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, format string) (*ChatTranscript, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionExportChat); err != nil {
+		return nil, err
+	}
+
+	return newChatTranscript(s.chatRepo, room, s.viewer(ctx, room, userID), format)
+}
+
+// checkChatAllowed - может ли пользователь писать в чат: чат комнаты включен и пользователю не отключен
+func checkChatAllowed(room *domain.Room, userID uuid.UUID) error {
+	controls := room.ChatControls()
+	if !controls.Enabled {
+		return errors.New("chat is disabled")
+	}
+	if slices.Contains(controls.DisabledUserIDs, userID) {
+		return errors.New("chat is disabled for participant")
+	}
+	return nil
+}
+
+// claimSlowMode занимает интервал медленного режима до сохранения сообщения: ключ ставится
+// атомарно (SET NX EX), поэтому из одновременных сообщений участника проходит одно.
+// Возвращает занятый ключ или пустую строку, если медленный режим выключен.
+func (s *chatService) claimSlowMode(ctx context.Context, room *domain.Room, participantID uuid.UUID) (string, error) {
+	seconds := room.ChatControls().SlowModeSeconds
+	if seconds <= 0 {
+		return "", nil
+	}
+
+	key := fmt.Sprintf("chat_slow_mode:%s:%s", room.ID, participantID)
+	acquired, err := s.rateLimit.Acquire(ctx, key, seconds)
+	if err != nil {
+		s.log.Error("Failed to check chat slow mode", "error", err, "room_id", room.ID)
+		return "", errors.New("failed to send message")
+	}
+	if !acquired {
+		return "", errors.New("slow mode is enabled")
+	}
+	return key, nil
+}
+
+// releaseSlowMode освобождает интервал, занятый claimSlowMode для несохраненного сообщения
+func (s *chatService) releaseSlowMode(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := s.rateLimit.Release(ctx, key); err != nil {
+		s.log.Warn("Failed to release chat slow mode interval", "error", err)
+	}
+}
+
+func (s *chatService) audit(ctx context.Context, room *domain.Room, userID uuid.UUID, eventType string, payload map[string]interface{}) {
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &room.ID,
+		EventType:   eventType,
+		Payload:     payload,
+	})
+}
+
+// populate подписывает ссылки на вложения сохраненного сообщения перед рассылкой. Сообщение
+// уже сохранено, поэтому при ошибке оно рассылается без вложений - клиент получит их при чтении истории.
+func (s *chatService) populate(ctx context.Context, message *domain.ChatMessage) {
+	if err := s.attachments.Populate(ctx, []*domain.ChatMessage{message}); err != nil {
+		s.log.Warn("Failed to load message attachments", "error", err, "message_id", message.ID)
+	}
+}
+
+// resolveMentions находит упомянутых в тексте активных участников комнаты. Сообщение важнее
+// упоминаний, поэтому при ошибке чтения участников оно отправляется без них.
+func (s *chatService) resolveMentions(ctx context.Context, roomID uuid.UUID, content string) []uuid.UUID {
+	if !strings.Contains(content, "@") {
+		return nil
+	}
+
+	participants, err := s.roomRepo.GetParticipantsByRoom(ctx, roomID)
+	if err != nil {
+		s.log.Warn("Failed to resolve mentions", "error", err, "room_id", roomID)
+		return nil
+	}
+
+	return parseMentions(content, participants)
+}
+
+// notifyMentions отправляет событие mention подключениям упомянутых участников, кроме автора
+func (s *chatService) notifyMentions(ctx context.Context, message *domain.ChatMessage, participantIDs []uuid.UUID) {
+	recipients := make([]uuid.UUID, 0, len(participantIDs))
+	for _, participantID := range participantIDs {
+		if message.SenderParticipantID == nil || participantID != *message.SenderParticipantID {
+			recipients = append(recipients, participantID)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	if err := s.realtime.PublishTo(ctx, message.RoomID, recipients, domain.RoomEventTypeMention, message); err != nil {
+		s.log.Warn("Failed to notify mentioned participants", "error", err, "room_id", message.RoomID, "message_id", message.ID)
+	}
+}
+
+// parseMentions разбирает упоминания вида @Имя. Имена сравниваются без учета регистра и могут
+// содержать пробелы; из подходящих имен берется самое длинное, тезки упоминаются все.
+// @ внутри слова (например, в адресе почты) упоминанием не считается.
+func parseMentions(content string, participants []*domain.RoomParticipant) []uuid.UUID {
+	byName := make(map[string][]uuid.UUID)
+	for _, participant := range participants {
+		name := strings.ToLower(strings.TrimSpace(participant.DisplayName))
+		if participant.IsKicked || name == "" {
+			continue
+		}
+		byName[name] = append(byName[name], participant.ID)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	text := strings.ToLower(content)
+	var mentions []uuid.UUID
+	for i := 0; i < len(text); i++ {
+		if text[i] != '@' {
+			continue
+		}
+		if prev, _ := utf8.DecodeLastRuneInString(text[:i]); i > 0 && isMentionRune(prev) {
+			continue
+		}
+
+		rest := text[i+1:]
+		for _, name := range names {
+			if !strings.HasPrefix(rest, name) {
+				continue
+			}
+			if next, _ := utf8.DecodeRuneInString(rest[len(name):]); len(rest) > len(name) && isMentionRune(next) {
+				continue
+			}
+			for _, participantID := range byName[name] {
+				if !slices.Contains(mentions, participantID) {
+					mentions = append(mentions, participantID)
+				}
+			}
+			i += len(name)
+			break
+		}
+	}
+
+	return mentions
+}
+
+func isMentionRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func chatPageSize(limit int) int {
+	if limit <= 0 || limit > maxChatPageSize {
+		return defaultChatPageSize
+	}
+	return limit
+}
+
+// viewer - пользователь как читатель чата комнаты
+func (s *chatService) viewer(ctx context.Context, room *domain.Room, userID uuid.UUID) domain.ChatViewer {
+	return domain.ChatViewer{UserID: userID, Host: s.perms.Can(ctx, room, userID, ActionReadHostsMessages)}
+}
+
+// canView - видно ли сообщение читателю; общие сообщения видны всем
+func (s *chatService) canView(ctx context.Context, message *domain.ChatMessage, viewer domain.ChatViewer) bool {
+	if !message.IsPrivate() {
+		return true
+	}
+	visible, err := s.chatRepo.IsVisible(ctx, message.ID, viewer)
+	return err == nil && visible
+}
+
+// deliver рассылает событие о сообщении: общее - всей комнате, личное - только подключениям
+// отправителя и адресатов. Адресаты определяются по пользователям, поэтому событие доходит
+// и до тех, кто перезашел в комнату после отправки.
+func (s *chatService) deliver(ctx context.Context, message *domain.ChatMessage, eventType string, payload interface{}) {
+	if !message.IsPrivate() {
+		s.broadcast(ctx, message.RoomID, eventType, payload)
+		return
+	}
+
+	recipients, err := s.privateRecipients(ctx, message)
+	if err != nil {
+		s.log.Warn("Failed to resolve private message recipients", "error", err, "room_id", message.RoomID, "message_id", message.ID)
+		return
+	}
+	// Пустой список адресатов означает рассылку всей комнате
+	if len(recipients) == 0 {
+		return
+	}
+
+	if err := s.realtime.PublishTo(ctx, message.RoomID, recipients, eventType, payload); err != nil {
+		s.log.Warn("Failed to deliver private chat event", "error", err, "room_id", message.RoomID, "type", eventType)
+	}
+}
+
+// privateRecipients возвращает активных участников комнаты, которым видно личное сообщение
+func (s *chatService) privateRecipients(ctx context.Context, message *domain.ChatMessage) ([]uuid.UUID, error) {
+	room, err := s.roomRepo.GetByID(ctx, message.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []uuid.UUID
+	for _, participantID := range []*uuid.UUID{message.SenderParticipantID, message.RecipientParticipantID} {
+		if participantID == nil {
+			continue
+		}
+		if participant, err := s.roomRepo.GetParticipantByID(ctx, *participantID); err == nil && participant.UserID != nil {
+			users = append(users, *participant.UserID)
+		}
+	}
+
+	participants, err := s.roomRepo.GetParticipantsByRoom(ctx, message.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []uuid.UUID
+	for _, participant := range participants {
+		if participant.UserID == nil || participant.IsKicked {
+			continue
+		}
+		isHost := *participant.UserID == room.HostUserID || roleCan(participant.Role, ActionReadHostsMessages)
+		if slices.Contains(users, *participant.UserID) || (message.RecipientType == domain.ChatRecipientHosts && isHost) {
+			recipients = append(recipients, participant.ID)
+		}
+	}
+
+	return recipients, nil
+}
+
+// broadcast рассылает событие чата в комнату. Сообщение уже сохранено,
+// поэтому ошибка публикации не возвращается клиенту.
+func (s *chatService) broadcast(ctx context.Context, roomID uuid.UUID, eventType string, payload interface{}) {
+	if err := s.realtime.Publish(ctx, roomID, eventType, payload); err != nil {
+		s.log.Warn("Failed to broadcast chat event", "error", err, "room_id", roomID, "type", eventType)
+	}
 }
 
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	// Декодеры форматов изображений для превью
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+	"video_conference/pkg/storage"
+)
+
+const (
+	// Длинная сторона превью изображения
+	attachmentThumbnailSize = 320
+	// Изображения больше этого числа пикселей не разбираются: превью потребовало бы слишком много памяти
+	attachmentMaxImagePixels = 50_000_000
+	// Загруженные, но так и не отправленные вложения удаляются через сутки
+	pendingAttachmentTTL = 24 * time.Hour
+	// Сколько вложений удаляется за один проход очистки
+	attachmentCleanupBatch = 100
+	// Через сколько повторить очистку вложения, файлы которого не удалось удалить
+	attachmentCleanupRetry  = time.Hour
+	maxAttachmentNameLength = 255
+)
+
+// ChatAttachmentService - файлы и изображения в чате комнаты. Файл загружается отдельно и
+// прикрепляется к сообщению по ID при отправке. Ссылки на скачивание подписаны и действуют
+// STORAGE_URL_TTL, поэтому выдаются заново при каждом чтении сообщений.
+type ChatAttachmentService interface {
+	// Upload проверяет размер и тип файла, сохраняет его в хранилище и строит превью для изображений
+	Upload(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, fileName string, size int64, body io.ReadSeeker) (*domain.ChatAttachment, error)
+	// Get возвращает вложение со свежими ссылками
+	Get(ctx context.Context, roomID uuid.UUID, attachmentID uuid.UUID, userID uuid.UUID) (*domain.ChatAttachment, error)
+	// Pending проверяет, что вложения загружены пользователем в эту комнату и еще не отправлены
+	Pending(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, attachmentIDs []uuid.UUID) ([]*domain.ChatAttachment, error)
+	// Populate заполняет вложения сообщений со ссылками на скачивание
+	Populate(ctx context.Context, messages []*domain.ChatMessage) error
+	// OpenFile открывает файл локального хранилища по подписанной ссылке
+	OpenFile(key string, fileName string, expires int64, signature string) (*os.File, error)
+	// DeleteExpired удаляет вложения с истекшим сроком хранения, возвращает их число
+	DeleteExpired(ctx context.Context) (int, error)
+}
+
+type chatAttachmentService struct {
+	attachmentRepo repository.ChatAttachmentRepository
+	chatRepo       repository.ChatRepository
+	roomRepo       repository.RoomRepository
+	storage        storage.Storage
+	cfg            config.ChatConfig
+	urlTTL         time.Duration
+	perms          *roomPermissions
+	log            logger.Logger
+}
+
+func NewChatAttachmentService(attachmentRepo repository.ChatAttachmentRepository, chatRepo repository.ChatRepository, roomRepo repository.RoomRepository, fileStorage storage.Storage, cfg config.ChatConfig, urlTTL time.Duration, log logger.Logger) ChatAttachmentService {
+	return &chatAttachmentService{
+		attachmentRepo: attachmentRepo,
+		chatRepo:       chatRepo,
+		roomRepo:       roomRepo,
+		storage:        fileStorage,
+		cfg:            cfg,
+		urlTTL:         urlTTL,
+		perms:          newRoomPermissions(roomRepo),
+		log:            log,
+	}
+}
+
+func (s *chatAttachmentService) Upload(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, fileName string, size int64, body io.ReadSeeker) (*domain.ChatAttachment, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	participant, err := s.roomRepo.GetParticipant(ctx, roomID, userID)
+	if err != nil || participant.IsKicked {
+		return nil, errors.New("not a room participant")
+	}
+
+	if size <= 0 {
+		return nil, errors.New("attachment is empty")
+	}
+	if size > s.cfg.AttachmentMaxSize {
+		return nil, errors.New("attachment is too large")
+	}
+
+	fileName = attachmentFileName(fileName)
+	if fileName == "" {
+		return nil, errors.New("invalid file name")
+	}
+
+	// Тип определяется по содержимому: заголовку Content-Type от клиента доверять нельзя
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, errors.New("failed to read attachment")
+	}
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil || !slices.Contains(s.cfg.AttachmentAllowedTypes, contentType) {
+		return nil, errors.New("attachment type is not allowed")
+	}
+
+	attachment := &domain.ChatAttachment{
+		ID:             uuid.New(),
+		RoomID:         roomID,
+		UploaderUserID: userID,
+		FileName:       fileName,
+		ContentType:    contentType,
+		SizeBytes:      size,
+	}
+	attachment.StorageKey = "chat/" + roomID.String() + "/" + attachment.ID.String()
+
+	var thumbnail []byte
+	if strings.HasPrefix(contentType, "image/") {
+		if thumbnail, err = s.thumbnail(body, attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.New("failed to read attachment")
+	}
+	if err := s.storage.Put(ctx, attachment.StorageKey, body, size, contentType); err != nil {
+		s.log.Error("Failed to store chat attachment", "error", err, "room_id", roomID)
+		return nil, errors.New("failed to store attachment")
+	}
+
+	if thumbnail != nil {
+		thumbnailKey := attachment.StorageKey + "-thumb.jpg"
+		if err := s.storage.Put(ctx, thumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+			s.log.Error("Failed to store attachment thumbnail", "error", err, "room_id", roomID)
+			s.deleteObjects(ctx, attachment)
+			return nil, errors.New("failed to store attachment")
+		}
+		attachment.ThumbnailKey = &thumbnailKey
+	}
+
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		s.deleteObjects(ctx, attachment)
+		return nil, errors.New("failed to store attachment")
+	}
+
+	if err := s.sign(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (s *chatAttachmentService) Get(ctx context.Context, roomID uuid.UUID, attachmentID uuid.UUID, userID uuid.UUID) (*domain.ChatAttachment, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("room not found")
+	}
+	if s.perms.Role(ctx, room, userID) == "" {
+		return nil, errors.New("not a room participant")
+	}
+
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil || attachment.RoomID != roomID {
+		return nil, errors.New("attachment not found")
+	}
+
+	// До отправки вложение видит только загрузивший, вложения удаленных сообщений не видит никто
+	if attachment.MessageID == nil {
+		if attachment.UploaderUserID != userID {
+			return nil, errors.New("attachment not found")
+		}
+	} else if message, err := s.chatRepo.GetMessageByID(ctx, *attachment.MessageID); err != nil || message.DeletedAt != nil {
+		return nil, errors.New("attachment not found")
+	} else if message.IsPrivate() {
+		// Вложение личного сообщения видно только тем, кому видно само сообщение
+		viewer := domain.ChatViewer{UserID: userID, Host: s.perms.Can(ctx, room, userID, ActionReadHostsMessages)}
+		if visible, err := s.chatRepo.IsVisible(ctx, message.ID, viewer); err != nil || !visible {
+			return nil, errors.New("attachment not found")
+		}
+	}
+
+	if err := s.sign(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (s *chatAttachmentService) Pending(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, attachmentIDs []uuid.UUID) ([]*domain.ChatAttachment, error) {
+	ids := make([]uuid.UUID, 0, len(attachmentIDs))
+	for _, id := range attachmentIDs {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	attachments, err := s.attachmentRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.New("failed to get attachments")
+	}
+	if len(attachments) != len(ids) {
+		return nil, errors.New("attachment not found")
+	}
+
+	for _, attachment := range attachments {
+		if attachment.RoomID != roomID || attachment.UploaderUserID != userID {
+			return nil, errors.New("attachment not found")
+		}
+		if attachment.MessageID != nil {
+			return nil, errors.New("attachment already sent")
+		}
+	}
+
+	return attachments, nil
+}
+
+func (s *chatAttachmentService) Populate(ctx context.Context, messages []*domain.ChatMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(messages))
+	byID := make(map[int64]*domain.ChatMessage, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+		byID[message.ID] = message
+		message.Attachments = nil
+	}
+
+	attachments, err := s.attachmentRepo.ListByMessages(ctx, ids)
+	if err != nil {
+		return errors.New("failed to get attachments")
+	}
+
+	for _, attachment := range attachments {
+		message, ok := byID[*attachment.MessageID]
+		if !ok {
+			continue
+		}
+		if err := s.sign(ctx, attachment); err != nil {
+			return err
+		}
+		message.Attachments = append(message.Attachments, attachment)
+	}
+
+	return nil
+}
+
+func (s *chatAttachmentService) OpenFile(key string, fileName string, expires int64, signature string) (*os.File, error) {
+	local, ok := s.storage.(*storage.Local)
+	if !ok {
+		// Файлы S3 скачиваются напрямую из хранилища
+		return nil, storage.ErrNotFound
+	}
+	return local.Open(key, fileName, expires, signature)
+}
+
+func (s *chatAttachmentService) DeleteExpired(ctx context.Context) (int, error) {
+	attachments, err := s.attachmentRepo.ListExpired(ctx, s.cfg.AttachmentRetention, time.Now().Add(-pendingAttachmentTTL), attachmentCleanupBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, attachment := range attachments {
+		// Запись удаляется только после файлов, иначе при сбое хранилища файлы останутся без владельца
+		if err := s.deleteObjects(ctx, attachment); err != nil {
+			// Вложение откладывается, чтобы следующие проходы брали другие вложения
+			if err := s.attachmentRepo.DeferCleanup(ctx, attachment.ID, time.Now().Add(attachmentCleanupRetry)); err != nil {
+				return deleted, err
+			}
+			continue
+		}
+		if err := s.attachmentRepo.Delete(ctx, attachment.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// thumbnail проверяет изображение, запоминает его размеры и строит превью в JPEG
+func (s *chatAttachmentService) thumbnail(body io.ReadSeeker, attachment *domain.ChatAttachment) ([]byte, error) {
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.New("failed to read attachment")
+	}
+	imageConfig, _, err := image.DecodeConfig(body)
+	if err != nil {
+		return nil, errors.New("invalid image")
+	}
+	if imageConfig.Width <= 0 || imageConfig.Height <= 0 || imageConfig.Width*imageConfig.Height > attachmentMaxImagePixels {
+		return nil, errors.New("image dimensions are too large")
+	}
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.New("failed to read attachment")
+	}
+	src, _, err := image.Decode(body)
+	if err != nil {
+		return nil, errors.New("invalid image")
+	}
+
+	width, height := imageConfig.Width, imageConfig.Height
+	attachment.Width, attachment.Height = &width, &height
+
+	thumbWidth, thumbHeight := width, height
+	if width > attachmentThumbnailSize || height > attachmentThumbnailSize {
+		if width >= height {
+			thumbWidth = attachmentThumbnailSize
+			thumbHeight = max(1, height*attachmentThumbnailSize/width)
+		} else {
+			thumbHeight = attachmentThumbnailSize
+			thumbWidth = max(1, width*attachmentThumbnailSize/height)
+		}
+	}
+
+	// JPEG без прозрачности: прозрачные области PNG, GIF и WebP становятся белыми
+	dst := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, errors.New("failed to create thumbnail")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// sign выдает вложению ссылки на скачивание. Превью отдается без имени файла, чтобы браузер
+// показывал его на странице, а не скачивал.
+func (s *chatAttachmentService) sign(ctx context.Context, attachment *domain.ChatAttachment) error {
+	url, err := s.storage.SignedURL(ctx, attachment.StorageKey, attachment.FileName, s.urlTTL)
+	if err != nil {
+		s.log.Error("Failed to sign attachment url", "error", err, "attachment_id", attachment.ID)
+		return errors.New("failed to sign attachment url")
+	}
+	attachment.URL = url
+	attachment.URLExpiresAt = time.Now().Add(s.urlTTL)
+
+	if attachment.ThumbnailKey != nil {
+		url, err := s.storage.SignedURL(ctx, *attachment.ThumbnailKey, "", s.urlTTL)
+		if err != nil {
+			s.log.Error("Failed to sign attachment thumbnail url", "error", err, "attachment_id", attachment.ID)
+			return errors.New("failed to sign attachment url")
+		}
+		attachment.ThumbnailURL = url
+	}
+
+	return nil
+}
+
+func (s *chatAttachmentService) deleteObjects(ctx context.Context, attachment *domain.ChatAttachment) error {
+	keys := []string{attachment.StorageKey}
+	if attachment.ThumbnailKey != nil {
+		keys = append(keys, *attachment.ThumbnailKey)
+	}
+
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			s.log.Warn("Failed to delete attachment file", "error", err, "attachment_id", attachment.ID, "key", key)
+			return err
+		}
+	}
+	return nil
+}
+
+// attachmentFileName оставляет от присланного имени только имя файла без пути и управляющих символов
+func attachmentFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "." || name == ".." || name == "/" || !utf8.ValidString(name) {
+		return ""
+	}
+
+	for len(name) > maxAttachmentNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/storage"
+)
+
+// fakeAttachmentRepo отдает все вложения как просроченные, повторяя порядок и фильтр ListExpired
+type fakeAttachmentRepo struct {
+	repository.ChatAttachmentRepository
+
+	attachments map[uuid.UUID]*domain.ChatAttachment
+	retryAt     map[uuid.UUID]time.Time
+}
+
+func (r *fakeAttachmentRepo) ListExpired(ctx context.Context, defaultRetention time.Duration, pendingBefore time.Time, limit int) ([]*domain.ChatAttachment, error) {
+	now := time.Now()
+	var result []*domain.ChatAttachment
+	for _, attachment := range r.attachments {
+		if retryAt, ok := r.retryAt[attachment.ID]; !ok || !retryAt.After(now) {
+			result = append(result, attachment)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		_, deferredI := r.retryAt[result[i].ID]
+		_, deferredJ := r.retryAt[result[j].ID]
+		if deferredI != deferredJ {
+			return deferredJ
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (r *fakeAttachmentRepo) DeferCleanup(ctx context.Context, id uuid.UUID, retryAt time.Time) error {
+	r.retryAt[id] = retryAt
+	return nil
+}
+
+func (r *fakeAttachmentRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.attachments, id)
+	return nil
+}
+
+// fakeStorage не может удалить ключи из broken
+type fakeStorage struct {
+	storage.Storage
+
+	broken  map[string]bool
+	deleted []string
+}
+
+func (s *fakeStorage) Delete(ctx context.Context, key string) error {
+	if s.broken[key] {
+		return errors.New("storage unavailable")
+	}
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestDeleteExpiredDefersFailedAttachments(t *testing.T) {
+	repo := &fakeAttachmentRepo{
+		attachments: make(map[uuid.UUID]*domain.ChatAttachment),
+		retryAt:     make(map[uuid.UUID]time.Time),
+	}
+	files := &fakeStorage{broken: make(map[string]bool)}
+
+	// Самые старые attachmentCleanupBatch вложений удалить не удается
+	created := time.Now().Add(-48 * time.Hour)
+	for i := 0; i < attachmentCleanupBatch+1; i++ {
+		attachment := &domain.ChatAttachment{
+			ID:         uuid.New(),
+			StorageKey: "chat/room/" + uuid.NewString(),
+			CreatedAt:  created.Add(time.Duration(i) * time.Second),
+		}
+		repo.attachments[attachment.ID] = attachment
+		if i < attachmentCleanupBatch {
+			files.broken[attachment.StorageKey] = true
+		}
+	}
+
+	svc := NewChatAttachmentService(repo, nil, newFakeRoomRepo(), files, config.ChatConfig{}, time.Minute, nopLogger{})
+	ctx := context.Background()
+
+	deleted, err := svc.DeleteExpired(ctx)
+	if err != nil || deleted != 0 {
+		t.Fatalf("first pass: deleted %d, err %v; want 0, nil", deleted, err)
+	}
+	if len(repo.retryAt) != attachmentCleanupBatch {
+		t.Fatalf("deferred attachments = %d, want %d", len(repo.retryAt), attachmentCleanupBatch)
+	}
+	for _, retryAt := range repo.retryAt {
+		if retryAt.Before(time.Now().Add(attachmentCleanupRetry - time.Minute)) {
+			t.Fatalf("retry at %v, want about %v from now", retryAt, attachmentCleanupRetry)
+		}
+	}
+
+	// Второй проход не застревает на неудачных вложениях
+	deleted, err = svc.DeleteExpired(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("second pass: deleted %d, err %v; want 1, nil", deleted, err)
+	}
+	if len(repo.attachments) != attachmentCleanupBatch {
+		t.Errorf("attachments left = %d, want %d", len(repo.attachments), attachmentCleanupBatch)
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+)
+
+type fakeChatRepo struct {
+	repository.ChatRepository
+
+	messages  map[int64]*domain.ChatMessage
+	createErr error
+}
+
+func (r *fakeChatRepo) CreateMessage(ctx context.Context, message *domain.ChatMessage) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	message.ID = int64(len(r.messages) + 1)
+	r.messages[message.ID] = message
+	return nil
+}
+
+func (r *fakeChatRepo) GetMessageByID(ctx context.Context, id int64) (*domain.ChatMessage, error) {
+	message, ok := r.messages[id]
+	if !ok {
+		return nil, errors.New("message not found")
+	}
+	return message, nil
+}
+
+func (r *fakeChatRepo) UpdateMessage(ctx context.Context, message *domain.ChatMessage) error {
+	r.messages[message.ID] = message
+	return nil
+}
+
+func (r *fakeChatRepo) ReplaceMentions(ctx context.Context, messageID int64, participantIDs []uuid.UUID) error {
+	return nil
+}
+
+// fakeRateLimit - счетчики в памяти без истечения окна
+type fakeRateLimit struct {
+	counts map[string]int64
+}
+
+func (r *fakeRateLimit) CheckLimit(ctx context.Context, key string, limit int, windowSeconds int) (bool, error) {
+	return r.counts[key] < int64(limit), nil
+}
+
+func (r *fakeRateLimit) Increment(ctx context.Context, key string, windowSeconds int) (int64, error) {
+	r.counts[key]++
+	return r.counts[key], nil
+}
+
+func (r *fakeRateLimit) Acquire(ctx context.Context, key string, windowSeconds int) (bool, error) {
+	if r.counts[key] > 0 {
+		return false, nil
+	}
+	r.counts[key] = 1
+	return true, nil
+}
+
+func (r *fakeRateLimit) Release(ctx context.Context, key string) error {
+	delete(r.counts, key)
+	return nil
+}
+
+type fakeChatAttachments struct {
+	ChatAttachmentService
+}
+
+func (fakeChatAttachments) Populate(ctx context.Context, messages []*domain.ChatMessage) error {
+	return nil
+}
+
+type chatFixture struct {
+	*roomFixture
+	service  ChatService
+	chatRepo *fakeChatRepo
+}
+
+func newChatFixture(t *testing.T) *chatFixture {
+	t.Helper()
+
+	f := &chatFixture{
+		roomFixture: newRoomFixture(t),
+		chatRepo:    &fakeChatRepo{messages: make(map[int64]*domain.ChatMessage)},
+	}
+	f.service = NewChatService(f.chatRepo, f.roomRepo, f.audit, f.realtime, &fakeRateLimit{counts: make(map[string]int64)},
+		fakeChatAttachments{}, config.ChatConfig{}, nopLogger{})
+
+	return f
+}
+
+func (f *chatFixture) send(content string) error {
+	_, err := f.service.SendMessage(context.Background(), f.room.ID, *f.participant.UserID, SendMessageParams{Content: content})
+	return err
+}
+
+func TestSlowModeReleasedWhenMessageIsNotSaved(t *testing.T) {
+	f := newChatFixture(t)
+	f.room.Settings[domain.RoomSettingChatSlowModeSeconds] = 30
+
+	f.chatRepo.createErr = errors.New("database unavailable")
+	if err := f.send("first"); err == nil {
+		t.Fatal("expected error when message is not saved")
+	}
+
+	f.chatRepo.createErr = nil
+	if err := f.send("retry"); err != nil {
+		t.Fatalf("retry after failed insert: %v", err)
+	}
+	if err := f.send("too soon"); err == nil || err.Error() != "slow mode is enabled" {
+		t.Fatalf("second message = %v, want slow mode error", err)
+	}
+	if len(f.chatRepo.messages) != 1 {
+		t.Errorf("saved messages = %d, want 1", len(f.chatRepo.messages))
+	}
+}
+
+func TestEditMessageAppliesChatControls(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		asHost   bool
+		wantErr  string
+	}{
+		{name: "chat enabled", settings: map[string]interface{}{}},
+		{name: "chat disabled", settings: map[string]interface{}{domain.RoomSettingChatEnabled: false}, wantErr: "chat is disabled"},
+		{name: "chat disabled for participant", wantErr: "chat is disabled for participant"},
+		{name: "host edits in disabled chat", settings: map[string]interface{}{domain.RoomSettingChatEnabled: false}, asHost: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newChatFixture(t)
+			author := f.participant
+			if tt.asHost {
+				author = &domain.RoomParticipant{ID: uuid.New(), RoomID: f.room.ID, UserID: &f.room.HostUserID, Role: domain.ParticipantRoleHost}
+				f.roomRepo.participants[author.ID] = author
+			}
+			message := &domain.ChatMessage{
+				ID:                  1,
+				RoomID:              f.room.ID,
+				SenderParticipantID: &author.ID,
+				MessageType:         domain.MessageTypeUser,
+				RecipientType:       domain.ChatRecipientRoom,
+				Content:             "before",
+				CreatedAt:           time.Now(),
+			}
+			f.chatRepo.messages[message.ID] = message
+
+			f.room.Settings = tt.settings
+			if f.room.Settings == nil {
+				f.room.Settings = map[string]interface{}{domain.RoomSettingChatDisabledUsers: []interface{}{f.participant.UserID.String()}}
+			}
+
+			_, err := f.service.EditMessage(context.Background(), f.room.ID, message.ID, *author.UserID, "after")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("EditMessage error = %v, want %q", err, tt.wantErr)
+				}
+				if message.Content != "before" {
+					t.Errorf("message was edited to %q", message.Content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EditMessage: %v", err)
+			}
+			if message.Content != "after" {
+				t.Errorf("content = %q, want %q", message.Content, "after")
+			}
+		})
+	}
+}
+
+func TestChatReadsRequireParticipant(t *testing.T) {
+	f := newChatFixture(t)
+	outsider := uuid.New()
+
+	if _, err := f.service.GetThread(context.Background(), f.room.ID, 1, outsider, 0, 0); err == nil || err.Error() != "not a room participant" {
+		t.Errorf("GetThread error = %v, want not a room participant", err)
+	}
+	if _, err := f.service.GetMentions(context.Background(), f.room.ID, outsider, 0, 0); err == nil || err.Error() != "not a room participant" {
+		t.Errorf("GetMentions error = %v, want not a room participant", err)
+	}
+}
+
+func TestParseMentions(t *testing.T) {
+	participant := func(name string) *domain.RoomParticipant {
+		return &domain.RoomParticipant{ID: uuid.New(), DisplayName: name}
+	}
+	anna := participant("Anna")
+	annaMaria := participant("Anna Maria")
+	ivan := participant("Иван Петров")
+	bob := participant("bob_2")
+	annaNamesake := participant("anna ")
+	kicked := participant("Mallory")
+	kicked.IsKicked = true
+	unnamed := participant("  ")
+
+	participants := []*domain.RoomParticipant{anna, annaMaria, ivan, bob, annaNamesake, kicked, unnamed}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []uuid.UUID
+	}{
+		{name: "no mentions", content: "hello everyone", want: nil},
+		{name: "single", content: "@bob_2 hi", want: []uuid.UUID{bob.ID}},
+		{name: "case insensitive", content: "hi @BOB_2", want: []uuid.UUID{bob.ID}},
+		{name: "multi-word name", content: "@Иван Петров, привет", want: []uuid.UUID{ivan.ID}},
+		{name: "longest name wins", content: "@Anna Maria look", want: []uuid.UUID{annaMaria.ID}},
+		{name: "namesakes are all mentioned", content: "@anna look", want: []uuid.UUID{anna.ID, annaNamesake.ID}},
+		{name: "trailing punctuation", content: "thanks @bob_2! and @anna.", want: []uuid.UUID{bob.ID, anna.ID, annaNamesake.ID}},
+		{name: "name followed by letter", content: "@annabel hi", want: nil},
+		{name: "name followed by digit or underscore", content: "@bob_23 @bob_2_", want: nil},
+		{name: "email is not a mention", content: "mail bob@bob_2 please", want: nil},
+		{name: "duplicates are reported once", content: "@bob_2 @bob_2 (@BOB_2)", want: []uuid.UUID{bob.ID}},
+		{name: "order of first mention", content: "@Иван Петров and @bob_2", want: []uuid.UUID{ivan.ID, bob.ID}},
+		{name: "kicked participant", content: "@Mallory", want: nil},
+		{name: "lone at sign", content: "@ @", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMentions(tt.content, participants)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMentions(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
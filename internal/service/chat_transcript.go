@@ -0,0 +1,273 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+)
+
+// Форматы выгрузки чата
+const (
+	TranscriptFormatJSON     = "json"
+	TranscriptFormatMarkdown = "markdown"
+	TranscriptFormatText     = "text"
+)
+
+const (
+	transcriptTimeFormat = "2006-01-02 15:04:05"
+	transcriptBufferSize = 32 * 1024
+)
+
+// ChatTranscript - подготовленная выгрузка чата комнаты. Права проверяются при ее создании,
+// сообщения читаются из БД и пишутся в Stream по одному.
+type ChatTranscript struct {
+	Format      string
+	ContentType string
+	FileName    string
+
+	room     *domain.Room
+	viewer   domain.ChatViewer
+	chatRepo repository.ChatRepository
+}
+
+func newChatTranscript(chatRepo repository.ChatRepository, room *domain.Room, viewer domain.ChatViewer, format string) (*ChatTranscript, error) {
+	transcript := &ChatTranscript{
+		Format:   format,
+		room:     room,
+		viewer:   viewer,
+		chatRepo: chatRepo,
+	}
+
+	switch format {
+	case TranscriptFormatJSON:
+		transcript.ContentType = "application/json; charset=utf-8"
+		transcript.FileName = "chat-" + room.ID.String() + ".json"
+	case TranscriptFormatMarkdown:
+		transcript.ContentType = "text/markdown; charset=utf-8"
+		transcript.FileName = "chat-" + room.ID.String() + ".md"
+	case TranscriptFormatText:
+		transcript.ContentType = "text/plain; charset=utf-8"
+		transcript.FileName = "chat-" + room.ID.String() + ".txt"
+	default:
+		return nil, errors.New("invalid transcript format")
+	}
+
+	return transcript, nil
+}
+
+// Stream пишет выгрузку в w. Ошибка посреди выгрузки означает, что вывод оборван.
+func (t *ChatTranscript) Stream(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriterSize(w, transcriptBufferSize)
+
+	var writer transcriptWriter
+	switch t.Format {
+	case TranscriptFormatJSON:
+		writer = &jsonTranscriptWriter{w: bw}
+	case TranscriptFormatMarkdown:
+		writer = &markdownTranscriptWriter{w: bw}
+	default:
+		writer = &textTranscriptWriter{w: bw}
+	}
+
+	if err := writer.header(t.room, time.Now()); err != nil {
+		return err
+	}
+
+	err := t.chatRepo.StreamTranscript(ctx, t.room.ID, t.viewer, func(entry *domain.ChatTranscriptEntry) error {
+		// От удаленного сообщения остается только отметка об удалении
+		if entry.Deleted {
+			entry.Content = ""
+			entry.Attachments = nil
+		}
+		return writer.entry(entry)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writer.footer(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+type transcriptWriter interface {
+	header(room *domain.Room, exportedAt time.Time) error
+	entry(entry *domain.ChatTranscriptEntry) error
+	footer() error
+}
+
+// jsonTranscriptWriter пишет объект с данными комнаты и массивом messages, по сообщению на строку
+type jsonTranscriptWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+func (j *jsonTranscriptWriter) header(room *domain.Room, exportedAt time.Time) error {
+	head, err := json.Marshal(struct {
+		RoomID     uuid.UUID `json:"room_id"`
+		Title      string    `json:"title"`
+		ExportedAt time.Time `json:"exported_at"`
+	}{room.ID, room.Title, exportedAt.UTC()})
+	if err != nil {
+		return err
+	}
+
+	// Массив messages дописывается вручную, чтобы не собирать его в памяти
+	_, err = j.w.Write(append(head[:len(head)-1], `,"messages":[`...))
+	return err
+}
+
+func (j *jsonTranscriptWriter) entry(entry *domain.ChatTranscriptEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if j.count > 0 {
+		j.w.WriteByte(',')
+	}
+	j.count++
+	j.w.WriteByte('\n')
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonTranscriptWriter) footer() error {
+	_, err := j.w.WriteString("\n]}\n")
+	return err
+}
+
+type markdownTranscriptWriter struct {
+	w *bufio.Writer
+}
+
+func (m *markdownTranscriptWriter) header(room *domain.Room, exportedAt time.Time) error {
+	_, err := fmt.Fprintf(m.w, "# Чат: %s\n\nВыгрузка: %s UTC\n", escapeMarkdown(room.Title), exportedAt.UTC().Format(transcriptTimeFormat))
+	return err
+}
+
+func (m *markdownTranscriptWriter) entry(entry *domain.ChatTranscriptEntry) error {
+	var b strings.Builder
+	b.WriteString("\n**" + escapeMarkdown(transcriptSender(entry)) + "**")
+	if recipient := transcriptRecipient(entry); recipient != "" {
+		b.WriteString(" → **" + escapeMarkdown(recipient) + "**")
+	}
+	b.WriteString(" · " + entry.CreatedAt.UTC().Format(transcriptTimeFormat) + fmt.Sprintf(" · #%d", entry.ID))
+	if entry.ReplyToMessageID != nil {
+		b.WriteString(fmt.Sprintf(" · ответ на #%d", *entry.ReplyToMessageID))
+	}
+	if entry.Edited && !entry.Deleted {
+		b.WriteString(" · _изменено_")
+	}
+	b.WriteString("\n\n")
+
+	if entry.Deleted {
+		b.WriteString("_Сообщение удалено_\n")
+	} else {
+		if entry.Content != "" {
+			// Переносы строк внутри сообщения сохраняются как жесткие переносы Markdown
+			b.WriteString(strings.ReplaceAll(entry.Content, "\n", "  \n") + "\n")
+		}
+		if len(entry.Attachments) > 0 {
+			if entry.Content != "" {
+				b.WriteString("\n")
+			}
+			b.WriteString("Вложения: " + escapeMarkdown(strings.Join(entry.Attachments, ", ")) + "\n")
+		}
+	}
+
+	_, err := m.w.WriteString(b.String())
+	return err
+}
+
+func (m *markdownTranscriptWriter) footer() error {
+	return nil
+}
+
+// textTranscriptWriter пишет по строке на сообщение; продолжение многострочного текста - с отступом
+type textTranscriptWriter struct {
+	w *bufio.Writer
+}
+
+func (t *textTranscriptWriter) header(room *domain.Room, exportedAt time.Time) error {
+	_, err := fmt.Fprintf(t.w, "Чат: %s\nВыгрузка: %s UTC\n\n", room.Title, exportedAt.UTC().Format(transcriptTimeFormat))
+	return err
+}
+
+func (t *textTranscriptWriter) entry(entry *domain.ChatTranscriptEntry) error {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("[%s] #%d %s", entry.CreatedAt.UTC().Format(transcriptTimeFormat), entry.ID, transcriptSender(entry)))
+	if recipient := transcriptRecipient(entry); recipient != "" {
+		b.WriteString(" -> " + recipient)
+	}
+	if entry.ReplyToMessageID != nil {
+		b.WriteString(fmt.Sprintf(" (ответ на #%d)", *entry.ReplyToMessageID))
+	}
+	if entry.Edited && !entry.Deleted {
+		b.WriteString(" (изменено)")
+	}
+	b.WriteString(": ")
+
+	if entry.Deleted {
+		b.WriteString("[сообщение удалено]")
+	} else {
+		b.WriteString(strings.ReplaceAll(entry.Content, "\n", "\n    "))
+		if len(entry.Attachments) > 0 {
+			if entry.Content != "" {
+				b.WriteString(" ")
+			}
+			b.WriteString("[вложения: " + strings.Join(entry.Attachments, ", ") + "]")
+		}
+	}
+	b.WriteString("\n")
+
+	_, err := t.w.WriteString(b.String())
+	return err
+}
+
+func (t *textTranscriptWriter) footer() error {
+	return nil
+}
+
+func transcriptSender(entry *domain.ChatTranscriptEntry) string {
+	if entry.MessageType == domain.MessageTypeSystem {
+		return "Система"
+	}
+	if entry.SenderName == "" {
+		return "Участник"
+	}
+	return entry.SenderName
+}
+
+// transcriptRecipient - адресат личного сообщения, для общих сообщений пусто
+func transcriptRecipient(entry *domain.ChatTranscriptEntry) string {
+	switch entry.RecipientType {
+	case domain.ChatRecipientParticipant:
+		if entry.RecipientName == "" {
+			return "участник"
+		}
+		return entry.RecipientName
+	case domain.ChatRecipientHosts:
+		return "ведущие"
+	}
+	return ""
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, ">", `\>`, "|", `\|`, "~", `\~`,
+)
+
+// escapeMarkdown экранирует разметку в именах, чтобы они выводились как есть
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
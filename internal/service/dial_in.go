@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// Длина PIN для входа по телефону и число попыток подобрать свободный PIN
+const (
+	dialInPINLength   = 8
+	dialInPINAttempts = 5
+)
+
+// Попытки удалить правило маршрутизации при освобождении PIN и пауза между ними
+var (
+	dialInReleaseAttempts = 3
+	dialInReleaseBackoff  = 500 * time.Millisecond
+)
+
+// DialInService управляет входом в комнату по телефону: номер и PIN комнаты и правило
+// маршрутизации звонков LiveKit SIP, которое по PIN соединяет звонящего с комнатой
+type DialInService interface {
+	Get(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.RoomDialIn, error)
+	// Enable выдает комнате PIN; повторный вызов возвращает уже выданный
+	Enable(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.RoomDialIn, error)
+	Disable(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error
+	// Release удаляет правило и PIN завершенной комнаты, без проверки прав
+	Release(ctx context.Context, room *domain.Room) error
+}
+
+type dialInService struct {
+	dialInRepo repository.DialInRepository
+	roomRepo   repository.RoomRepository
+	auditRepo  repository.AuditRepository
+	livekit    LiveKitService
+	perms      *roomPermissions
+	cfg        config.SIPConfig
+	log        logger.Logger
+}
+
+func NewDialInService(dialInRepo repository.DialInRepository, roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, livekit LiveKitService, cfg config.SIPConfig, log logger.Logger) DialInService {
+	return &dialInService{
+		dialInRepo: dialInRepo,
+		roomRepo:   roomRepo,
+		auditRepo:  auditRepo,
+		livekit:    livekit,
+		perms:      newRoomPermissions(roomRepo),
+		cfg:        cfg,
+		log:        log,
+	}
+}
+
+func (s *dialInService) Get(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.RoomDialIn, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageInvites); err != nil {
+		return nil, err
+	}
+
+	return s.dialInRepo.GetByRoom(ctx, roomID)
+}
+
+func (s *dialInService) Enable(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.RoomDialIn, error) {
+	if s.cfg.DialInNumber == "" {
+		return nil, errors.New("dial-in is not configured")
+	}
+
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageInvites); err != nil {
+		return nil, err
+	}
+
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	if existing, err := s.dialInRepo.GetByRoom(ctx, roomID); err == nil {
+		return existing, nil
+	}
+
+	dialIn, err := s.createDialIn(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.updateSettings(ctx, room, map[string]interface{}{
+		domain.RoomSettingDialInEnabled: true,
+		domain.RoomSettingDialInNumber:  dialIn.PhoneNumber,
+	}, nil); err != nil {
+		return nil, err
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeDialInEnabled,
+		Payload:     map[string]interface{}{"phone_number": dialIn.PhoneNumber},
+	})
+
+	return dialIn, nil
+}
+
+func (s *dialInService) Disable(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageInvites); err != nil {
+		return err
+	}
+
+	if err := s.Release(ctx, room); err != nil {
+		return err
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeDialInDisabled,
+	})
+
+	return nil
+}
+
+func (s *dialInService) Release(ctx context.Context, room *domain.Room) error {
+	dialIn, err := s.dialInRepo.GetByRoom(ctx, room.ID)
+	if err != nil {
+		return err
+	}
+
+	// Сначала правило: пока оно есть, звонки с этим PIN попадают в комнату. Запись в БД
+	// удаляется только после правила, иначе потерянное правило уже не найти
+	if err := s.deleteDispatchRule(ctx, dialIn.SIPDispatchRuleID); err != nil {
+		return err
+	}
+	if err := s.dialInRepo.Delete(ctx, room.ID); err != nil {
+		return errors.New("failed to disable dial-in")
+	}
+
+	return s.updateSettings(ctx, room, nil, []string{domain.RoomSettingDialInEnabled, domain.RoomSettingDialInNumber})
+}
+
+// deleteDispatchRule удаляет правило с повторами: кратковременный сбой SIP API не должен
+// оставлять завершенную комнату доступной по телефону
+func (s *dialInService) deleteDispatchRule(ctx context.Context, ruleID string) error {
+	var err error
+	for attempt := 1; attempt <= dialInReleaseAttempts; attempt++ {
+		if err = s.livekit.DeleteSIPDispatchRule(ctx, ruleID); err == nil {
+			return nil
+		}
+		s.log.Warn("Failed to delete SIP dispatch rule", "error", err, "rule_id", ruleID, "attempt", attempt)
+		if attempt == dialInReleaseAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * dialInReleaseBackoff):
+		}
+	}
+	return err
+}
+
+// createDialIn подбирает свободный PIN и создает для него правило маршрутизации.
+// PIN уникален среди всех комнат: по нему LiveKit выбирает комнату для звонка.
+func (s *dialInService) createDialIn(ctx context.Context, room *domain.Room) (*domain.RoomDialIn, error) {
+	for attempt := 0; attempt < dialInPINAttempts; attempt++ {
+		pin, err := generateDialInPIN()
+		if err != nil {
+			return nil, errors.New("failed to generate pin")
+		}
+
+		exists, err := s.dialInRepo.PinExists(ctx, pin)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			continue
+		}
+
+		ruleID, err := s.livekit.CreateSIPDispatchRule(ctx, room.LiveKitRoomName, pin, s.cfg.TrunkIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		dialIn := &domain.RoomDialIn{
+			RoomID:            room.ID,
+			PhoneNumber:       s.cfg.DialInNumber,
+			PIN:               pin,
+			SIPDispatchRuleID: ruleID,
+			CreatedAt:         time.Now(),
+		}
+		err = s.dialInRepo.Create(ctx, dialIn)
+		if err == nil {
+			return dialIn, nil
+		}
+
+		// Правило без записи в БД нельзя будет удалить через API
+		if deleteErr := s.livekit.DeleteSIPDispatchRule(ctx, ruleID); deleteErr != nil {
+			s.log.Warn("Failed to delete orphaned SIP dispatch rule", "error", deleteErr, "rule_id", ruleID)
+		}
+		if err.Error() != "dial-in pin already in use" {
+			return nil, errors.New("failed to save dial-in")
+		}
+	}
+
+	return nil, errors.New("failed to generate pin")
+}
+
+// updateSettings меняет служебные настройки входа по телефону в обход mergeRoomSettings,
+// где они доступны только для чтения. Остальные ключи settings не перезаписываются.
+func (s *dialInService) updateSettings(ctx context.Context, room *domain.Room, set map[string]interface{}, unset []string) error {
+	settings, err := s.roomRepo.UpdateSettings(ctx, room.ID, set, unset)
+	if err != nil {
+		return errors.New("failed to update room")
+	}
+	room.Settings = settings
+
+	return nil
+}
+
+func generateDialInPIN() (string, error) {
+	max := big.NewInt(10)
+	var pin strings.Builder
+	for i := 0; i < dialInPINLength; i++ {
+		digit, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		pin.WriteString(digit.String())
+	}
+	return pin.String(), nil
+}
+
+// maskCallerID оставляет от номера звонящего последние 4 цифры: "sip_+79991234567" -> "***4567"
+func maskCallerID(identity string) string {
+	var digits []rune
+	for _, r := range identity {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) > 4 {
+		digits = digits[len(digits)-4:]
+	}
+	return "***" + string(digits)
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+	"github.com/twitchtv/twirp"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+)
+
+type fakeDialInRepo struct {
+	repository.DialInRepository
+
+	dialIns map[uuid.UUID]*domain.RoomDialIn
+}
+
+func (r *fakeDialInRepo) GetByRoom(ctx context.Context, roomID uuid.UUID) (*domain.RoomDialIn, error) {
+	dialIn, ok := r.dialIns[roomID]
+	if !ok {
+		return nil, errors.New("dial-in not found")
+	}
+	return dialIn, nil
+}
+
+func (r *fakeDialInRepo) Delete(ctx context.Context, roomID uuid.UUID) error {
+	delete(r.dialIns, roomID)
+	return nil
+}
+
+func newDialInRoom() *domain.Room {
+	return &domain.Room{
+		ID:              uuid.New(),
+		LiveKitRoomName: "room-dial-in",
+		HostUserID:      uuid.New(),
+		Status:          domain.RoomStatusActive,
+		MaxParticipants: 2,
+		Settings: map[string]interface{}{
+			domain.RoomSettingDialInEnabled: true,
+			domain.RoomSettingDialInNumber:  "+70000000000",
+		},
+	}
+}
+
+// sipJoinedEvent - вебхук LiveKit о звонящем, которого правило SIP направило в комнату по PIN
+func sipJoinedEvent(room *domain.Room, identity string) *livekit.WebhookEvent {
+	return &livekit.WebhookEvent{
+		Event: webhook.EventParticipantJoined,
+		Room:  &livekit.Room{Name: room.LiveKitRoomName},
+		Participant: &livekit.ParticipantInfo{
+			Sid:      "PA_" + uuid.NewString()[:8],
+			Identity: identity,
+			Kind:     livekit.ParticipantInfo_SIP,
+		},
+	}
+}
+
+func TestPhoneParticipantAdmission(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(room *domain.Room, repo *fakeRoomRepo)
+		admit   bool
+	}{
+		{name: "open room", admit: true},
+		{
+			name:    "dial-in disabled",
+			prepare: func(room *domain.Room, repo *fakeRoomRepo) { delete(room.Settings, domain.RoomSettingDialInEnabled) },
+		},
+		{
+			name:    "locked room",
+			prepare: func(room *domain.Room, repo *fakeRoomRepo) { room.IsLocked = true },
+		},
+		{
+			name:    "ended room",
+			prepare: func(room *domain.Room, repo *fakeRoomRepo) { room.Status = domain.RoomStatusEnded },
+		},
+		{
+			name: "after scheduled end",
+			prepare: func(room *domain.Room, repo *fakeRoomRepo) {
+				endAt := time.Now().Add(-time.Minute)
+				room.ScheduledEndAt = &endAt
+			},
+		},
+		{
+			name: "full room",
+			prepare: func(room *domain.Room, repo *fakeRoomRepo) {
+				for i := 0; i < room.MaxParticipants; i++ {
+					userID := uuid.New()
+					repo.participants[uuid.New()] = &domain.RoomParticipant{ID: uuid.New(), RoomID: room.ID, UserID: &userID}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := newDialInRoom()
+			roomRepo := newFakeRoomRepo(room)
+			if tt.prepare != nil {
+				tt.prepare(room, roomRepo)
+			}
+			before := len(roomRepo.participants)
+
+			lk, lkService := newFakeLiveKit(t)
+			svc := NewLiveKitWebhookService(roomRepo, nil, nil, &fakeAuditRepo{}, lkService, nil, nil, nopLogger{})
+
+			identity := "sip_+79991234567"
+			if err := svc.HandleEvent(context.Background(), sipJoinedEvent(room, identity)); err != nil {
+				t.Fatalf("HandleEvent: %v", err)
+			}
+
+			removed := lk.rooms.removedIdentities()
+			created := len(roomRepo.participants) - before
+			if tt.admit {
+				if len(removed) != 0 || created != 1 {
+					t.Fatalf("removed = %v, created = %d, want caller admitted", removed, created)
+				}
+				for _, p := range roomRepo.participants {
+					if p.Source == domain.ParticipantSourcePhone && (p.CallerID == nil || *p.CallerID != "***4567") {
+						t.Errorf("caller id = %v, want masked number", p.CallerID)
+					}
+				}
+				return
+			}
+			if len(removed) != 1 || removed[0] != identity || created != 0 {
+				t.Errorf("removed = %v, created = %d, want caller disconnected", removed, created)
+			}
+		})
+	}
+}
+
+func TestReleaseRetriesDispatchRuleDeletion(t *testing.T) {
+	backoff := dialInReleaseBackoff
+	dialInReleaseBackoff = time.Millisecond
+	t.Cleanup(func() { dialInReleaseBackoff = backoff })
+
+	tests := []struct {
+		name       string
+		deleteErrs []error
+		wantErr    bool
+		wantCalls  int
+	}{
+		{name: "first attempt", wantCalls: 1},
+		{name: "transient failure", deleteErrs: []error{twirp.InternalError("unavailable")}, wantCalls: 2},
+		{name: "rule already gone", deleteErrs: []error{twirp.NotFoundError("rule not found")}, wantCalls: 1},
+		{
+			name:       "persistent failure",
+			deleteErrs: []error{twirp.InternalError("unavailable"), twirp.InternalError("unavailable"), twirp.InternalError("unavailable")},
+			wantErr:    true,
+			wantCalls:  dialInReleaseAttempts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := newDialInRoom()
+			dialInRepo := &fakeDialInRepo{dialIns: map[uuid.UUID]*domain.RoomDialIn{
+				room.ID: {RoomID: room.ID, PIN: "12345678", SIPDispatchRuleID: "SDR_test"},
+			}}
+			lk, lkService := newFakeLiveKit(t)
+			lk.sip.deleteErrs = tt.deleteErrs
+
+			svc := NewDialInService(dialInRepo, newFakeRoomRepo(room), &fakeAuditRepo{}, lkService, config.SIPConfig{}, nopLogger{})
+			err := svc.Release(context.Background(), room)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Release error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := lk.sip.deleteCalls(); got != tt.wantCalls {
+				t.Errorf("DeleteSIPDispatchRule calls = %d, want %d", got, tt.wantCalls)
+			}
+			// Пока правило не удалено, запись остается для следующей попытки
+			_, stillStored := dialInRepo.dialIns[room.ID]
+			if stillStored != tt.wantErr {
+				t.Errorf("dial-in stored = %v, want %v", stillStored, tt.wantErr)
+			}
+			if !tt.wantErr && room.Settings[domain.RoomSettingDialInEnabled] != nil {
+				t.Error("dial-in setting was not cleared")
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livekit/protocol/livekit"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+)
+
+// Общие заглушки для тестов сервисов. Репозитории встраивают интерфейс, поэтому
+// вызов нереализованного метода падает с паникой и сразу виден в тесте.
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+func (nopLogger) Fatal(string, ...interface{}) {}
+
+type fakeRoomRepo struct {
+	repository.RoomRepository
+
+	rooms        map[uuid.UUID]*domain.Room
+	participants map[uuid.UUID]*domain.RoomParticipant
+	// breakoutBatches - число вызовов CreateBreakouts
+	breakoutBatches int
+}
+
+func newFakeRoomRepo(rooms ...*domain.Room) *fakeRoomRepo {
+	r := &fakeRoomRepo{
+		rooms:        make(map[uuid.UUID]*domain.Room),
+		participants: make(map[uuid.UUID]*domain.RoomParticipant),
+	}
+	for _, room := range rooms {
+		r.rooms[room.ID] = room
+	}
+	return r
+}
+
+func (r *fakeRoomRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Room, error) {
+	room, ok := r.rooms[id]
+	if !ok {
+		return nil, errors.New("room not found")
+	}
+	return room, nil
+}
+
+func (r *fakeRoomRepo) GetByLiveKitRoomName(ctx context.Context, name string) (*domain.Room, error) {
+	for _, room := range r.rooms {
+		if room.LiveKitRoomName == name {
+			return room, nil
+		}
+	}
+	return nil, errors.New("room not found")
+}
+
+func (r *fakeRoomRepo) Update(ctx context.Context, room *domain.Room) error {
+	r.rooms[room.ID] = room
+	return nil
+}
+
+func (r *fakeRoomRepo) UpdateSettings(ctx context.Context, roomID uuid.UUID, set map[string]interface{}, unset []string) (map[string]interface{}, error) {
+	room, ok := r.rooms[roomID]
+	if !ok {
+		return nil, errors.New("room not found")
+	}
+	settings := make(map[string]interface{}, len(room.Settings)+len(set))
+	for key, value := range room.Settings {
+		settings[key] = value
+	}
+	for _, key := range unset {
+		delete(settings, key)
+	}
+	for key, value := range set {
+		settings[key] = value
+	}
+	room.Settings = settings
+	return settings, nil
+}
+
+func (r *fakeRoomRepo) CreateParticipant(ctx context.Context, participant *domain.RoomParticipant) error {
+	r.participants[participant.ID] = participant
+	return nil
+}
+
+func (r *fakeRoomRepo) GetParticipant(ctx context.Context, roomID, userID uuid.UUID) (*domain.RoomParticipant, error) {
+	for _, p := range r.participants {
+		if p.RoomID == roomID && p.UserID != nil && *p.UserID == userID && p.LeftAt == nil {
+			return p, nil
+		}
+	}
+	return nil, errors.New("participant not found")
+}
+
+func (r *fakeRoomRepo) GetParticipantByID(ctx context.Context, participantID uuid.UUID) (*domain.RoomParticipant, error) {
+	p, ok := r.participants[participantID]
+	if !ok {
+		return nil, errors.New("participant not found")
+	}
+	return p, nil
+}
+
+func (r *fakeRoomRepo) GetParticipantByLiveKitSID(ctx context.Context, sid string) (*domain.RoomParticipant, error) {
+	for _, p := range r.participants {
+		if p.LiveKitSID != nil && *p.LiveKitSID == sid {
+			return p, nil
+		}
+	}
+	return nil, errors.New("participant not found")
+}
+
+func (r *fakeRoomRepo) GetParticipantsByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.RoomParticipant, error) {
+	var result []*domain.RoomParticipant
+	for _, p := range r.participants {
+		if p.RoomID == roomID && p.LeftAt == nil {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeRoomRepo) UpdateParticipant(ctx context.Context, participant *domain.RoomParticipant) error {
+	r.participants[participant.ID] = participant
+	return nil
+}
+
+func (r *fakeRoomRepo) IsBanned(ctx context.Context, roomID, userID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+type fakeAuditRepo struct {
+	logs []*domain.AuditLog
+}
+
+func (r *fakeAuditRepo) CreateLog(ctx context.Context, log *domain.AuditLog) error {
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+func (r *fakeAuditRepo) eventTypes() []string {
+	var types []string
+	for _, log := range r.logs {
+		types = append(types, log.EventType)
+	}
+	return types
+}
+
+type fakeRealtime struct {
+	RealtimeService
+
+	events []string
+}
+
+func (r *fakeRealtime) Publish(ctx context.Context, roomID uuid.UUID, eventType string, payload interface{}) error {
+	r.events = append(r.events, eventType)
+	return nil
+}
+
+func (r *fakeRealtime) PublishTo(ctx context.Context, roomID uuid.UUID, participantIDs []uuid.UUID, eventType string, payload interface{}) error {
+	r.events = append(r.events, eventType)
+	return nil
+}
+
+// fakeLiveKit - Twirp сервер LiveKit на httptest: запросы проходят через настоящие
+// protobuf клиенты livekitService, а ответы задаются полями заглушек
+type fakeLiveKit struct {
+	rooms  *fakeRoomService
+	egress *fakeEgress
+	sip    *fakeSIP
+	server *httptest.Server
+}
+
+func newFakeLiveKit(t *testing.T) (*fakeLiveKit, LiveKitService) {
+	t.Helper()
+
+	f := &fakeLiveKit{
+		rooms:  &fakeRoomService{},
+		egress: &fakeEgress{},
+		sip:    &fakeSIP{},
+	}
+
+	mux := http.NewServeMux()
+	for _, srv := range []livekit.TwirpServer{
+		livekit.NewRoomServiceServer(f.rooms),
+		livekit.NewEgressServer(f.egress),
+		livekit.NewSIPServer(f.sip),
+	} {
+		mux.Handle(srv.PathPrefix(), srv)
+	}
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+
+	svc := NewLiveKitService(config.LiveKitConfig{
+		APIURL:    f.server.URL,
+		APIKey:    "test-key",
+		APISecret: "test-secret-test-secret-test-secret",
+	}, nopLogger{})
+
+	return f, svc
+}
+
+type fakeRoomService struct {
+	livekit.RoomService
+
+	mu        sync.Mutex
+	removeErr error
+	removed   []string
+}
+
+func (f *fakeRoomService) RemoveParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.RemoveParticipantResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.removed = append(f.removed, req.Identity)
+	if f.removeErr != nil {
+		return nil, f.removeErr
+	}
+	return &livekit.RemoveParticipantResponse{}, nil
+}
+
+func (f *fakeRoomService) removedIdentities() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.removed...)
+}
+
+type fakeEgress struct {
+	livekit.Egress
+
+	mu       sync.Mutex
+	startErr error
+	// onStart вызывается до ответа на запуск, как если бы webhook egress_started
+	// пришел раньше, чем API вернул EgressInfo
+	onStart func(info *livekit.EgressInfo)
+	stopped []string
+}
+
+func (f *fakeEgress) StartRoomCompositeEgress(ctx context.Context, req *livekit.RoomCompositeEgressRequest) (*livekit.EgressInfo, error) {
+	return f.start(&livekit.EgressInfo{
+		RoomName: req.RoomName,
+		Request:  &livekit.EgressInfo_RoomComposite{RoomComposite: req},
+	})
+}
+
+func (f *fakeEgress) StartTrackEgress(ctx context.Context, req *livekit.TrackEgressRequest) (*livekit.EgressInfo, error) {
+	return f.start(&livekit.EgressInfo{
+		RoomName: req.RoomName,
+		Request:  &livekit.EgressInfo_Track{Track: req},
+	})
+}
+
+func (f *fakeEgress) start(info *livekit.EgressInfo) (*livekit.EgressInfo, error) {
+	f.mu.Lock()
+	startErr, onStart := f.startErr, f.onStart
+	f.mu.Unlock()
+
+	if startErr != nil {
+		return nil, startErr
+	}
+
+	info.EgressId = "EG_" + uuid.NewString()[:8]
+	info.Status = livekit.EgressStatus_EGRESS_STARTING
+	if onStart != nil {
+		onStart(info)
+	}
+	return info, nil
+}
+
+func (f *fakeEgress) StopEgress(ctx context.Context, req *livekit.StopEgressRequest) (*livekit.EgressInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stopped = append(f.stopped, req.EgressId)
+	return &livekit.EgressInfo{EgressId: req.EgressId, Status: livekit.EgressStatus_EGRESS_ENDING}, nil
+}
+
+type fakeSIP struct {
+	livekit.SIP
+
+	mu sync.Mutex
+	// deleteErrs - ответы на очередные вызовы DeleteSIPDispatchRule, дальше - успех
+	deleteErrs []error
+	deleted    []string
+}
+
+func (f *fakeSIP) DeleteSIPDispatchRule(ctx context.Context, req *livekit.DeleteSIPDispatchRuleRequest) (*livekit.SIPDispatchRuleInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deleted = append(f.deleted, req.SipDispatchRuleId)
+	if len(f.deleteErrs) > 0 {
+		err := f.deleteErrs[0]
+		f.deleteErrs = f.deleteErrs[1:]
+		return nil, err
+	}
+	return &livekit.SIPDispatchRuleInfo{SipDispatchRuleId: req.SipDispatchRuleId}, nil
+}
+
+func (f *fakeSIP) deleteCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.deleted)
+}
+
+func (r *fakeRoomRepo) CreateBreakouts(ctx context.Context, rooms []*domain.Room) error {
+	r.breakoutBatches++
+	for _, room := range rooms {
+		r.rooms[room.ID] = room
+	}
+	return nil
+}
+
+func (r *fakeRoomRepo) ListBreakouts(ctx context.Context, parentRoomID uuid.UUID) ([]*domain.Room, error) {
+	var result []*domain.Room
+	for _, room := range r.rooms {
+		if room.ParentRoomID != nil && *room.ParentRoomID == parentRoomID && room.Status != domain.RoomStatusEnded {
+			result = append(result, room)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result, nil
+}
+
+// roomFixture - активная комната хоста с одним участником и заглушками вокруг нее.
+// Фикстуры отдельных сервисов встраивают ее и добавляют только свой сервис и репозитории.
+type roomFixture struct {
+	room        *domain.Room
+	participant *domain.RoomParticipant
+	roomRepo    *fakeRoomRepo
+	audit       *fakeAuditRepo
+	realtime    *fakeRealtime
+	livekit     *fakeLiveKit
+	lkService   LiveKitService
+}
+
+func newRoomFixture(t *testing.T) *roomFixture {
+	t.Helper()
+
+	room := &domain.Room{
+		ID:              uuid.New(),
+		HostUserID:      uuid.New(),
+		Status:          domain.RoomStatusActive,
+		MaxParticipants: 10,
+		Settings:        map[string]interface{}{},
+	}
+	room.LiveKitRoomName = "room-" + room.ID.String()
+	userID := uuid.New()
+	participant := &domain.RoomParticipant{
+		ID:          uuid.New(),
+		RoomID:      room.ID,
+		UserID:      &userID,
+		Role:        domain.ParticipantRoleParticipant,
+		DisplayName: "Anna",
+		JoinedAt:    time.Now(),
+	}
+
+	roomRepo := newFakeRoomRepo(room)
+	roomRepo.participants[participant.ID] = participant
+	lk, lkService := newFakeLiveKit(t)
+
+	return &roomFixture{
+		room:        room,
+		participant: participant,
+		roomRepo:    roomRepo,
+		audit:       &fakeAuditRepo{},
+		realtime:    &fakeRealtime{},
+		livekit:     lk,
+		lkService:   lkService,
+	}
+}
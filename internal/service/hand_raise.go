@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livekit/protocol/livekit"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// HandRaiseService - очередь поднятых рук комнаты. Участники поднимают и опускают свою руку,
+// ведущие опускают чужие руки и вызывают следующего выступающего по порядку очереди.
+type HandRaiseService interface {
+	// Queue возвращает текущую очередь, например после переподключения клиента
+	Queue(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.RaisedHand, error)
+	Raise(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.RaisedHand, error)
+	Lower(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error
+	// LowerParticipant опускает руку участника; чужую руку может опустить только ведущий
+	LowerParticipant(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID) error
+	LowerAll(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error
+	// NextSpeaker снимает с очереди первого участника; grantPublish разрешает ему публиковать
+	// камеру и микрофон до конца текущего подключения
+	NextSpeaker(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, grantPublish bool) (*domain.RaisedHand, error)
+}
+
+type handRaiseService struct {
+	handRepo repository.HandRaiseRepository
+	roomRepo repository.RoomRepository
+	realtime RealtimeService
+	livekit  LiveKitService
+	perms    *roomPermissions
+	log      logger.Logger
+}
+
+func NewHandRaiseService(handRepo repository.HandRaiseRepository, roomRepo repository.RoomRepository, realtime RealtimeService, livekit LiveKitService, log logger.Logger) HandRaiseService {
+	return &handRaiseService{
+		handRepo: handRepo,
+		roomRepo: roomRepo,
+		realtime: realtime,
+		livekit:  livekit,
+		perms:    newRoomPermissions(roomRepo),
+		log:      log,
+	}
+}
+
+func (s *handRaiseService) Queue(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.RaisedHand, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.perms.Role(ctx, room, userID) == "" {
+		return nil, errors.New("not a room participant")
+	}
+
+	return s.activeQueue(ctx, roomID)
+}
+
+func (s *handRaiseService) Raise(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.RaisedHand, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	participant, err := s.roomRepo.GetParticipant(ctx, roomID, userID)
+	if err != nil || participant.IsKicked {
+		return nil, errors.New("not a room participant")
+	}
+
+	added, err := s.handRepo.Raise(ctx, roomID, &domain.RaisedHand{
+		ParticipantID: participant.ID,
+		UserID:        participant.UserID,
+		DisplayName:   participant.DisplayName,
+		RaisedAt:      time.Now(),
+	})
+	if err != nil {
+		return nil, errors.New("failed to raise hand")
+	}
+
+	queue, err := s.activeQueue(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Повторно поднятая рука очередь не меняет
+	if added {
+		s.publish(ctx, roomID, &domain.HandQueuePayload{
+			Action:        domain.HandActionRaised,
+			ParticipantID: &participant.ID,
+			Queue:         queue,
+		})
+	}
+
+	return queue, nil
+}
+
+func (s *handRaiseService) Lower(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error {
+	participant, err := s.roomRepo.GetParticipant(ctx, roomID, userID)
+	if err != nil {
+		return errors.New("not a room participant")
+	}
+
+	return s.lower(ctx, roomID, participant.ID)
+}
+
+func (s *handRaiseService) LowerParticipant(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID) error {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	participant, err := s.roomRepo.GetParticipantByID(ctx, participantID)
+	if err != nil || participant.RoomID != roomID {
+		return errors.New("participant not found")
+	}
+
+	isOwn := participant.UserID != nil && *participant.UserID == userID
+	if !isOwn {
+		if err := s.perms.Authorize(ctx, room, userID, ActionModerate); err != nil {
+			return err
+		}
+	}
+
+	return s.lower(ctx, roomID, participant.ID)
+}
+
+func (s *handRaiseService) LowerAll(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionModerate); err != nil {
+		return err
+	}
+
+	if err := s.handRepo.Clear(ctx, roomID); err != nil {
+		return errors.New("failed to lower hands")
+	}
+
+	s.publish(ctx, roomID, &domain.HandQueuePayload{
+		Action: domain.HandActionLoweredAll,
+		Queue:  []*domain.RaisedHand{},
+	})
+
+	return nil
+}
+
+func (s *handRaiseService) NextSpeaker(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, grantPublish bool) (*domain.RaisedHand, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionModerate); err != nil {
+		return nil, err
+	}
+
+	// Первым может оказаться уже вышедший участник - пропускаем таких
+	var speaker *domain.RaisedHand
+	var participant *domain.RoomParticipant
+	for speaker == nil {
+		hand, err := s.handRepo.PopNext(ctx, roomID)
+		if err != nil {
+			return nil, errors.New("failed to update hand queue")
+		}
+		if hand == nil {
+			return nil, errors.New("hand queue is empty")
+		}
+
+		participant, err = s.roomRepo.GetParticipantByID(ctx, hand.ParticipantID)
+		if err != nil || participant.LeftAt != nil || participant.IsKicked {
+			continue
+		}
+		speaker = hand
+	}
+
+	if grantPublish && participant.UserID != nil {
+		if err := s.livekit.UpdateParticipantPermission(ctx, room.LiveKitRoomName, participant.UserID.String(), speakerPermission(room, participant.Role)); err != nil {
+			// Время поднятия руки - ее место в очереди, участник возвращается туда же
+			if _, raiseErr := s.handRepo.Raise(ctx, roomID, speaker); raiseErr != nil {
+				s.log.Warn("Failed to return hand to queue", "error", raiseErr, "room_id", roomID)
+			}
+			return nil, err
+		}
+	}
+
+	queue, err := s.activeQueue(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, roomID, &domain.HandQueuePayload{
+		Action:        domain.HandActionNextSpeaker,
+		ParticipantID: &speaker.ParticipantID,
+		Queue:         queue,
+		Speaker:       speaker,
+		CanPublish:    grantPublish && participant.UserID != nil,
+	})
+
+	return speaker, nil
+}
+
+func (s *handRaiseService) lower(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID) error {
+	removed, err := s.handRepo.Lower(ctx, roomID, participantID)
+	if err != nil {
+		return errors.New("failed to lower hand")
+	}
+	if !removed {
+		return errors.New("hand is not raised")
+	}
+
+	queue, err := s.activeQueue(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, roomID, &domain.HandQueuePayload{
+		Action:        domain.HandActionLowered,
+		ParticipantID: &participantID,
+		Queue:         queue,
+	})
+
+	return nil
+}
+
+// activeQueue возвращает очередь без участников, которые уже вышли из комнаты,
+// и заодно убирает их руки из Redis
+func (s *handRaiseService) activeQueue(ctx context.Context, roomID uuid.UUID) ([]*domain.RaisedHand, error) {
+	hands, err := s.handRepo.List(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("failed to get hand queue")
+	}
+	if len(hands) == 0 {
+		return hands, nil
+	}
+
+	participants, err := s.roomRepo.GetParticipantsByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[uuid.UUID]bool, len(participants))
+	for _, participant := range participants {
+		if !participant.IsKicked {
+			active[participant.ID] = true
+		}
+	}
+
+	queue := make([]*domain.RaisedHand, 0, len(hands))
+	for _, hand := range hands {
+		if active[hand.ParticipantID] {
+			queue = append(queue, hand)
+			continue
+		}
+		if _, err := s.handRepo.Lower(ctx, roomID, hand.ParticipantID); err != nil {
+			s.log.Warn("Failed to drop stale hand", "error", err, "room_id", roomID)
+		}
+	}
+
+	return queue, nil
+}
+
+func (s *handRaiseService) publish(ctx context.Context, roomID uuid.UUID, payload *domain.HandQueuePayload) {
+	if err := s.realtime.Publish(ctx, roomID, domain.RoomEventTypeHand, payload); err != nil {
+		s.log.Warn("Failed to publish hand queue event", "error", err, "room_id", roomID, "action", payload.Action)
+	}
+}
+
+// speakerPermission - права выступающего: к обычным правам роли добавляются камера и микрофон,
+// в том числе для зрителя вебинара
+func speakerPermission(room *domain.Room, role string) *livekit.ParticipantPermission {
+	sources := publishSources(room, role)
+	for _, source := range []livekit.TrackSource{livekit.TrackSource_CAMERA, livekit.TrackSource_MICROPHONE} {
+		if !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+
+	permission := &livekit.ParticipantPermission{
+		CanSubscribe:   true,
+		CanPublish:     true,
+		CanPublishData: true,
+	}
+	if len(sources) < len(allPublishSources) {
+		permission.CanPublishSources = sources
+	}
+
+	return permission
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livekit/protocol/livekit"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/encryption"
+	"video_conference/pkg/logger"
+)
+
+// StartStreamParams - куда транслировать: RTMP сервер с ключом или HLS в хранилище Egress
+type StartStreamParams struct {
+	Protocol  string
+	RTMPURL   string
+	StreamKey string
+}
+
+// LiveStreamService управляет трансляциями комнаты (RTMP и HLS) через LiveKit Egress.
+// Ключ RTMP трансляции хранится зашифрованным и не возвращается в API.
+type LiveStreamService interface {
+	Start(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params StartStreamParams) (*domain.LiveStream, error)
+	Stop(ctx context.Context, roomID uuid.UUID, streamID uuid.UUID, userID uuid.UUID) (*domain.LiveStream, error)
+	List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.LiveStream, error)
+	// HandleEgressUpdate применяет состояние Egress из webhook-а к трансляции
+	HandleEgressUpdate(ctx context.Context, info *livekit.EgressInfo) error
+}
+
+type liveStreamService struct {
+	streamRepo repository.LiveStreamRepository
+	roomRepo   repository.RoomRepository
+	auditRepo  repository.AuditRepository
+	livekit    LiveKitService
+	encryptor  *encryption.Encryptor
+	perms      *roomPermissions
+	streamDir  string
+	log        logger.Logger
+}
+
+func NewLiveStreamService(streamRepo repository.LiveStreamRepository, roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, livekit LiveKitService, cfg config.LiveKitConfig, log logger.Logger) LiveStreamService {
+	encryptor, err := encryption.New(cfg.StreamKeySecret)
+	if err != nil {
+		log.Error("Stream key encryption is not configured", "error", err)
+	}
+
+	return &liveStreamService{
+		streamRepo: streamRepo,
+		roomRepo:   roomRepo,
+		auditRepo:  auditRepo,
+		livekit:    livekit,
+		encryptor:  encryptor,
+		perms:      newRoomPermissions(roomRepo),
+		streamDir:  cfg.StreamDir,
+		log:        log,
+	}
+}
+
+func (s *liveStreamService) Start(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params StartStreamParams) (*domain.LiveStream, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageStreams); err != nil {
+		return nil, err
+	}
+
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	params.Protocol = strings.ToLower(params.Protocol)
+	params.RTMPURL = strings.TrimSuffix(strings.TrimSpace(params.RTMPURL), "/")
+	params.StreamKey = strings.TrimSpace(params.StreamKey)
+	if err := validateStreamParams(params); err != nil {
+		return nil, err
+	}
+
+	// HLS ведется в один плейлист, на каждый RTMP сервер допускается одна трансляция
+	streams, err := s.streamRepo.ListByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range streams {
+		if existing.IsFinished() || existing.Protocol != params.Protocol {
+			continue
+		}
+		if params.Protocol == domain.StreamProtocolHLS || (existing.RTMPURL != nil && *existing.RTMPURL == params.RTMPURL) {
+			return nil, errors.New("stream already in progress")
+		}
+	}
+
+	// Строка создается до запуска Egress: webhook egress_started может прийти раньше ответа API
+	// и находит трансляцию через GetPending
+	now := time.Now()
+	stream := &domain.LiveStream{
+		ID:              uuid.New(),
+		RoomID:          roomID,
+		Protocol:        params.Protocol,
+		Status:          domain.LiveStreamStatusStarting,
+		StartedByUserID: &userID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if params.Protocol == domain.StreamProtocolRTMP {
+		if s.encryptor == nil {
+			return nil, errors.New("stream key encryption is not configured")
+		}
+		encrypted, err := s.encryptor.Encrypt(params.StreamKey)
+		if err != nil {
+			return nil, errors.New("failed to encrypt stream key")
+		}
+		stream.RTMPURL = &params.RTMPURL
+		stream.StreamKeyEncrypted = &encrypted
+	}
+
+	if err := s.streamRepo.Create(ctx, stream); err != nil {
+		return nil, errors.New("failed to save stream")
+	}
+
+	var info *livekit.EgressInfo
+	if params.Protocol == domain.StreamProtocolRTMP {
+		info, err = s.livekit.StartRTMPStream(ctx, room.LiveKitRoomName, params.RTMPURL+"/"+params.StreamKey)
+	} else {
+		info, err = s.livekit.StartHLSStream(ctx, room.LiveKitRoomName, s.streamDir+"/"+roomID.String()+"/"+stream.ID.String()+"/segment")
+	}
+	if err != nil {
+		message := err.Error()
+		stream.Status = domain.LiveStreamStatusFailed
+		stream.Error = &message
+		stream.UpdatedAt = time.Now()
+		if updateErr := s.streamRepo.Update(ctx, stream); updateErr != nil {
+			s.log.Warn("Failed to mark stream as failed", "error", updateErr, "stream_id", stream.ID)
+		}
+		return nil, err
+	}
+
+	if err := s.streamRepo.AttachEgress(ctx, stream.ID, info.EgressId); err != nil {
+		// Egress уже запущен - без записи в БД его нельзя будет остановить через API
+		if _, stopErr := s.livekit.StopEgress(ctx, info.EgressId); stopErr != nil {
+			s.log.Warn("Failed to stop orphaned egress", "error", stopErr, "egress_id", info.EgressId)
+		}
+		return nil, errors.New("failed to save stream")
+	}
+	stream.EgressID = info.EgressId
+
+	// Состояние могли уже обновить webhook-и Egress
+	if current, err := s.streamRepo.GetByID(ctx, stream.ID); err == nil {
+		stream = current
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   now,
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeStreamStarted,
+		Payload:     streamAuditPayload(stream),
+	})
+
+	return stream, nil
+}
+
+func (s *liveStreamService) Stop(ctx context.Context, roomID uuid.UUID, streamID uuid.UUID, userID uuid.UUID) (*domain.LiveStream, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageStreams); err != nil {
+		return nil, err
+	}
+
+	stream, err := s.streamRepo.GetByID(ctx, streamID)
+	if err != nil {
+		return nil, err
+	}
+	if stream.RoomID != roomID {
+		return nil, errors.New("stream not found")
+	}
+	if stream.IsFinished() || stream.Status == domain.LiveStreamStatusEnding {
+		return nil, errors.New("stream is not active")
+	}
+
+	info, err := s.livekit.StopEgress(ctx, stream.EgressID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyStreamEgressInfo(stream, info)
+	stream.UpdatedAt = time.Now()
+	if err := s.streamRepo.Update(ctx, stream); err != nil {
+		return nil, errors.New("failed to update stream")
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeStreamStopped,
+		Payload:     streamAuditPayload(stream),
+	})
+
+	return stream, nil
+}
+
+func (s *liveStreamService) List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.LiveStream, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageStreams); err != nil {
+		return nil, err
+	}
+
+	streams, err := s.streamRepo.ListByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if streams == nil {
+		streams = []*domain.LiveStream{}
+	}
+
+	return streams, nil
+}
+
+func (s *liveStreamService) HandleEgressUpdate(ctx context.Context, info *livekit.EgressInfo) error {
+	stream, err := s.streamRepo.GetByEgressID(ctx, info.EgressId)
+	if err != nil && err.Error() == "stream not found" {
+		stream, err = s.pendingStream(ctx, info)
+	}
+	if err != nil {
+		if err.Error() == "stream not found" {
+			return nil
+		}
+		return err
+	}
+
+	if stream.IsFinished() {
+		return nil
+	}
+
+	applyStreamEgressInfo(stream, info)
+	stream.UpdatedAt = time.Now()
+
+	return s.streamRepo.Update(ctx, stream)
+}
+
+// pendingStream находит трансляцию, для которой Start еще не получил ответ Egress, и привязывает
+// к ней egress_id из webhook-а. HLS в комнате ведется одна, RTMP - одна на каждый сервер,
+// поэтому RTMP трансляция выбирается по адресу без ключа.
+func (s *liveStreamService) pendingStream(ctx context.Context, info *livekit.EgressInfo) (*domain.LiveStream, error) {
+	if info.RoomName == "" {
+		return nil, errors.New("stream not found")
+	}
+
+	room, err := s.roomRepo.GetByLiveKitRoomName(ctx, info.RoomName)
+	if err != nil {
+		return nil, errors.New("stream not found")
+	}
+
+	composite := info.GetRoomComposite()
+	var protocol string
+	var rtmpURL *string
+	switch {
+	case len(composite.GetStreamOutputs()) > 0 && len(composite.GetStreamOutputs()[0].Urls) > 0:
+		// Ключ - последний сегмент адреса, LiveKit может вернуть его замаскированным
+		url := composite.GetStreamOutputs()[0].Urls[0]
+		if i := strings.LastIndex(url, "/"); i > 0 {
+			url = url[:i]
+		}
+		protocol, rtmpURL = domain.StreamProtocolRTMP, &url
+	case len(composite.GetSegmentOutputs()) > 0:
+		protocol = domain.StreamProtocolHLS
+	default:
+		// Записи тоже используют Egress, но без потокового вывода
+		return nil, errors.New("stream not found")
+	}
+
+	stream, err := s.streamRepo.GetPending(ctx, room.ID, protocol, rtmpURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.streamRepo.AttachEgress(ctx, stream.ID, info.EgressId); err != nil {
+		return nil, err
+	}
+	stream.EgressID = info.EgressId
+
+	return stream, nil
+}
+
+func validateStreamParams(params StartStreamParams) error {
+	switch params.Protocol {
+	case domain.StreamProtocolRTMP:
+		if !strings.HasPrefix(params.RTMPURL, "rtmp://") && !strings.HasPrefix(params.RTMPURL, "rtmps://") {
+			return errors.New("rtmp_url must start with rtmp:// or rtmps://")
+		}
+		if params.StreamKey == "" {
+			return errors.New("stream_key is required")
+		}
+		if strings.ContainsAny(params.StreamKey, "/ ") {
+			return errors.New("invalid stream_key")
+		}
+	case domain.StreamProtocolHLS:
+		if params.RTMPURL != "" || params.StreamKey != "" {
+			return errors.New("rtmp_url and stream_key are not used for hls")
+		}
+	default:
+		return errors.New("protocol must be rtmp or hls")
+	}
+	return nil
+}
+
+// streamAuditPayload - данные трансляции для аудита, без ключа
+func streamAuditPayload(stream *domain.LiveStream) map[string]interface{} {
+	payload := map[string]interface{}{
+		"stream_id": stream.ID,
+		"egress_id": stream.EgressID,
+		"protocol":  stream.Protocol,
+	}
+	if stream.RTMPURL != nil {
+		payload["rtmp_url"] = *stream.RTMPURL
+	}
+	return payload
+}
+
+func applyStreamEgressInfo(stream *domain.LiveStream, info *livekit.EgressInfo) {
+	if info == nil {
+		return
+	}
+
+	stream.Status = liveStreamStatus(info.Status)
+	if startedAt := egressTime(info.StartedAt); startedAt != nil {
+		stream.StartedAt = startedAt
+	}
+	if endedAt := egressTime(info.EndedAt); endedAt != nil {
+		stream.EndedAt = endedAt
+	}
+	if info.Error != "" {
+		stream.Error = &info.Error
+	}
+
+	segments := info.GetSegments()
+	if len(info.SegmentResults) > 0 {
+		segments = info.SegmentResults[0]
+	}
+	if segments == nil {
+		return
+	}
+
+	location := segments.LivePlaylistLocation
+	if location == "" {
+		location = segments.PlaylistLocation
+	}
+	if location != "" {
+		stream.PlaylistLocation = &location
+	}
+}
+
+func liveStreamStatus(status livekit.EgressStatus) string {
+	switch status {
+	case livekit.EgressStatus_EGRESS_ACTIVE:
+		return domain.LiveStreamStatusActive
+	case livekit.EgressStatus_EGRESS_ENDING:
+		return domain.LiveStreamStatusEnding
+	case livekit.EgressStatus_EGRESS_COMPLETE, livekit.EgressStatus_EGRESS_LIMIT_REACHED:
+		return domain.LiveStreamStatusCompleted
+	case livekit.EgressStatus_EGRESS_FAILED:
+		return domain.LiveStreamStatusFailed
+	case livekit.EgressStatus_EGRESS_ABORTED:
+		return domain.LiveStreamStatusAborted
+	default:
+		return domain.LiveStreamStatusStarting
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/livekit/protocol/livekit"
+	"github.com/twitchtv/twirp"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+)
+
+type fakeLiveStreamRepo struct {
+	repository.LiveStreamRepository
+
+	mu      sync.Mutex
+	streams map[uuid.UUID]*domain.LiveStream
+}
+
+func (r *fakeLiveStreamRepo) Create(ctx context.Context, stream *domain.LiveStream) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copy := *stream
+	r.streams[stream.ID] = &copy
+	return nil
+}
+
+func (r *fakeLiveStreamRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.LiveStream, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stream, ok := r.streams[id]
+	if !ok {
+		return nil, errors.New("stream not found")
+	}
+	copy := *stream
+	return &copy, nil
+}
+
+func (r *fakeLiveStreamRepo) GetByEgressID(ctx context.Context, egressID string) (*domain.LiveStream, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stream := range r.streams {
+		if stream.EgressID == egressID {
+			copy := *stream
+			return &copy, nil
+		}
+	}
+	return nil, errors.New("stream not found")
+}
+
+func (r *fakeLiveStreamRepo) GetPending(ctx context.Context, roomID uuid.UUID, protocol string, rtmpURL *string) (*domain.LiveStream, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stream := range r.streams {
+		sameURL := rtmpURL == nil || (stream.RTMPURL != nil && *stream.RTMPURL == *rtmpURL)
+		if stream.RoomID == roomID && stream.Protocol == protocol && sameURL &&
+			stream.EgressID == "" && stream.Status == domain.LiveStreamStatusStarting {
+			copy := *stream
+			return &copy, nil
+		}
+	}
+	return nil, errors.New("stream not found")
+}
+
+func (r *fakeLiveStreamRepo) AttachEgress(ctx context.Context, streamID uuid.UUID, egressID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stream, ok := r.streams[streamID]
+	if !ok || (stream.EgressID != "" && stream.EgressID != egressID) {
+		return errors.New("stream not found")
+	}
+	stream.EgressID = egressID
+	return nil
+}
+
+func (r *fakeLiveStreamRepo) ListByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.LiveStream, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*domain.LiveStream
+	for _, stream := range r.streams {
+		if stream.RoomID == roomID {
+			copy := *stream
+			result = append(result, &copy)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeLiveStreamRepo) Update(ctx context.Context, stream *domain.LiveStream) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.streams[stream.ID]
+	if !ok {
+		return errors.New("stream not found")
+	}
+	// Как и SQL Update, egress_id не меняется
+	copy := *stream
+	copy.EgressID = stored.EgressID
+	r.streams[stream.ID] = &copy
+	return nil
+}
+
+type liveStreamFixture struct {
+	*roomFixture
+	service LiveStreamService
+	repo    *fakeLiveStreamRepo
+}
+
+func newLiveStreamFixture(t *testing.T) *liveStreamFixture {
+	t.Helper()
+
+	f := &liveStreamFixture{
+		roomFixture: newRoomFixture(t),
+		repo:        &fakeLiveStreamRepo{streams: make(map[uuid.UUID]*domain.LiveStream)},
+	}
+	cfg := config.LiveKitConfig{StreamDir: "streams", StreamKeySecret: "stream-key-secret"}
+	f.service = NewLiveStreamService(f.repo, f.roomRepo, f.audit, f.lkService, cfg, nopLogger{})
+
+	return f
+}
+
+func TestStreamEgressStartedBeforeStartReturns(t *testing.T) {
+	tests := []struct {
+		name   string
+		params StartStreamParams
+	}{
+		{name: "rtmp", params: StartStreamParams{Protocol: "rtmp", RTMPURL: "rtmp://live.example.com/app", StreamKey: "secret"}},
+		{name: "hls", params: StartStreamParams{Protocol: "hls"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLiveStreamFixture(t)
+			f.livekit.egress.onStart = func(info *livekit.EgressInfo) {
+				started := &livekit.EgressInfo{
+					EgressId:  info.EgressId,
+					RoomName:  info.RoomName,
+					Request:   info.Request,
+					Status:    livekit.EgressStatus_EGRESS_ACTIVE,
+					StartedAt: 1_700_000_000_000_000_000,
+				}
+				if err := f.service.HandleEgressUpdate(context.Background(), started); err != nil {
+					t.Errorf("HandleEgressUpdate: %v", err)
+				}
+			}
+
+			stream, err := f.service.Start(context.Background(), f.room.ID, f.room.HostUserID, tt.params)
+			if err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+
+			if stream.EgressID == "" || stream.Status != domain.LiveStreamStatusActive || stream.StartedAt == nil {
+				t.Errorf("stream egress_id = %q status = %q started_at = %v, want active from early webhook", stream.EgressID, stream.Status, stream.StartedAt)
+			}
+		})
+	}
+}
+
+func TestStartStreamEgressFailure(t *testing.T) {
+	f := newLiveStreamFixture(t)
+	f.livekit.egress.startErr = twirp.InternalError("egress unavailable")
+
+	params := StartStreamParams{Protocol: "hls"}
+	if _, err := f.service.Start(context.Background(), f.room.ID, f.room.HostUserID, params); err == nil {
+		t.Fatal("expected error when egress fails to start")
+	}
+
+	streams, _ := f.repo.ListByRoom(context.Background(), f.room.ID)
+	if len(streams) != 1 || streams[0].Status != domain.LiveStreamStatusFailed {
+		t.Fatalf("streams = %+v, want one failed stream", streams)
+	}
+
+	// Неудачный запуск не блокирует следующий
+	f.livekit.egress.startErr = nil
+	if _, err := f.service.Start(context.Background(), f.room.ID, f.room.HostUserID, params); err != nil {
+		t.Fatalf("Start after failure: %v", err)
+	}
+}
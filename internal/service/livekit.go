@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/twitchtv/twirp"
+	"video_conference/internal/config"
+	"video_conference/pkg/logger"
+)
+
+// Время жизни служебного токена для вызовов серверного API LiveKit
+const livekitAdminTokenTTL = time.Minute
+
+// Типы дорожек для принудительного отключения
+const (
+	TrackKindAudio = "audio"
+	TrackKindVideo = "video"
+	TrackKindAll   = "all"
+)
+
+// LiveKitService - обращения к серверному API LiveKit (Twirp RoomService, Egress, Ingress и SIP)
+type LiveKitService interface {
+	// RemoveParticipant отключает участника от медиасервера. Отсутствие участника в комнате не ошибка.
+	RemoveParticipant(ctx context.Context, roomName string, identity string) error
+	// MuteTracks отключает опубликованные дорожки участника и возвращает число отключенных
+	MuteTracks(ctx context.Context, roomName string, identity string, kind string) (int, error)
+	// MuteTrack отключает одну опубликованную дорожку
+	MuteTrack(ctx context.Context, roomName string, identity string, trackSID string) error
+	// UpdateParticipantPermission меняет права подключенного участника без переподключения
+	UpdateParticipantPermission(ctx context.Context, roomName string, identity string, permission *livekit.ParticipantPermission) error
+	// DeleteRoom закрывает комнату на медиасервере и отключает всех участников
+	DeleteRoom(ctx context.Context, roomName string) error
+	// StartRoomRecording запускает запись всей комнаты (room composite) в файл filepath
+	StartRoomRecording(ctx context.Context, roomName string, filepath string, audioOnly bool) (*livekit.EgressInfo, error)
+	// StartTrackRecording запускает запись одной дорожки без перекодирования
+	StartTrackRecording(ctx context.Context, roomName string, trackSID string, filepath string) (*livekit.EgressInfo, error)
+	// StartRTMPStream транслирует комнату на RTMP адрес (вместе с ключом трансляции)
+	StartRTMPStream(ctx context.Context, roomName string, url string) (*livekit.EgressInfo, error)
+	// StartHLSStream пишет комнату в HLS сегменты с живым плейлистом
+	StartHLSStream(ctx context.Context, roomName string, filenamePrefix string) (*livekit.EgressInfo, error)
+	StopEgress(ctx context.Context, egressID string) (*livekit.EgressInfo, error)
+	// CreateSIPDispatchRule направляет звонки с указанным PIN в комнату, возвращает ID правила
+	CreateSIPDispatchRule(ctx context.Context, roomName string, pin string, trunkIDs []string) (string, error)
+	// DeleteSIPDispatchRule удаляет правило. Отсутствие правила не ошибка.
+	DeleteSIPDispatchRule(ctx context.Context, ruleID string) error
+	// CreateIngress создает вход RTMP или WHIP, который публикует поток в комнату участником identity
+	CreateIngress(ctx context.Context, inputType livekit.IngressInput, roomName string, identity string, name string) (*livekit.IngressInfo, error)
+	// DeleteIngress удаляет вход и отключает его участника. Отсутствие входа не ошибка.
+	DeleteIngress(ctx context.Context, ingressID string) error
+}
+
+type livekitService struct {
+	rooms   livekit.RoomService
+	egress  livekit.Egress
+	sip     livekit.SIP
+	ingress livekit.Ingress
+	cfg     config.LiveKitConfig
+	log     logger.Logger
+}
+
+func NewLiveKitService(cfg config.LiveKitConfig, log logger.Logger) LiveKitService {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	egressURL := livekitAPIURL(cfg)
+	if cfg.EgressURL != "" {
+		egressURL = strings.TrimSuffix(cfg.EgressURL, "/")
+	}
+
+	return &livekitService{
+		rooms:   livekit.NewRoomServiceProtobufClient(livekitAPIURL(cfg), client),
+		egress:  livekit.NewEgressProtobufClient(egressURL, client),
+		sip:     livekit.NewSIPProtobufClient(livekitAPIURL(cfg), client),
+		ingress: livekit.NewIngressProtobufClient(livekitAPIURL(cfg), client),
+		cfg:     cfg,
+		log:     log,
+	}
+}
+
+func (s *livekitService) RemoveParticipant(ctx context.Context, roomName string, identity string) error {
+	ctx, err := s.withAuth(ctx, roomName)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.rooms.RemoveParticipant(ctx, &livekit.RoomParticipantIdentity{
+		Room:     roomName,
+		Identity: identity,
+	})
+	if err != nil && !isTwirpNotFound(err) {
+		s.log.Error("Failed to remove participant in LiveKit", "error", err, "room", roomName, "identity", identity)
+		return errors.New("failed to remove participant from media server")
+	}
+
+	return nil
+}
+
+func (s *livekitService) MuteTrack(ctx context.Context, roomName string, identity string, trackSID string) error {
+	ctx, err := s.withAuth(ctx, roomName)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.rooms.MutePublishedTrack(ctx, &livekit.MuteRoomTrackRequest{
+		Room:     roomName,
+		Identity: identity,
+		TrackSid: trackSID,
+		Muted:    true,
+	})
+	if err != nil && !isTwirpNotFound(err) {
+		s.log.Error("Failed to mute track in LiveKit", "error", err, "room", roomName, "track_sid", trackSID)
+		return errors.New("failed to mute participant on media server")
+	}
+
+	return nil
+}
+
+func (s *livekitService) UpdateParticipantPermission(ctx context.Context, roomName string, identity string, permission *livekit.ParticipantPermission) error {
+	ctx, err := s.withAuth(ctx, roomName)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.rooms.UpdateParticipant(ctx, &livekit.UpdateParticipantRequest{
+		Room:       roomName,
+		Identity:   identity,
+		Permission: permission,
+	})
+	if err != nil {
+		if isTwirpNotFound(err) {
+			return errors.New("participant is not connected")
+		}
+		s.log.Error("Failed to update participant permission in LiveKit", "error", err, "room", roomName, "identity", identity)
+		return errors.New("failed to update participant permissions")
+	}
+
+	return nil
+}
+
+func (s *livekitService) DeleteRoom(ctx context.Context, roomName string) error {
+	ctx, err := s.withAuth(ctx, roomName)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.rooms.DeleteRoom(ctx, &livekit.DeleteRoomRequest{Room: roomName})
+	if err != nil && !isTwirpNotFound(err) {
+		s.log.Error("Failed to delete room in LiveKit", "error", err, "room", roomName)
+		return errors.New("failed to close room on media server")
+	}
+
+	return nil
+}
+
+func (s *livekitService) StartRoomRecording(ctx context.Context, roomName string, filepath string, audioOnly bool) (*livekit.EgressInfo, error) {
+	ctx, err := s.withRecordAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fileType := livekit.EncodedFileType_MP4
+	if audioOnly {
+		fileType = livekit.EncodedFileType_OGG
+	}
+
+	info, err := s.egress.StartRoomCompositeEgress(ctx, &livekit.RoomCompositeEgressRequest{
+		RoomName:  roomName,
+		AudioOnly: audioOnly,
+		FileOutputs: []*livekit.EncodedFileOutput{{
+			FileType: fileType,
+			Filepath: filepath,
+		}},
+	})
+	if err != nil {
+		s.log.Error("Failed to start room composite egress", "error", err, "room", roomName)
+		return nil, errors.New("failed to start recording")
+	}
+
+	return info, nil
+}
+
+func (s *livekitService) StartTrackRecording(ctx context.Context, roomName string, trackSID string, filepath string) (*livekit.EgressInfo, error) {
+	ctx, err := s.withRecordAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.egress.StartTrackEgress(ctx, &livekit.TrackEgressRequest{
+		RoomName: roomName,
+		TrackId:  trackSID,
+		Output: &livekit.TrackEgressRequest_File{
+			File: &livekit.DirectFileOutput{Filepath: filepath},
+		},
+	})
+	if err != nil {
+		s.log.Error("Failed to start track egress", "error", err, "room", roomName, "track_sid", trackSID)
+		return nil, errors.New("failed to start recording")
+	}
+
+	return info, nil
+}
+
+func (s *livekitService) StartRTMPStream(ctx context.Context, roomName string, url string) (*livekit.EgressInfo, error) {
+	ctx, err := s.withRecordAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.egress.StartRoomCompositeEgress(ctx, &livekit.RoomCompositeEgressRequest{
+		RoomName: roomName,
+		StreamOutputs: []*livekit.StreamOutput{{
+			Protocol: livekit.StreamProtocol_RTMP,
+			Urls:     []string{url},
+		}},
+	})
+	if err != nil {
+		// Адрес содержит ключ трансляции и в лог не пишется
+		s.log.Error("Failed to start RTMP stream egress", "error", err, "room", roomName)
+		return nil, errors.New("failed to start stream")
+	}
+
+	return info, nil
+}
+
+func (s *livekitService) StartHLSStream(ctx context.Context, roomName string, filenamePrefix string) (*livekit.EgressInfo, error) {
+	ctx, err := s.withRecordAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.egress.StartRoomCompositeEgress(ctx, &livekit.RoomCompositeEgressRequest{
+		RoomName: roomName,
+		SegmentOutputs: []*livekit.SegmentedFileOutput{{
+			Protocol:         livekit.SegmentedFileProtocol_HLS_PROTOCOL,
+			FilenamePrefix:   filenamePrefix,
+			PlaylistName:     "playlist.m3u8",
+			LivePlaylistName: "live.m3u8",
+		}},
+	})
+	if err != nil {
+		s.log.Error("Failed to start HLS stream egress", "error", err, "room", roomName)
+		return nil, errors.New("failed to start stream")
+	}
+
+	return info, nil
+}
+
+func (s *livekitService) StopEgress(ctx context.Context, egressID string) (*livekit.EgressInfo, error) {
+	ctx, err := s.withRecordAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.egress.StopEgress(ctx, &livekit.StopEgressRequest{EgressId: egressID})
+	if err != nil {
+		s.log.Error("Failed to stop egress", "error", err, "egress_id", egressID)
+		return nil, errors.New("failed to stop egress")
+	}
+
+	return info, nil
+}
+
+func (s *livekitService) CreateSIPDispatchRule(ctx context.Context, roomName string, pin string, trunkIDs []string) (string, error) {
+	ctx, err := s.withSIPAuth(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := s.sip.CreateSIPDispatchRule(ctx, &livekit.CreateSIPDispatchRuleRequest{
+		Rule: &livekit.SIPDispatchRule{
+			Rule: &livekit.SIPDispatchRule_DispatchRuleDirect{
+				DispatchRuleDirect: &livekit.SIPDispatchRuleDirect{
+					RoomName: roomName,
+					Pin:      pin,
+				},
+			},
+		},
+		TrunkIds: trunkIDs,
+	})
+	if err != nil {
+		s.log.Error("Failed to create SIP dispatch rule", "error", err, "room", roomName)
+		return "", errors.New("failed to configure dial-in")
+	}
+
+	return info.SipDispatchRuleId, nil
+}
+
+func (s *livekitService) DeleteSIPDispatchRule(ctx context.Context, ruleID string) error {
+	ctx, err := s.withSIPAuth(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.sip.DeleteSIPDispatchRule(ctx, &livekit.DeleteSIPDispatchRuleRequest{SipDispatchRuleId: ruleID})
+	if err != nil && !isTwirpNotFound(err) {
+		s.log.Error("Failed to delete SIP dispatch rule", "error", err, "rule_id", ruleID)
+		return errors.New("failed to configure dial-in")
+	}
+
+	return nil
+}
+
+func (s *livekitService) CreateIngress(ctx context.Context, inputType livekit.IngressInput, roomName string, identity string, name string) (*livekit.IngressInfo, error) {
+	ctx, err := s.withIngressAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.ingress.CreateIngress(ctx, &livekit.CreateIngressRequest{
+		InputType:           inputType,
+		Name:                name,
+		RoomName:            roomName,
+		ParticipantIdentity: identity,
+		ParticipantName:     name,
+	})
+	if err != nil {
+		s.log.Error("Failed to create ingress", "error", err, "room", roomName)
+		return nil, errors.New("failed to create ingress")
+	}
+
+	return info, nil
+}
+
+func (s *livekitService) DeleteIngress(ctx context.Context, ingressID string) error {
+	ctx, err := s.withIngressAuth(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ingress.DeleteIngress(ctx, &livekit.DeleteIngressRequest{IngressId: ingressID})
+	if err != nil && !isTwirpNotFound(err) {
+		s.log.Error("Failed to delete ingress", "error", err, "ingress_id", ingressID)
+		return errors.New("failed to delete ingress")
+	}
+
+	return nil
+}
+
+func (s *livekitService) MuteTracks(ctx context.Context, roomName string, identity string, kind string) (int, error) {
+	ctx, err := s.withAuth(ctx, roomName)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := s.rooms.GetParticipant(ctx, &livekit.RoomParticipantIdentity{
+		Room:     roomName,
+		Identity: identity,
+	})
+	if err != nil {
+		if isTwirpNotFound(err) {
+			return 0, nil
+		}
+		s.log.Error("Failed to get participant from LiveKit", "error", err, "room", roomName, "identity", identity)
+		return 0, errors.New("failed to mute participant on media server")
+	}
+
+	muted := 0
+	for _, track := range info.Tracks {
+		if track.Muted || !matchesTrackKind(track, kind) {
+			continue
+		}
+
+		_, err := s.rooms.MutePublishedTrack(ctx, &livekit.MuteRoomTrackRequest{
+			Room:     roomName,
+			Identity: identity,
+			TrackSid: track.Sid,
+			Muted:    true,
+		})
+		if err != nil {
+			s.log.Error("Failed to mute track in LiveKit", "error", err, "room", roomName, "track_sid", track.Sid)
+			return muted, errors.New("failed to mute participant on media server")
+		}
+		muted++
+	}
+
+	return muted, nil
+}
+
+// withAuth добавляет к запросу служебный токен с правами администратора комнаты
+func (s *livekitService) withAuth(ctx context.Context, roomName string) (context.Context, error) {
+	return s.withGrant(ctx, &auth.VideoGrant{
+		RoomAdmin: true,
+		Room:      roomName,
+	})
+}
+
+// withRecordAuth добавляет к запросу служебный токен с правом управления Egress
+func (s *livekitService) withRecordAuth(ctx context.Context) (context.Context, error) {
+	return s.withGrant(ctx, &auth.VideoGrant{RoomRecord: true})
+}
+
+// withIngressAuth добавляет к запросу служебный токен для Ingress API
+func (s *livekitService) withIngressAuth(ctx context.Context) (context.Context, error) {
+	return s.withGrant(ctx, &auth.VideoGrant{IngressAdmin: true})
+}
+
+// withSIPAuth добавляет к запросу служебный токен для SIP API (правила маршрутизации звонков)
+func (s *livekitService) withSIPAuth(ctx context.Context) (context.Context, error) {
+	return s.withGrant(ctx, &auth.VideoGrant{RoomCreate: true, RoomAdmin: true})
+}
+
+func (s *livekitService) withGrant(ctx context.Context, grant *auth.VideoGrant) (context.Context, error) {
+	at := auth.NewAccessToken(s.cfg.APIKey, s.cfg.APISecret)
+	at.AddGrant(grant).SetValidFor(livekitAdminTokenTTL)
+
+	token, err := at.ToJWT()
+	if err != nil {
+		s.log.Error("Failed to generate LiveKit admin token", "error", err)
+		return nil, errors.New("failed to generate token")
+	}
+
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+token)
+
+	return twirp.WithHTTPRequestHeaders(ctx, header)
+}
+
+func matchesTrackKind(track *livekit.TrackInfo, kind string) bool {
+	switch kind {
+	case TrackKindAudio:
+		return track.Type == livekit.TrackType_AUDIO
+	case TrackKindVideo:
+		return track.Type == livekit.TrackType_VIDEO
+	default:
+		return true
+	}
+}
+
+func isTwirpNotFound(err error) bool {
+	var twerr twirp.Error
+	return errors.As(err, &twerr) && twerr.Code() == twirp.NotFound
+}
+
+// livekitAPIURL возвращает HTTP адрес серверного API: LIVEKIT_API_URL
+// или LIVEKIT_URL с заменой ws(s):// на http(s)://
+func livekitAPIURL(cfg config.LiveKitConfig) string {
+	if cfg.APIURL != "" {
+		return strings.TrimSuffix(cfg.APIURL, "/")
+	}
+
+	url := strings.TrimSuffix(cfg.URL, "/")
+	switch {
+	case strings.HasPrefix(url, "wss://"):
+		return "https://" + strings.TrimPrefix(url, "wss://")
+	case strings.HasPrefix(url, "ws://"):
+		return "http://" + strings.TrimPrefix(url, "ws://")
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		return url
+	default:
+		return "http://" + url
+	}
+}
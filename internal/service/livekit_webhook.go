@@ -0,0 +1,405 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// Причины выхода, которые выставляются по событиям LiveKit
+const (
+	leaveReasonDisconnected = "disconnected"
+	leaveReasonRoomFinished = "room_finished"
+)
+
+// LiveKitWebhookService применяет события LiveKit к состоянию комнат и участников,
+// чтобы присутствие не зависело от того, вызвал ли клиент /leave
+type LiveKitWebhookService interface {
+	HandleEvent(ctx context.Context, event *livekit.WebhookEvent) error
+}
+
+type livekitWebhookService struct {
+	roomRepo     repository.RoomRepository
+	anonRoomRepo repository.AnonymousRoomRepository
+	ingressRepo  repository.IngressRepository
+	auditRepo    repository.AuditRepository
+	livekit      LiveKitService
+	recordings   RecordingService
+	streams      LiveStreamService
+	log          logger.Logger
+}
+
+func NewLiveKitWebhookService(roomRepo repository.RoomRepository, anonRoomRepo repository.AnonymousRoomRepository, ingressRepo repository.IngressRepository, auditRepo repository.AuditRepository, livekit LiveKitService, recordings RecordingService, streams LiveStreamService, log logger.Logger) LiveKitWebhookService {
+	return &livekitWebhookService{
+		roomRepo:     roomRepo,
+		anonRoomRepo: anonRoomRepo,
+		ingressRepo:  ingressRepo,
+		auditRepo:    auditRepo,
+		livekit:      livekit,
+		recordings:   recordings,
+		streams:      streams,
+		log:          log,
+	}
+}
+
+func (s *livekitWebhookService) HandleEvent(ctx context.Context, event *livekit.WebhookEvent) error {
+	// События Egress приходят без комнаты, запись или трансляция находится по egress_id
+	switch event.Event {
+	case webhook.EventEgressStarted, webhook.EventEgressUpdated, webhook.EventEgressEnded:
+		if event.EgressInfo == nil {
+			return nil
+		}
+		// Обработчики независимы: ошибка записи не должна терять событие трансляции и наоборот
+		recordingErr := s.recordings.HandleEgressUpdate(ctx, event.EgressInfo)
+		if recordingErr != nil {
+			s.log.Warn("Failed to apply egress update to recording", "error", recordingErr, "egress_id", event.EgressInfo.EgressId)
+		}
+		streamErr := s.streams.HandleEgressUpdate(ctx, event.EgressInfo)
+		if streamErr != nil {
+			s.log.Warn("Failed to apply egress update to live stream", "error", streamErr, "egress_id", event.EgressInfo.EgressId)
+		}
+		return errors.Join(recordingErr, streamErr)
+	}
+
+	if event.Room == nil {
+		return nil
+	}
+
+	eventTime := time.Now()
+	if event.CreatedAt > 0 {
+		eventTime = time.Unix(event.CreatedAt, 0)
+	}
+
+	room, err := s.roomRepo.GetByLiveKitRoomName(ctx, event.Room.Name)
+	if err != nil {
+		// Комнаты без регистрации живут в отдельной таблице
+		return s.handleAnonymousEvent(ctx, event, eventTime)
+	}
+
+	switch event.Event {
+	case webhook.EventRoomStarted:
+		return s.roomStarted(ctx, room, eventTime)
+	case webhook.EventRoomFinished:
+		return s.roomFinished(ctx, room, eventTime)
+	case webhook.EventParticipantJoined:
+		return s.participantJoined(ctx, room, event.Participant, eventTime)
+	case webhook.EventParticipantLeft:
+		return s.participantLeft(ctx, room, event.Participant, eventTime)
+	case webhook.EventTrackPublished:
+		return s.trackPublished(ctx, room, event.Participant, event.Track)
+	}
+
+	return nil
+}
+
+func (s *livekitWebhookService) roomStarted(ctx context.Context, room *domain.Room, eventTime time.Time) error {
+	if room.Status != domain.RoomStatusScheduled && room.ActualStartAt != nil {
+		return nil
+	}
+
+	if room.Status == domain.RoomStatusScheduled {
+		room.Status = domain.RoomStatusActive
+	}
+	if room.ActualStartAt == nil {
+		room.ActualStartAt = &eventTime
+	}
+	room.UpdatedAt = time.Now()
+
+	return s.roomRepo.Update(ctx, room)
+}
+
+func (s *livekitWebhookService) roomFinished(ctx context.Context, room *domain.Room, eventTime time.Time) error {
+	closed, err := s.roomRepo.CloseOpenParticipants(ctx, room.ID, eventTime, leaveReasonRoomFinished)
+	if err != nil {
+		return err
+	}
+
+	if room.Status != domain.RoomStatusActive {
+		return nil
+	}
+
+	room.Status = domain.RoomStatusEnded
+	room.ActualEndAt = &eventTime
+	room.UpdatedAt = time.Now()
+	if err := s.roomRepo.Update(ctx, room); err != nil {
+		return err
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime: eventTime,
+		ActorRole: domain.ActorRoleSystem,
+		RoomID:    &room.ID,
+		EventType: domain.EventTypeRoomEnded,
+		Payload:   map[string]interface{}{"source": "livekit", "closed_participants": closed},
+	})
+
+	return nil
+}
+
+func (s *livekitWebhookService) participantJoined(ctx context.Context, room *domain.Room, info *livekit.ParticipantInfo, eventTime time.Time) error {
+	participant := s.findParticipant(ctx, room, info)
+	if participant == nil && info.GetKind() == livekit.ParticipantInfo_SIP {
+		return s.phoneParticipantJoined(ctx, room, info, eventTime)
+	}
+	if participant == nil && info.GetKind() == livekit.ParticipantInfo_INGRESS {
+		return s.ingressParticipantJoined(ctx, room, info, eventTime)
+	}
+	if participant == nil {
+		s.log.Warn("LiveKit participant has no active room participant", "room_id", room.ID, "identity", info.GetIdentity())
+		return nil
+	}
+
+	sid := info.Sid
+	participant.LiveKitSID = &sid
+	if info.JoinedAt > 0 {
+		participant.JoinedAt = time.Unix(info.JoinedAt, 0)
+	} else {
+		participant.JoinedAt = eventTime
+	}
+
+	return s.roomRepo.UpdateParticipant(ctx, participant)
+}
+
+// phoneParticipantJoined добавляет в комнату звонящего по телефону. Звонок проходит
+// через правило SIP с PIN комнаты, поэтому Join для него не вызывается.
+func (s *livekitWebhookService) phoneParticipantJoined(ctx context.Context, room *domain.Room, info *livekit.ParticipantInfo, eventTime time.Time) error {
+	if err := s.checkPhoneAdmission(ctx, room); err != nil {
+		s.log.Warn("SIP participant refused", "reason", err.Error(), "room_id", room.ID)
+		return s.livekit.RemoveParticipant(ctx, room.LiveKitRoomName, info.Identity)
+	}
+
+	joinedAt := eventTime
+	if info.JoinedAt > 0 {
+		joinedAt = time.Unix(info.JoinedAt, 0)
+	}
+
+	sid := info.Sid
+	callerID := maskCallerID(info.Identity)
+	participant := &domain.RoomParticipant{
+		ID:          uuid.New(),
+		RoomID:      room.ID,
+		Role:        domain.ParticipantRoleParticipant,
+		DisplayName: "Телефон " + callerID,
+		LiveKitSID:  &sid,
+		JoinedAt:    joinedAt,
+		Source:      domain.ParticipantSourcePhone,
+		CallerID:    &callerID,
+	}
+
+	return s.roomRepo.CreateParticipant(ctx, participant)
+}
+
+// checkPhoneAdmission повторяет для звонящего проверки входа через веб: звонок не должен
+// открывать закрытую, заполненную или уже завершенную комнату
+func (s *livekitWebhookService) checkPhoneAdmission(ctx context.Context, room *domain.Room) error {
+	if enabled, _ := room.Settings[domain.RoomSettingDialInEnabled].(bool); !enabled {
+		return errors.New("dial-in is disabled")
+	}
+	if room.Status != domain.RoomStatusActive && room.Status != domain.RoomStatusScheduled {
+		return errors.New("room is not available")
+	}
+	if room.IsLocked {
+		return errors.New("room is locked")
+	}
+	if err := checkJoinWindow(room, time.Now()); err != nil {
+		return err
+	}
+	return checkRoomCapacity(ctx, s.roomRepo, room)
+}
+
+// ingressParticipantJoined добавляет в комнату участника входящей трансляции. Identity
+// задается при создании входа (RoomIngress.Identity); чужие и отозванные входы отключаются.
+func (s *livekitWebhookService) ingressParticipantJoined(ctx context.Context, room *domain.Room, info *livekit.ParticipantInfo, eventTime time.Time) error {
+	var ingress *domain.RoomIngress
+	if id, err := uuid.Parse(strings.TrimPrefix(info.Identity, "ingress_")); err == nil {
+		ingress, _ = s.ingressRepo.GetByID(ctx, id)
+	}
+	if ingress == nil || ingress.RoomID != room.ID || ingress.IsRevoked() {
+		s.log.Warn("Unknown ingress participant joined room", "room_id", room.ID, "identity", info.Identity)
+		return s.livekit.RemoveParticipant(ctx, room.LiveKitRoomName, info.Identity)
+	}
+
+	joinedAt := eventTime
+	if info.JoinedAt > 0 {
+		joinedAt = time.Unix(info.JoinedAt, 0)
+	}
+
+	sid := info.Sid
+	return s.roomRepo.CreateParticipant(ctx, &domain.RoomParticipant{
+		ID:          uuid.New(),
+		RoomID:      room.ID,
+		Role:        domain.ParticipantRoleIngest,
+		DisplayName: ingress.ParticipantName,
+		LiveKitSID:  &sid,
+		JoinedAt:    joinedAt,
+		Source:      domain.ParticipantSourceIngress,
+	})
+}
+
+func (s *livekitWebhookService) participantLeft(ctx context.Context, room *domain.Room, info *livekit.ParticipantInfo, eventTime time.Time) error {
+	participant := s.findParticipant(ctx, room, info)
+	if participant == nil || participant.LeftAt != nil {
+		return nil
+	}
+
+	reason := leaveReasonDisconnected
+	participant.LeftAt = &eventTime
+	participant.LeaveReason = &reason
+
+	return s.roomRepo.UpdateParticipant(ctx, participant)
+}
+
+// trackPublished отключает дорожку, если ее источник запрещен текущими настройками комнаты
+// (например, их изменили после выдачи токена)
+func (s *livekitWebhookService) trackPublished(ctx context.Context, room *domain.Room, info *livekit.ParticipantInfo, track *livekit.TrackInfo) error {
+	if track == nil {
+		return nil
+	}
+
+	participant := s.findParticipant(ctx, room, info)
+	if participant == nil {
+		return nil
+	}
+
+	for _, source := range publishSources(room, participant.Role) {
+		if source == track.Source {
+			return nil
+		}
+	}
+
+	s.log.Info("Muting track not allowed by room settings", "room_id", room.ID, "participant_id", participant.ID, "source", track.Source.String())
+	return s.livekit.MuteTrack(ctx, room.LiveKitRoomName, info.Identity, track.Sid)
+}
+
+// findParticipant ищет участника по SID LiveKit, а до первого participant_joined - по identity
+func (s *livekitWebhookService) findParticipant(ctx context.Context, room *domain.Room, info *livekit.ParticipantInfo) *domain.RoomParticipant {
+	if info == nil {
+		return nil
+	}
+
+	if info.Sid != "" {
+		if participant, err := s.roomRepo.GetParticipantByLiveKitSID(ctx, info.Sid); err == nil && participant.RoomID == room.ID {
+			return participant
+		}
+	}
+
+	userID, err := uuid.Parse(info.Identity)
+	if err != nil {
+		return nil
+	}
+
+	participant, err := s.roomRepo.GetParticipant(ctx, room.ID, userID)
+	if err != nil {
+		return nil
+	}
+	// Участие уже привязано к другой сессии LiveKit (например, событие от старого подключения)
+	if participant.LiveKitSID != nil && *participant.LiveKitSID != info.Sid {
+		return nil
+	}
+	return participant
+}
+
+func (s *livekitWebhookService) handleAnonymousEvent(ctx context.Context, event *livekit.WebhookEvent, eventTime time.Time) error {
+	if s.anonRoomRepo == nil {
+		return nil
+	}
+
+	room, err := s.anonRoomRepo.GetByLiveKitRoomName(ctx, event.Room.Name)
+	if err != nil {
+		s.log.Warn("LiveKit webhook for unknown room", "room", event.Room.Name, "event", event.Event)
+		return nil
+	}
+
+	switch event.Event {
+	case webhook.EventParticipantJoined:
+		participant := s.findAnonymousParticipant(ctx, room.ID, event.Participant)
+		if participant == nil {
+			return nil
+		}
+		sid := event.Participant.Sid
+		participant.LiveKitSID = &sid
+		return s.anonRoomRepo.UpdateParticipant(ctx, participant)
+
+	case webhook.EventParticipantLeft:
+		participant := s.findAnonymousParticipant(ctx, room.ID, event.Participant)
+		if participant == nil {
+			return nil
+		}
+		participant.LeftAt = &eventTime
+		if err := s.anonRoomRepo.UpdateParticipant(ctx, participant); err != nil {
+			return err
+		}
+		return s.endEmptyAnonymousRoom(ctx, room.ID)
+
+	case webhook.EventRoomFinished:
+		participants, err := s.anonRoomRepo.GetParticipantsByRoom(ctx, room.ID)
+		if err != nil {
+			return err
+		}
+		for _, participant := range participants {
+			if participant.LeftAt != nil {
+				continue
+			}
+			participant.LeftAt = &eventTime
+			if err := s.anonRoomRepo.UpdateParticipant(ctx, participant); err != nil {
+				return err
+			}
+		}
+		return s.anonRoomRepo.SetRoomStatus(ctx, room.ID, domain.RoomStatusEnded)
+	}
+
+	return nil
+}
+
+// endEmptyAnonymousRoom повторяет поведение AnonymousRoomService.Leave: пустая комната завершается
+func (s *livekitWebhookService) endEmptyAnonymousRoom(ctx context.Context, roomID uuid.UUID) error {
+	count, err := s.anonRoomRepo.GetActiveParticipantCount(ctx, roomID)
+	if err != nil || count > 0 {
+		return nil
+	}
+	return s.anonRoomRepo.SetRoomStatus(ctx, roomID, domain.RoomStatusEnded)
+}
+
+// findAnonymousParticipant сопоставляет identity с участником. Identity равна participant_id,
+// а для не-UUID participant_id - производному UUID (см. anonymousIdentity).
+func (s *livekitWebhookService) findAnonymousParticipant(ctx context.Context, roomID uuid.UUID, info *livekit.ParticipantInfo) *domain.AnonymousParticipant {
+	if info == nil {
+		return nil
+	}
+
+	if participant, err := s.anonRoomRepo.GetParticipant(ctx, roomID, info.Identity); err == nil {
+		return participant
+	}
+
+	participants, err := s.anonRoomRepo.GetParticipantsByRoom(ctx, roomID)
+	if err != nil {
+		return nil
+	}
+	for _, participant := range participants {
+		if participant.LeftAt != nil {
+			continue
+		}
+		if anonymousIdentity(participant.ParticipantID) == info.Identity {
+			return participant
+		}
+	}
+
+	return nil
+}
+
+// anonymousIdentity - identity в LiveKit для participant_id анонимного участника.
+// Используем participant_id, а если это не UUID - производный от него UUID для consistency.
+func anonymousIdentity(participantID string) string {
+	if _, err := uuid.Parse(participantID); err == nil {
+		return participantID
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(participantID)).String()
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+)
+
+type fakeEgressHandler struct {
+	err     error
+	handled []string
+}
+
+func (h *fakeEgressHandler) HandleEgressUpdate(ctx context.Context, info *livekit.EgressInfo) error {
+	h.handled = append(h.handled, info.EgressId)
+	return h.err
+}
+
+type fakeRecordingHandler struct {
+	RecordingService
+	fakeEgressHandler
+}
+
+func (h *fakeRecordingHandler) HandleEgressUpdate(ctx context.Context, info *livekit.EgressInfo) error {
+	return h.fakeEgressHandler.HandleEgressUpdate(ctx, info)
+}
+
+type fakeStreamHandler struct {
+	LiveStreamService
+	fakeEgressHandler
+}
+
+func (h *fakeStreamHandler) HandleEgressUpdate(ctx context.Context, info *livekit.EgressInfo) error {
+	return h.fakeEgressHandler.HandleEgressUpdate(ctx, info)
+}
+
+func TestEgressEventReachesEveryHandler(t *testing.T) {
+	recordingErr := errors.New("recording update failed")
+	streamErr := errors.New("stream update failed")
+
+	tests := []struct {
+		name         string
+		recordingErr error
+		streamErr    error
+	}{
+		{name: "both succeed"},
+		{name: "recording fails", recordingErr: recordingErr},
+		{name: "stream fails", streamErr: streamErr},
+		{name: "both fail", recordingErr: recordingErr, streamErr: streamErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recordings := &fakeRecordingHandler{fakeEgressHandler: fakeEgressHandler{err: tt.recordingErr}}
+			streams := &fakeStreamHandler{fakeEgressHandler: fakeEgressHandler{err: tt.streamErr}}
+			svc := NewLiveKitWebhookService(newFakeRoomRepo(), nil, nil, &fakeAuditRepo{}, nil, recordings, streams, nopLogger{})
+
+			err := svc.HandleEvent(context.Background(), &livekit.WebhookEvent{
+				Event:      webhook.EventEgressStarted,
+				EgressInfo: &livekit.EgressInfo{EgressId: "EG_test"},
+			})
+
+			if len(recordings.handled) != 1 || len(streams.handled) != 1 {
+				t.Errorf("handled by recordings %v and streams %v, want both", recordings.handled, streams.handled)
+			}
+			for _, want := range []error{tt.recordingErr, tt.streamErr} {
+				if want != nil && !errors.Is(err, want) {
+					t.Errorf("error = %v, want it to include %v", err, want)
+				}
+			}
+			if tt.recordingErr == nil && tt.streamErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
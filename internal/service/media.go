@@ -7,51 +7,115 @@ import (
 	"time"
 
 	"video_conference/internal/config"
+	"video_conference/internal/domain"
 	"video_conference/internal/repository"
+	"video_conference/pkg/encryption"
 	"video_conference/pkg/logger"
 
 	"github.com/google/uuid"
 	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
 )
 
+// CreateIngressParams - тип входа (rtmp или whip) и имя ведущего в комнате
+type CreateIngressParams struct {
+	InputType string
+	Name      string
+}
+
+// MediaService выдает доступ к медиасерверу: токены участникам и входы LiveKit Ingress
+// для внешних ведущих (OBS и т.п.), которые публикуют поток в комнату без браузера
 type MediaService interface {
 	GetToken(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, displayName string) (string, string, error)
+	// GetBreakoutToken выдает токен сессионного зала, в который распределен участник основной
+	// комнаты; ведущие могут указать любой открытый зал. Возвращает и ID зала.
+	GetBreakoutToken(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, displayName string, breakoutRoomID *uuid.UUID) (string, string, uuid.UUID, error)
+	// CreateIngress создает вход и возвращает адрес и ключ потока для кодировщика
+	CreateIngress(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params CreateIngressParams) (*domain.RoomIngress, error)
+	ListIngresses(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.RoomIngress, error)
+	// RevokeIngress удаляет вход в LiveKit; подключенный кодировщик отключается
+	RevokeIngress(ctx context.Context, roomID uuid.UUID, ingressID uuid.UUID, userID uuid.UUID) error
+	// RevokeRoomIngresses отзывает все входы завершенной комнаты, без проверки прав
+	RevokeRoomIngresses(ctx context.Context, room *domain.Room) error
 }
 
+const (
+	// TTL токена для комнат без запланированного окончания
+	defaultTokenTTL = time.Hour
+	// Минимальный TTL, чтобы токен успел дойти до клиента перед самым концом встречи
+	minTokenTTL = 5 * time.Minute
+)
+
+// Имя участника ingress, если хост его не указал
+const defaultIngressName = "Внешний ведущий"
+
 type mediaService struct {
-	roomRepo repository.RoomRepository
-	cfg      config.LiveKitConfig
-	log      logger.Logger
+	roomRepo     repository.RoomRepository
+	ingressRepo  repository.IngressRepository
+	breakoutRepo repository.BreakoutRepository
+	auditRepo    repository.AuditRepository
+	livekit      LiveKitService
+	encryptor    *encryption.Encryptor
+	perms        *roomPermissions
+	cfg          config.LiveKitConfig
+	log          logger.Logger
 }
 
-func NewMediaService(roomRepo repository.RoomRepository, cfg config.LiveKitConfig, log logger.Logger) MediaService {
+func NewMediaService(roomRepo repository.RoomRepository, ingressRepo repository.IngressRepository, breakoutRepo repository.BreakoutRepository, auditRepo repository.AuditRepository, livekit LiveKitService, cfg config.LiveKitConfig, log logger.Logger) MediaService {
+	// Ключи потоков ingress шифруются тем же секретом, что и ключи трансляций
+	encryptor, err := encryption.New(cfg.StreamKeySecret)
+	if err != nil {
+		log.Error("Ingress stream key encryption is not configured", "error", err)
+	}
+
 	return &mediaService{
-		roomRepo: roomRepo,
-		cfg:      cfg,
-		log:      log,
+		roomRepo:     roomRepo,
+		ingressRepo:  ingressRepo,
+		breakoutRepo: breakoutRepo,
+		auditRepo:    auditRepo,
+		livekit:      livekit,
+		encryptor:    encryptor,
+		perms:        newRoomPermissions(roomRepo),
+		cfg:          cfg,
+		log:          log,
 	}
 }
 
+// GetToken выдает LiveKit токен активному участнику комнаты. Права зависят от его роли
+// и настроек комнаты. Запись участника создают только Join, RedeemInvite и одобрение
+// в waiting room, поэтому пароль, блокировка, окно встречи и waiting room уже пройдены.
 func (s *mediaService) GetToken(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, displayName string) (string, string, error) {
 	room, err := s.roomRepo.GetByID(ctx, roomID)
 	if err != nil {
 		return "", "", errors.New("room not found")
 	}
 
-	at := auth.NewAccessToken(s.cfg.APIKey, s.cfg.APISecret)
-	canPublish := true
-	canSubscribe := true
-	grant := &auth.VideoGrant{
-		RoomJoin:     true,
-		Room:         room.LiveKitRoomName,
-		CanPublish:   &canPublish,
-		CanSubscribe: &canSubscribe,
+	if room.Status != domain.RoomStatusActive && room.Status != domain.RoomStatusScheduled {
+		return "", "", errors.New("room is not available")
 	}
 
-	at.AddGrant(grant).
+	banned, err := s.roomRepo.IsBanned(ctx, roomID, userID)
+	if err != nil {
+		return "", "", errors.New("failed to check participant ban")
+	}
+	if banned {
+		return "", "", errors.New("you are banned from this room")
+	}
+
+	participant, err := s.roomRepo.GetParticipant(ctx, roomID, userID)
+	if err != nil || participant.IsKicked {
+		return "", "", errors.New("not a room participant")
+	}
+
+	if displayName == "" {
+		displayName = participant.DisplayName
+	}
+
+	at := auth.NewAccessToken(s.cfg.APIKey, s.cfg.APISecret)
+	at.AddGrant(s.grantFor(room, participant)).
 		SetIdentity(userID.String()).
 		SetName(displayName).
-		SetValidFor(time.Hour)
+		SetValidFor(s.tokenTTL(room))
 
 	token, err := at.ToJWT()
 	if err != nil {
@@ -101,3 +165,321 @@ func (s *mediaService) GetToken(ctx context.Context, roomID uuid.UUID, userID uu
 
 	return token, url, nil
 }
+
+func (s *mediaService) GetBreakoutToken(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, displayName string, breakoutRoomID *uuid.UUID) (string, string, uuid.UUID, error) {
+	parent, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return "", "", uuid.Nil, errors.New("room not found")
+	}
+
+	role := s.perms.Role(ctx, parent, userID)
+	if role == "" {
+		return "", "", uuid.Nil, errors.New("not a room participant")
+	}
+
+	banned, err := s.roomRepo.IsBanned(ctx, roomID, userID)
+	if err != nil {
+		return "", "", uuid.Nil, errors.New("failed to check participant ban")
+	}
+	if banned {
+		return "", "", uuid.Nil, errors.New("you are banned from this room")
+	}
+
+	// Участник идет в свой зал, ведущие - в выбранный
+	var targetID uuid.UUID
+	if breakoutRoomID != nil && roleCan(role, ActionManageBreakouts) {
+		targetID = *breakoutRoomID
+	} else {
+		assignment, err := s.breakoutRepo.GetAssignment(ctx, roomID, userID)
+		if err != nil {
+			return "", "", uuid.Nil, err
+		}
+		if breakoutRoomID != nil && *breakoutRoomID != assignment.BreakoutRoomID {
+			return "", "", uuid.Nil, errors.New("not assigned to a breakout")
+		}
+		targetID = assignment.BreakoutRoomID
+	}
+
+	breakout, err := s.roomRepo.GetByID(ctx, targetID)
+	if err != nil || breakout.ParentRoomID == nil || *breakout.ParentRoomID != parent.ID {
+		return "", "", uuid.Nil, errors.New("breakout not found")
+	}
+	if breakout.Status != domain.RoomStatusActive {
+		return "", "", uuid.Nil, errors.New("breakouts are not open")
+	}
+
+	// В зале участник сохраняет роль и имя из основной комнаты
+	if _, err := s.roomRepo.GetParticipant(ctx, breakout.ID, userID); err != nil {
+		name := displayName
+		if parentParticipant, err := s.roomRepo.GetParticipant(ctx, roomID, userID); err == nil && name == "" {
+			name = parentParticipant.DisplayName
+		}
+
+		participant := &domain.RoomParticipant{
+			ID:          uuid.New(),
+			RoomID:      breakout.ID,
+			UserID:      &userID,
+			Role:        role,
+			DisplayName: name,
+			JoinedAt:    time.Now(),
+		}
+		if err := s.roomRepo.CreateParticipant(ctx, participant); err != nil {
+			return "", "", uuid.Nil, errors.New("failed to join breakout")
+		}
+	}
+
+	token, url, err := s.GetToken(ctx, breakout.ID, userID, displayName)
+	if err != nil {
+		return "", "", uuid.Nil, err
+	}
+
+	return token, url, breakout.ID, nil
+}
+
+func (s *mediaService) CreateIngress(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params CreateIngressParams) (*domain.RoomIngress, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageIngress); err != nil {
+		return nil, err
+	}
+
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	var inputType livekit.IngressInput
+	switch strings.ToLower(params.InputType) {
+	case domain.IngressInputRTMP:
+		inputType = livekit.IngressInput_RTMP_INPUT
+	case domain.IngressInputWHIP:
+		inputType = livekit.IngressInput_WHIP_INPUT
+	default:
+		return nil, errors.New("input_type must be rtmp or whip")
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		name = defaultIngressName
+	}
+	if len([]rune(name)) > 100 {
+		return nil, errors.New("name is too long")
+	}
+
+	if s.encryptor == nil {
+		return nil, errors.New("stream key encryption is not configured")
+	}
+
+	ingress := &domain.RoomIngress{
+		ID:              uuid.New(),
+		RoomID:          roomID,
+		InputType:       strings.ToLower(params.InputType),
+		ParticipantName: name,
+		CreatedByUserID: &userID,
+		CreatedAt:       time.Now(),
+	}
+
+	info, err := s.livekit.CreateIngress(ctx, inputType, room.LiveKitRoomName, ingress.Identity(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	ingress.IngressID = info.IngressId
+	ingress.URL = info.Url
+	ingress.StreamKey = info.StreamKey
+	ingress.StreamKeyEncrypted, err = s.encryptor.Encrypt(info.StreamKey)
+	if err == nil {
+		err = s.ingressRepo.Create(ctx, ingress)
+	}
+	if err != nil {
+		// Вход без записи в БД нельзя будет отозвать через API
+		if deleteErr := s.livekit.DeleteIngress(ctx, info.IngressId); deleteErr != nil {
+			s.log.Warn("Failed to delete orphaned ingress", "error", deleteErr, "ingress_id", info.IngressId)
+		}
+		return nil, errors.New("failed to save ingress")
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   ingress.CreatedAt,
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeIngressCreated,
+		Payload:     map[string]interface{}{"ingress_id": ingress.ID, "livekit_ingress_id": ingress.IngressID, "input_type": ingress.InputType},
+	})
+
+	return ingress, nil
+}
+
+func (s *mediaService) ListIngresses(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.RoomIngress, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageIngress); err != nil {
+		return nil, err
+	}
+
+	ingresses, err := s.ingressRepo.ListByRoom(ctx, roomID, false)
+	if err != nil {
+		return nil, err
+	}
+	if ingresses == nil {
+		ingresses = []*domain.RoomIngress{}
+	}
+
+	// Ключ нужен хосту, чтобы заново настроить кодировщик; у отозванных входов он бесполезен
+	for _, ingress := range ingresses {
+		if ingress.IsRevoked() || s.encryptor == nil {
+			continue
+		}
+		if key, err := s.encryptor.Decrypt(ingress.StreamKeyEncrypted); err == nil {
+			ingress.StreamKey = key
+		} else {
+			s.log.Warn("Failed to decrypt ingress stream key", "error", err, "ingress_id", ingress.ID)
+		}
+	}
+
+	return ingresses, nil
+}
+
+func (s *mediaService) RevokeIngress(ctx context.Context, roomID uuid.UUID, ingressID uuid.UUID, userID uuid.UUID) error {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageIngress); err != nil {
+		return err
+	}
+
+	ingress, err := s.ingressRepo.GetByID(ctx, ingressID)
+	if err != nil {
+		return err
+	}
+	if ingress.RoomID != roomID {
+		return errors.New("ingress not found")
+	}
+	if ingress.IsRevoked() {
+		return errors.New("ingress already revoked")
+	}
+
+	if err := s.revokeIngress(ctx, room, ingress); err != nil {
+		return err
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeIngressRevoked,
+		Payload:     map[string]interface{}{"ingress_id": ingress.ID, "livekit_ingress_id": ingress.IngressID},
+	})
+
+	return nil
+}
+
+func (s *mediaService) RevokeRoomIngresses(ctx context.Context, room *domain.Room) error {
+	ingresses, err := s.ingressRepo.ListByRoom(ctx, room.ID, true)
+	if err != nil {
+		return err
+	}
+
+	for _, ingress := range ingresses {
+		if err := s.revokeIngress(ctx, room, ingress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeIngress удаляет вход в LiveKit и отключает его участника; участие в БД
+// закроет webhook participant_left
+func (s *mediaService) revokeIngress(ctx context.Context, room *domain.Room, ingress *domain.RoomIngress) error {
+	if err := s.livekit.DeleteIngress(ctx, ingress.IngressID); err != nil {
+		return err
+	}
+	if err := s.livekit.RemoveParticipant(ctx, room.LiveKitRoomName, ingress.Identity()); err != nil {
+		s.log.Warn("Failed to remove ingress participant", "error", err, "ingress_id", ingress.ID)
+	}
+
+	if err := s.ingressRepo.Revoke(ctx, ingress.ID, time.Now()); err != nil {
+		return errors.New("failed to revoke ingress")
+	}
+
+	return nil
+}
+
+// grantFor собирает права LiveKit по роли участника и Room.Settings
+func (s *mediaService) grantFor(room *domain.Room, participant *domain.RoomParticipant) *auth.VideoGrant {
+	grant := &auth.VideoGrant{
+		RoomJoin: true,
+		Room:     room.LiveKitRoomName,
+	}
+	grant.SetCanSubscribe(true)
+	grant.RoomAdmin = roleCan(participant.Role, ActionModerate)
+
+	sources := publishSources(room, participant.Role)
+	grant.SetCanPublish(len(sources) > 0)
+	if len(sources) > 0 && len(sources) < len(allPublishSources) {
+		grant.SetCanPublishSources(sources)
+	}
+
+	// Зритель вебинара только подписывается на чужие дорожки
+	grant.SetCanPublishData(!isWebinarViewer(room, participant.Role))
+
+	return grant
+}
+
+var allPublishSources = []livekit.TrackSource{
+	livekit.TrackSource_CAMERA,
+	livekit.TrackSource_MICROPHONE,
+	livekit.TrackSource_SCREEN_SHARE,
+	livekit.TrackSource_SCREEN_SHARE_AUDIO,
+}
+
+// publishSources - источники, которые участник с этой ролью может публиковать в комнате.
+// Хост и co-host не ограничены настройками публикации.
+func publishSources(room *domain.Room, role string) []livekit.TrackSource {
+	// Ingress публикует то, что прислал кодировщик; хост создал его намеренно
+	if roleCan(role, ActionModerate) || role == domain.ParticipantRoleIngest {
+		return allPublishSources
+	}
+	if isWebinarViewer(room, role) {
+		return nil
+	}
+
+	var sources []livekit.TrackSource
+	if room.SettingBool(domain.RoomSettingAllowCamera, true) {
+		sources = append(sources, livekit.TrackSource_CAMERA)
+	}
+	if room.SettingBool(domain.RoomSettingAllowMicrophone, true) {
+		sources = append(sources, livekit.TrackSource_MICROPHONE)
+	}
+	if room.SettingBool(domain.RoomSettingAllowScreenShare, true) {
+		sources = append(sources, livekit.TrackSource_SCREEN_SHARE, livekit.TrackSource_SCREEN_SHARE_AUDIO)
+	}
+	return sources
+}
+
+func isWebinarViewer(room *domain.Room, role string) bool {
+	return room.SettingBool(domain.RoomSettingWebinarMode, false) && !roleCan(role, ActionModerate)
+}
+
+// tokenTTL - токен действует до запланированного окончания встречи
+func (s *mediaService) tokenTTL(room *domain.Room) time.Duration {
+	if room.ScheduledEndAt == nil {
+		return defaultTokenTTL
+	}
+
+	ttl := time.Until(*room.ScheduledEndAt)
+	if ttl < minTokenTTL {
+		return minTokenTTL
+	}
+	return ttl
+}
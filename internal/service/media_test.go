@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"video_conference/internal/config"
+)
+
+func TestGetTokenRequiresActiveParticipant(t *testing.T) {
+	f := newRoomFixture(t)
+	cfg := config.LiveKitConfig{APIKey: "key", APISecret: "secret-secret-secret-secret-secret", URL: "ws://localhost:7880"}
+	svc := NewMediaService(f.roomRepo, nil, nil, f.audit, nil, cfg, nopLogger{})
+	ctx := context.Background()
+	memberID := *f.participant.UserID
+
+	if _, _, err := svc.GetToken(ctx, f.room.ID, uuid.New(), "Outsider"); err == nil || err.Error() != "not a room participant" {
+		t.Errorf("outsider token error = %v, want not a room participant", err)
+	}
+
+	token, _, err := svc.GetToken(ctx, f.room.ID, memberID, "")
+	if err != nil || token == "" {
+		t.Fatalf("participant token = %q, %v", token, err)
+	}
+
+	f.participant.IsKicked = true
+	if _, _, err := svc.GetToken(ctx, f.room.ID, memberID, ""); err == nil || err.Error() != "not a room participant" {
+		t.Errorf("kicked participant token error = %v, want not a room participant", err)
+	}
+}
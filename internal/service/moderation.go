@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// Причина выхода участника, исключенного модератором
+const leaveReasonKicked = "kicked"
+
+type ModerationService interface {
+	// Kick исключает участника из комнаты, ban запрещает ему повторный вход
+	Kick(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID, ban bool, reason *string) (*domain.RoomParticipant, error)
+	// Mute принудительно отключает опубликованные дорожки участника, возвращает число отключенных
+	Mute(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID, kind string) (int, error)
+	Lock(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.Room, error)
+	Unlock(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.Room, error)
+}
+
+type moderationService struct {
+	roomRepo  repository.RoomRepository
+	auditRepo repository.AuditRepository
+	realtime  RealtimeService
+	livekit   LiveKitService
+	perms     *roomPermissions
+	log       logger.Logger
+}
+
+func NewModerationService(roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, realtime RealtimeService, livekit LiveKitService, log logger.Logger) ModerationService {
+	return &moderationService{
+		roomRepo:  roomRepo,
+		auditRepo: auditRepo,
+		realtime:  realtime,
+		livekit:   livekit,
+		perms:     newRoomPermissions(roomRepo),
+		log:       log,
+	}
+}
+
+func (s *moderationService) Kick(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID, ban bool, reason *string) (*domain.RoomParticipant, error) {
+	room, target, err := s.getTarget(ctx, roomID, participantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Вышедшего участника можно только забанить
+	if target.LeftAt != nil && !ban {
+		return nil, errors.New("participant is not in the room")
+	}
+
+	connected := target.LeftAt == nil
+	if connected {
+		now := time.Now()
+		leaveReason := leaveReasonKicked
+		target.LeftAt = &now
+		target.LeaveReason = &leaveReason
+	}
+	target.IsKicked = target.IsKicked || ban
+
+	if err := s.roomRepo.UpdateParticipant(ctx, target); err != nil {
+		return nil, errors.New("failed to update participant")
+	}
+
+	// Решение уже сохранено: сбой медиасервера не должен терять событие и запись аудита,
+	// клиент исключенного участника отключится сам по событию модерации
+	if connected && target.UserID != nil {
+		if err := s.livekit.RemoveParticipant(ctx, room.LiveKitRoomName, target.UserID.String()); err != nil {
+			s.log.Warn("Failed to remove kicked participant from LiveKit", "error", err, "room_id", roomID, "participant_id", target.ID)
+		}
+	}
+
+	s.publish(ctx, roomID, &domain.ModerationPayload{
+		Action:        domain.ModerationActionKicked,
+		ParticipantID: &target.ID,
+		ActorUserID:   userID,
+		Banned:        target.IsKicked,
+	})
+
+	payload := map[string]interface{}{
+		"participant_id": target.ID,
+		"display_name":   target.DisplayName,
+		"ban":            ban,
+	}
+	if target.UserID != nil {
+		payload["user_id"] = *target.UserID
+	}
+	if reason != nil {
+		payload["reason"] = *reason
+	}
+	s.audit(ctx, room, userID, domain.EventTypeUserKicked, payload)
+
+	return target, nil
+}
+
+func (s *moderationService) Mute(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID, kind string) (int, error) {
+	if kind == "" {
+		kind = TrackKindAll
+	}
+	if kind != TrackKindAll && kind != TrackKindAudio && kind != TrackKindVideo {
+		return 0, errors.New("invalid track kind")
+	}
+
+	room, target, err := s.getTarget(ctx, roomID, participantID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if target.LeftAt != nil || target.UserID == nil {
+		return 0, errors.New("participant is not in the room")
+	}
+
+	muted, err := s.livekit.MuteTracks(ctx, room.LiveKitRoomName, target.UserID.String(), kind)
+	if err != nil {
+		return muted, err
+	}
+
+	s.publish(ctx, roomID, &domain.ModerationPayload{
+		Action:        domain.ModerationActionMuted,
+		ParticipantID: &target.ID,
+		ActorUserID:   userID,
+		TrackKind:     kind,
+	})
+
+	s.audit(ctx, room, userID, domain.EventTypeParticipantMuted, map[string]interface{}{
+		"participant_id": target.ID,
+		"track_kind":     kind,
+		"muted_tracks":   muted,
+	})
+
+	return muted, nil
+}
+
+func (s *moderationService) Lock(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.Room, error) {
+	return s.setLocked(ctx, roomID, userID, true)
+}
+
+func (s *moderationService) Unlock(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.Room, error) {
+	return s.setLocked(ctx, roomID, userID, false)
+}
+
+func (s *moderationService) setLocked(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, locked bool) (*domain.Room, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionModerate); err != nil {
+		return nil, err
+	}
+
+	if room.IsLocked == locked {
+		return room, nil
+	}
+
+	room.IsLocked = locked
+	room.UpdatedAt = time.Now()
+	if err := s.roomRepo.Update(ctx, room); err != nil {
+		return nil, errors.New("failed to update room")
+	}
+
+	action, eventType := domain.ModerationActionUnlocked, domain.EventTypeRoomUnlocked
+	if locked {
+		action, eventType = domain.ModerationActionLocked, domain.EventTypeRoomLocked
+	}
+
+	s.publish(ctx, roomID, &domain.ModerationPayload{
+		Action:      action,
+		ActorUserID: userID,
+	})
+	s.audit(ctx, room, userID, eventType, map[string]interface{}{})
+
+	return room, nil
+}
+
+// getTarget проверяет права модератора и возвращает участника комнаты, над которым выполняется действие
+func (s *moderationService) getTarget(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID) (*domain.Room, *domain.RoomParticipant, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	role := s.perms.Role(ctx, room, userID)
+	if !roleCan(role, ActionModerate) {
+		return nil, nil, permissionDenied(ActionModerate)
+	}
+
+	target, err := s.roomRepo.GetParticipantByID(ctx, participantID)
+	if err != nil || target.RoomID != roomID {
+		return nil, nil, errors.New("participant not found")
+	}
+
+	if target.UserID != nil && *target.UserID == userID {
+		return nil, nil, errors.New("cannot moderate yourself")
+	}
+	if target.Role == domain.ParticipantRoleHost || (target.UserID != nil && *target.UserID == room.HostUserID) {
+		return nil, nil, errors.New("cannot moderate room host")
+	}
+	// Co-host не может исключать или глушить других co-host
+	if target.Role == domain.ParticipantRoleCoHost && !roleCan(role, ActionManageCoHosts) {
+		return nil, nil, errors.New("cannot moderate co-host")
+	}
+
+	return room, target, nil
+}
+
+func (s *moderationService) publish(ctx context.Context, roomID uuid.UUID, payload *domain.ModerationPayload) {
+	if err := s.realtime.Publish(ctx, roomID, domain.RoomEventTypeModeration, payload); err != nil {
+		s.log.Warn("Failed to publish moderation event", "error", err, "room_id", roomID, "action", payload.Action)
+	}
+}
+
+func (s *moderationService) audit(ctx context.Context, room *domain.Room, userID uuid.UUID, eventType string, payload map[string]interface{}) {
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &room.ID,
+		EventType:   eventType,
+		Payload:     payload,
+	})
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/twitchtv/twirp"
+	"video_conference/internal/domain"
+)
+
+type moderationFixture struct {
+	*roomFixture
+	service ModerationService
+}
+
+func newModerationFixture(t *testing.T) *moderationFixture {
+	t.Helper()
+
+	f := &moderationFixture{roomFixture: newRoomFixture(t)}
+	f.service = NewModerationService(f.roomRepo, f.audit, f.realtime, f.lkService, nopLogger{})
+
+	return f
+}
+
+func TestKickRemovesConnectedParticipant(t *testing.T) {
+	f := newModerationFixture(t)
+
+	kicked, err := f.service.Kick(context.Background(), f.room.ID, f.participant.ID, f.room.HostUserID, false, nil)
+	if err != nil {
+		t.Fatalf("Kick: %v", err)
+	}
+
+	if kicked.LeftAt == nil || kicked.IsKicked {
+		t.Errorf("kicked participant: left_at=%v is_kicked=%v, want left and not banned", kicked.LeftAt, kicked.IsKicked)
+	}
+	if got, want := f.livekit.rooms.removedIdentities(), []string{f.participant.UserID.String()}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RemoveParticipant identities = %v, want %v", got, want)
+	}
+	if got := f.audit.eventTypes(); !reflect.DeepEqual(got, []string{domain.EventTypeUserKicked}) {
+		t.Errorf("audit events = %v", got)
+	}
+	if got := f.realtime.events; !reflect.DeepEqual(got, []string{domain.RoomEventTypeModeration}) {
+		t.Errorf("published events = %v", got)
+	}
+}
+
+func TestKickKeepsDecisionWhenLiveKitFails(t *testing.T) {
+	f := newModerationFixture(t)
+	f.livekit.rooms.removeErr = twirp.InternalError("media server unavailable")
+
+	kicked, err := f.service.Kick(context.Background(), f.room.ID, f.participant.ID, f.room.HostUserID, true, nil)
+	if err != nil {
+		t.Fatalf("Kick: %v", err)
+	}
+
+	if !kicked.IsKicked || f.roomRepo.participants[f.participant.ID].LeftAt == nil {
+		t.Error("ban was not saved")
+	}
+	if got := f.audit.eventTypes(); !reflect.DeepEqual(got, []string{domain.EventTypeUserKicked}) {
+		t.Errorf("audit events = %v", got)
+	}
+	if len(f.realtime.events) != 1 {
+		t.Errorf("published events = %v, want one moderation event", f.realtime.events)
+	}
+}
+
+func TestBanParticipantWhoAlreadyLeft(t *testing.T) {
+	f := newModerationFixture(t)
+	leftAt := time.Now().Add(-time.Minute)
+	f.participant.LeftAt = &leftAt
+
+	banned, err := f.service.Kick(context.Background(), f.room.ID, f.participant.ID, f.room.HostUserID, true, nil)
+	if err != nil {
+		t.Fatalf("Kick: %v", err)
+	}
+
+	if !banned.IsKicked || !banned.LeftAt.Equal(leftAt) {
+		t.Errorf("banned participant: is_kicked=%v left_at=%v, want banned with original left_at", banned.IsKicked, banned.LeftAt)
+	}
+	if got := f.livekit.rooms.removedIdentities(); len(got) != 0 {
+		t.Errorf("RemoveParticipant called for participant who left: %v", got)
+	}
+	if len(f.audit.logs) != 1 {
+		t.Errorf("audit logs = %d, want 1", len(f.audit.logs))
+	}
+}
+
+func TestKickParticipantWhoLeftWithoutBan(t *testing.T) {
+	f := newModerationFixture(t)
+	leftAt := time.Now()
+	f.participant.LeftAt = &leftAt
+
+	if _, err := f.service.Kick(context.Background(), f.room.ID, f.participant.ID, f.room.HostUserID, false, nil); err == nil {
+		t.Fatal("expected error for kicking participant who left")
+	}
+	if len(f.audit.logs) != 0 {
+		t.Errorf("audit logs = %d, want none", len(f.audit.logs))
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+)
+
+// Действия в комнате, доступ к которым зависит от роли участника
+const (
+	ActionUpdateRoom          = "update_room"
+	ActionDeleteRoom          = "delete_room"
+	ActionCancelRoom          = "cancel_room"
+	ActionManageInvites       = "manage_invites"
+	ActionManageCoHosts       = "manage_co_hosts"
+	ActionModerate            = "moderate"
+	ActionManageWaitingRoom   = "manage_waiting_room"
+	ActionDeleteOthersMessage = "delete_others_message"
+	ActionViewMessageHistory  = "view_message_history"
+	ActionManageChat          = "manage_chat"
+	ActionReadHostsMessages   = "read_hosts_messages"
+	ActionExportChat          = "export_chat"
+	ActionManageRecordings    = "manage_recordings"
+	ActionManageStreams       = "manage_streams"
+	ActionManageIngress       = "manage_ingress"
+	ActionManageBreakouts     = "manage_breakouts"
+	ActionManagePolls         = "manage_polls"
+)
+
+// rolePermissions - какие действия разрешены каждой роли.
+// Хост может все, co-host помогает вести встречу, но не меняет саму комнату.
+var rolePermissions = map[string]map[string]bool{
+	domain.ParticipantRoleHost: {
+		ActionUpdateRoom:          true,
+		ActionDeleteRoom:          true,
+		ActionCancelRoom:          true,
+		ActionManageInvites:       true,
+		ActionManageCoHosts:       true,
+		ActionModerate:            true,
+		ActionManageWaitingRoom:   true,
+		ActionDeleteOthersMessage: true,
+		ActionViewMessageHistory:  true,
+		ActionManageChat:          true,
+		ActionReadHostsMessages:   true,
+		ActionExportChat:          true,
+		ActionManageRecordings:    true,
+		ActionManageStreams:       true,
+		ActionManageIngress:       true,
+		ActionManageBreakouts:     true,
+		ActionManagePolls:         true,
+	},
+	domain.ParticipantRoleCoHost: {
+		ActionManageInvites:       true,
+		ActionModerate:            true,
+		ActionManageWaitingRoom:   true,
+		ActionDeleteOthersMessage: true,
+		ActionViewMessageHistory:  true,
+		ActionManageChat:          true,
+		ActionReadHostsMessages:   true,
+		ActionExportChat:          true,
+		ActionManageBreakouts:     true,
+		ActionManagePolls:         true,
+	},
+}
+
+// Ошибки отказа в доступе; тексты используются handler-ами для выбора статуса
+var permissionDeniedErrors = map[string]string{
+	ActionUpdateRoom:          "only host can update room",
+	ActionDeleteRoom:          "only host can delete room",
+	ActionCancelRoom:          "only host can cancel room",
+	ActionManageInvites:       "only host or co-host can manage invites",
+	ActionManageCoHosts:       "only host can manage co-hosts",
+	ActionModerate:            "only host or co-host can moderate room",
+	ActionManageWaitingRoom:   "only host or co-host can manage waiting room",
+	ActionDeleteOthersMessage: "only sender or moderator can delete message",
+	ActionViewMessageHistory:  "only host or co-host can view message history",
+	ActionManageChat:          "only host or co-host can manage chat",
+	ActionReadHostsMessages:   "only host or co-host can read messages to hosts",
+	ActionExportChat:          "only host or co-host can export chat",
+	ActionManageRecordings:    "only host can manage recordings",
+	ActionManageStreams:       "only host can manage streams",
+	ActionManageIngress:       "only host can manage ingress",
+	ActionManageBreakouts:     "only host or co-host can manage breakouts",
+	ActionManagePolls:         "only host or co-host can manage polls",
+}
+
+// roomPermissions определяет роль пользователя в комнате и проверяет его права
+type roomPermissions struct {
+	roomRepo repository.RoomRepository
+}
+
+func newRoomPermissions(roomRepo repository.RoomRepository) *roomPermissions {
+	return &roomPermissions{roomRepo: roomRepo}
+}
+
+// Role возвращает роль пользователя: хост комнаты всегда host, остальные - по активному
+// участию. Пустая строка означает, что пользователь не участник комнаты.
+func (p *roomPermissions) Role(ctx context.Context, room *domain.Room, userID uuid.UUID) string {
+	if room.HostUserID == userID {
+		return domain.ParticipantRoleHost
+	}
+
+	participant, err := p.roomRepo.GetParticipant(ctx, room.ID, userID)
+	if err != nil || participant.IsKicked {
+		return ""
+	}
+
+	return participant.Role
+}
+
+// Can - разрешено ли пользователю действие в комнате
+func (p *roomPermissions) Can(ctx context.Context, room *domain.Room, userID uuid.UUID, action string) bool {
+	return roleCan(p.Role(ctx, room, userID), action)
+}
+
+// Authorize возвращает ошибку отказа, если действие пользователю не разрешено
+func (p *roomPermissions) Authorize(ctx context.Context, room *domain.Room, userID uuid.UUID, action string) error {
+	if p.Can(ctx, room, userID, action) {
+		return nil
+	}
+	return permissionDenied(action)
+}
+
+// ActorRole возвращает роль пользователя в комнате для журнала аудита
+func (p *roomPermissions) ActorRole(ctx context.Context, room *domain.Room, userID uuid.UUID) string {
+	switch p.Role(ctx, room, userID) {
+	case domain.ParticipantRoleHost:
+		return domain.ActorRoleHost
+	case domain.ParticipantRoleCoHost:
+		return domain.ActorRoleCoHost
+	default:
+		return domain.ActorRoleUser
+	}
+}
+
+func roleCan(role string, action string) bool {
+	return rolePermissions[role][action]
+}
+
+func permissionDenied(action string) error {
+	if message, ok := permissionDeniedErrors[action]; ok {
+		return errors.New(message)
+	}
+	return errors.New("permission denied")
+}
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// Ограничения опросов
+const (
+	minPollOptions        = 2
+	maxPollOptions        = 10
+	maxPollQuestionLength = 500
+	maxPollOptionLength   = 200
+)
+
+// CreatePollParams - вопрос, варианты ответа и режим голосования
+type CreatePollParams struct {
+	Question       string
+	Options        []string
+	MultipleChoice bool
+	Anonymous      bool
+}
+
+// PollService - опросы во время встречи. Хост и co-host создают опрос черновиком,
+// открывают и закрывают его; голосуют активные участники комнаты. Результаты считаются
+// на лету и рассылаются в канал комнаты после каждого голоса.
+type PollService interface {
+	Create(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params CreatePollParams) (*domain.Poll, error)
+	// List возвращает опросы с результатами; черновики видят только ведущие
+	List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.Poll, error)
+	Get(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID) (*domain.Poll, error)
+	Open(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID) (*domain.Poll, error)
+	Close(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID) (*domain.Poll, error)
+	Delete(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID) error
+	// Vote заменяет прежний выбор пользователя, пока опрос открыт
+	Vote(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID, optionIDs []uuid.UUID) (*domain.Poll, error)
+	// Export выгружает результаты открытых и закрытых опросов комнаты в CSV, в том числе после встречи
+	Export(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]byte, error)
+}
+
+type pollService struct {
+	pollRepo  repository.PollRepository
+	roomRepo  repository.RoomRepository
+	auditRepo repository.AuditRepository
+	realtime  RealtimeService
+	perms     *roomPermissions
+	log       logger.Logger
+}
+
+func NewPollService(pollRepo repository.PollRepository, roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, realtime RealtimeService, log logger.Logger) PollService {
+	return &pollService{
+		pollRepo:  pollRepo,
+		roomRepo:  roomRepo,
+		auditRepo: auditRepo,
+		realtime:  realtime,
+		perms:     newRoomPermissions(roomRepo),
+		log:       log,
+	}
+}
+
+func (s *pollService) Create(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params CreatePollParams) (*domain.Poll, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManagePolls); err != nil {
+		return nil, err
+	}
+
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	question := strings.TrimSpace(params.Question)
+	if question == "" {
+		return nil, errors.New("question is required")
+	}
+	if len([]rune(question)) > maxPollQuestionLength {
+		return nil, errors.New("question is too long")
+	}
+
+	if len(params.Options) < minPollOptions || len(params.Options) > maxPollOptions {
+		return nil, fmt.Errorf("poll must have %d to %d options", minPollOptions, maxPollOptions)
+	}
+
+	options := make([]*domain.PollOption, 0, len(params.Options))
+	for i, text := range params.Options {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, errors.New("option text is required")
+		}
+		if len([]rune(text)) > maxPollOptionLength {
+			return nil, errors.New("option is too long")
+		}
+		options = append(options, &domain.PollOption{
+			ID:       uuid.New(),
+			Position: i + 1,
+			Text:     text,
+		})
+	}
+
+	poll := &domain.Poll{
+		ID:              uuid.New(),
+		RoomID:          roomID,
+		CreatedByUserID: &userID,
+		Question:        question,
+		MultipleChoice:  params.MultipleChoice,
+		Anonymous:       params.Anonymous,
+		Status:          domain.PollStatusDraft,
+		Options:         options,
+		CreatedAt:       time.Now(),
+	}
+
+	if err := s.pollRepo.Create(ctx, poll); err != nil {
+		return nil, errors.New("failed to create poll")
+	}
+
+	s.audit(ctx, room, poll, userID, domain.EventTypePollCreated)
+
+	return poll, nil
+}
+
+func (s *pollService) List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.Poll, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	role := s.perms.Role(ctx, room, userID)
+	if role == "" {
+		return nil, errors.New("not a room participant")
+	}
+
+	polls, err := s.pollRepo.ListByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]*domain.Poll, 0, len(polls))
+	for _, poll := range polls {
+		if poll.Status == domain.PollStatusDraft && !roleCan(role, ActionManagePolls) {
+			continue
+		}
+		visible = append(visible, poll)
+	}
+
+	if err := s.loadResults(ctx, visible, &userID); err != nil {
+		return nil, err
+	}
+
+	return visible, nil
+}
+
+func (s *pollService) Get(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID) (*domain.Poll, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	role := s.perms.Role(ctx, room, userID)
+	if role == "" {
+		return nil, errors.New("not a room participant")
+	}
+
+	poll, err := s.getPoll(ctx, roomID, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if poll.Status == domain.PollStatusDraft && !roleCan(role, ActionManagePolls) {
+		return nil, errors.New("poll not found")
+	}
+
+	if err := s.loadResults(ctx, []*domain.Poll{poll}, &userID); err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+func (s *pollService) Open(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID) (*domain.Poll, error) {
+	room, poll, err := s.authorizePoll(ctx, roomID, pollID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+	if poll.Status != domain.PollStatusDraft {
+		return nil, errors.New("poll already opened")
+	}
+
+	now := time.Now()
+	poll.Status = domain.PollStatusOpen
+	poll.OpenedAt = &now
+	if err := s.pollRepo.Update(ctx, poll); err != nil {
+		return nil, errors.New("failed to update poll")
+	}
+
+	s.audit(ctx, room, poll, userID, domain.EventTypePollOpened)
+
+	if err := s.loadResults(ctx, []*domain.Poll{poll}, nil); err != nil {
+		return nil, err
+	}
+	s.publish(ctx, domain.PollActionOpened, poll)
+
+	return poll, nil
+}
+
+func (s *pollService) Close(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID) (*domain.Poll, error) {
+	room, poll, err := s.authorizePoll(ctx, roomID, pollID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if poll.Status != domain.PollStatusOpen {
+		return nil, errors.New("poll is not open")
+	}
+
+	now := time.Now()
+	poll.Status = domain.PollStatusClosed
+	poll.ClosedAt = &now
+	if err := s.pollRepo.Update(ctx, poll); err != nil {
+		return nil, errors.New("failed to update poll")
+	}
+
+	s.audit(ctx, room, poll, userID, domain.EventTypePollClosed)
+
+	if err := s.loadResults(ctx, []*domain.Poll{poll}, nil); err != nil {
+		return nil, err
+	}
+	s.publish(ctx, domain.PollActionClosed, poll)
+
+	return poll, nil
+}
+
+func (s *pollService) Delete(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID) error {
+	room, poll, err := s.authorizePoll(ctx, roomID, pollID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.pollRepo.Delete(ctx, poll.ID); err != nil {
+		return errors.New("failed to delete poll")
+	}
+
+	s.audit(ctx, room, poll, userID, domain.EventTypePollDeleted)
+
+	// Черновик участники не видели, сообщать о его удалении незачем
+	if poll.Status != domain.PollStatusDraft {
+		s.publish(ctx, domain.PollActionDeleted, poll)
+	}
+
+	return nil
+}
+
+func (s *pollService) Vote(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID, optionIDs []uuid.UUID) (*domain.Poll, error) {
+	poll, err := s.getPoll(ctx, roomID, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Голосуют только те, кто сейчас в комнате, включая хоста
+	participant, err := s.roomRepo.GetParticipant(ctx, roomID, userID)
+	if err != nil || participant.IsKicked {
+		return nil, errors.New("not a room participant")
+	}
+
+	if poll.Status != domain.PollStatusOpen {
+		return nil, errors.New("poll is not open")
+	}
+
+	if len(optionIDs) == 0 {
+		return nil, errors.New("select at least one option")
+	}
+	if !poll.MultipleChoice && len(optionIDs) > 1 {
+		return nil, errors.New("poll allows only one option")
+	}
+
+	valid := make(map[uuid.UUID]bool, len(poll.Options))
+	for _, option := range poll.Options {
+		valid[option.ID] = true
+	}
+
+	now := time.Now()
+	seen := make(map[uuid.UUID]bool, len(optionIDs))
+	votes := make([]*domain.PollVote, 0, len(optionIDs))
+	for _, optionID := range optionIDs {
+		if !valid[optionID] {
+			return nil, errors.New("invalid option")
+		}
+		if seen[optionID] {
+			continue
+		}
+		seen[optionID] = true
+		votes = append(votes, &domain.PollVote{
+			PollID:      poll.ID,
+			OptionID:    optionID,
+			UserID:      userID,
+			DisplayName: participant.DisplayName,
+			VotedAt:     now,
+		})
+	}
+
+	if err := s.pollRepo.ReplaceVotes(ctx, poll.ID, userID, votes); err != nil {
+		return nil, errors.New("failed to save vote")
+	}
+
+	if err := s.loadResults(ctx, []*domain.Poll{poll}, nil); err != nil {
+		return nil, err
+	}
+	s.publish(ctx, domain.PollActionVoted, poll)
+
+	// В ответе голосующему - его выбор; в канал комнаты он не попадает
+	response := *poll
+	response.MyOptionIDs = make([]uuid.UUID, 0, len(votes))
+	for _, vote := range votes {
+		response.MyOptionIDs = append(response.MyOptionIDs, vote.OptionID)
+	}
+
+	return &response, nil
+}
+
+func (s *pollService) Export(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]byte, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManagePolls); err != nil {
+		return nil, err
+	}
+
+	polls, err := s.pollRepo.ListByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]*domain.Poll, 0, len(polls))
+	for _, poll := range polls {
+		if poll.Status != domain.PollStatusDraft {
+			exported = append(exported, poll)
+		}
+	}
+
+	if err := s.loadResults(ctx, exported, nil); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"poll_id", "question", "status", "multiple_choice", "anonymous", "total_voters", "option", "votes", "voters"})
+	for _, poll := range exported {
+		for _, option := range poll.Options {
+			names := make([]string, 0, len(option.Voters))
+			for _, voter := range option.Voters {
+				names = append(names, voter.DisplayName)
+			}
+			w.Write([]string{
+				poll.ID.String(),
+				poll.Question,
+				poll.Status,
+				strconv.FormatBool(poll.MultipleChoice),
+				strconv.FormatBool(poll.Anonymous),
+				strconv.Itoa(poll.TotalVoters),
+				option.Text,
+				strconv.Itoa(option.Votes),
+				strings.Join(names, "; "),
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, errors.New("failed to export polls")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// authorizePoll загружает комнату и опрос и проверяет право управлять опросами
+func (s *pollService) authorizePoll(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID, userID uuid.UUID) (*domain.Room, *domain.Poll, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManagePolls); err != nil {
+		return nil, nil, err
+	}
+
+	poll, err := s.getPoll(ctx, roomID, pollID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return room, poll, nil
+}
+
+// getPoll возвращает опрос, только если он принадлежит комнате
+func (s *pollService) getPoll(ctx context.Context, roomID uuid.UUID, pollID uuid.UUID) (*domain.Poll, error) {
+	poll, err := s.pollRepo.GetByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if poll.RoomID != roomID {
+		return nil, errors.New("poll not found")
+	}
+	return poll, nil
+}
+
+// loadResults подсчитывает голоса опросов. В анонимных опросах авторы голосов не раскрываются;
+// для viewerID заполняется его собственный выбор.
+func (s *pollService) loadResults(ctx context.Context, polls []*domain.Poll, viewerID *uuid.UUID) error {
+	if len(polls) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(polls))
+	byID := make(map[uuid.UUID]*domain.Poll, len(polls))
+	options := make(map[uuid.UUID]*domain.PollOption)
+	for _, poll := range polls {
+		ids = append(ids, poll.ID)
+		byID[poll.ID] = poll
+		poll.TotalVoters = 0
+		for _, option := range poll.Options {
+			option.Votes = 0
+			option.Voters = nil
+			options[option.ID] = option
+		}
+	}
+
+	votes, err := s.pollRepo.ListVotes(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	voters := make(map[uuid.UUID]map[uuid.UUID]bool, len(polls))
+	for _, vote := range votes {
+		poll, ok := byID[vote.PollID]
+		option, found := options[vote.OptionID]
+		if !ok || !found {
+			continue
+		}
+
+		option.Votes++
+		if !poll.Anonymous {
+			option.Voters = append(option.Voters, &domain.PollVoter{UserID: vote.UserID, DisplayName: vote.DisplayName})
+		}
+		if viewerID != nil && vote.UserID == *viewerID {
+			poll.MyOptionIDs = append(poll.MyOptionIDs, vote.OptionID)
+		}
+
+		if voters[poll.ID] == nil {
+			voters[poll.ID] = make(map[uuid.UUID]bool)
+		}
+		if !voters[poll.ID][vote.UserID] {
+			voters[poll.ID][vote.UserID] = true
+			poll.TotalVoters++
+		}
+	}
+
+	return nil
+}
+
+func (s *pollService) publish(ctx context.Context, action string, poll *domain.Poll) {
+	if err := s.realtime.Publish(ctx, poll.RoomID, domain.RoomEventTypePoll, &domain.PollPayload{
+		Action: action,
+		Poll:   poll,
+	}); err != nil {
+		s.log.Warn("Failed to publish poll event", "error", err, "room_id", poll.RoomID, "action", action)
+	}
+}
+
+func (s *pollService) audit(ctx context.Context, room *domain.Room, poll *domain.Poll, userID uuid.UUID, eventType string) {
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &poll.RoomID,
+		EventType:   eventType,
+		Payload:     map[string]interface{}{"poll_id": poll.ID, "question": poll.Question},
+	})
+}
@@ -11,6 +11,10 @@ import (
 type RateLimitService interface {
 	CheckLimit(ctx context.Context, key string, limit int, windowSeconds int) (bool, error)
 	Increment(ctx context.Context, key string, windowSeconds int) (int64, error)
+	// Acquire атомарно занимает ключ на windowSeconds; false - ключ уже занят
+	Acquire(ctx context.Context, key string, windowSeconds int) (bool, error)
+	// Release освобождает ключ до истечения окна
+	Release(ctx context.Context, key string) error
 }
 
 type rateLimitService struct {
@@ -33,3 +37,10 @@ func (s *rateLimitService) Increment(ctx context.Context, key string, windowSeco
 	return s.rateLimitRepo.Increment(ctx, key, time.Duration(windowSeconds)*time.Second)
 }
 
+func (s *rateLimitService) Acquire(ctx context.Context, key string, windowSeconds int) (bool, error) {
+	return s.rateLimitRepo.Acquire(ctx, key, time.Duration(windowSeconds)*time.Second)
+}
+
+func (s *rateLimitService) Release(ctx context.Context, key string) error {
+	return s.rateLimitRepo.Release(ctx, key)
+}
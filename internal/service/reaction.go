@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// ReactionService - реакции во время встречи. Реакция сразу рассылается в канал комнаты,
+// а в БД попадает только счетчик за минуту для статистики после встречи.
+type ReactionService interface {
+	// Send отправляет реакцию активного участника; частота ограничена на участника
+	Send(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, emoji string) (*domain.Reaction, error)
+	// Stats возвращает реакции комнаты по минутам, в том числе после встречи
+	Stats(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.ReactionStats, error)
+}
+
+type reactionService struct {
+	reactionRepo repository.ReactionRepository
+	roomRepo     repository.RoomRepository
+	realtime     RealtimeService
+	rateLimit    RateLimitService
+	perms        *roomPermissions
+	cfg          config.RoomConfig
+	log          logger.Logger
+}
+
+func NewReactionService(reactionRepo repository.ReactionRepository, roomRepo repository.RoomRepository, realtime RealtimeService, rateLimit RateLimitService, cfg config.RoomConfig, log logger.Logger) ReactionService {
+	return &reactionService{
+		reactionRepo: reactionRepo,
+		roomRepo:     roomRepo,
+		realtime:     realtime,
+		rateLimit:    rateLimit,
+		perms:        newRoomPermissions(roomRepo),
+		cfg:          cfg,
+		log:          log,
+	}
+}
+
+func (s *reactionService) Send(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, emoji string) (*domain.Reaction, error) {
+	if !slices.Contains(domain.AllowedReactions, emoji) {
+		return nil, errors.New("unsupported reaction")
+	}
+
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	participant, err := s.roomRepo.GetParticipant(ctx, roomID, userID)
+	if err != nil || participant.IsKicked {
+		return nil, errors.New("not a room participant")
+	}
+
+	// Счетчик увеличивается до проверки: так параллельные запросы не проскочат лимит
+	key := fmt.Sprintf("reaction:%s:%s", roomID, participant.ID)
+	count, err := s.rateLimit.Increment(ctx, key, int(s.cfg.ReactionWindow.Seconds()))
+	if err != nil {
+		s.log.Error("Failed to check reaction rate limit", "error", err, "room_id", roomID)
+		return nil, errors.New("failed to send reaction")
+	}
+	if count > int64(s.cfg.ReactionMaxPerWindow) {
+		return nil, errors.New("too many reactions")
+	}
+
+	reaction := &domain.Reaction{
+		ParticipantID: participant.ID,
+		UserID:        participant.UserID,
+		DisplayName:   participant.DisplayName,
+		Emoji:         emoji,
+		SentAt:        time.Now(),
+	}
+
+	if err := s.realtime.Publish(ctx, roomID, domain.RoomEventTypeReaction, reaction); err != nil {
+		s.log.Error("Failed to publish reaction", "error", err, "room_id", roomID)
+		return nil, errors.New("failed to send reaction")
+	}
+
+	// Реакция уже показана участникам, потерянный счетчик только занижает статистику
+	if err := s.reactionRepo.IncrementCount(ctx, roomID, reaction.SentAt.UTC().Truncate(time.Minute), emoji); err != nil {
+		s.log.Warn("Failed to count reaction", "error", err, "room_id", roomID)
+	}
+
+	return reaction, nil
+}
+
+func (s *reactionService) Stats(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.ReactionStats, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.perms.Role(ctx, room, userID) == "" {
+		return nil, errors.New("not a room participant")
+	}
+
+	counts, err := s.reactionRepo.ListCounts(ctx, roomID)
+	if err != nil {
+		return nil, errors.New("failed to get reaction stats")
+	}
+
+	stats := &domain.ReactionStats{
+		RoomID:  roomID,
+		Totals:  make(map[string]int),
+		Minutes: []*domain.ReactionMinute{},
+	}
+
+	var current *domain.ReactionMinute
+	for _, count := range counts {
+		if current == nil || !current.Minute.Equal(count.Minute) {
+			current = &domain.ReactionMinute{Minute: count.Minute, Counts: make(map[string]int)}
+			stats.Minutes = append(stats.Minutes, current)
+		}
+		current.Counts[count.Emoji] += count.Count
+		current.Total += count.Count
+		stats.Totals[count.Emoji] += count.Count
+		stats.Total += count.Count
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// Размер буфера исходящих событий одного подключения.
+// Медленные клиенты теряют события, а не блокируют всю комнату.
+const roomConnectionBufferSize = 64
+
+type RealtimeService interface {
+	// Publish отправляет событие всем подключениям комнаты на всех репликах
+	Publish(ctx context.Context, roomID uuid.UUID, eventType string, payload interface{}) error
+	// PublishTo отправляет событие только подключениям перечисленных участников (или слушателей) комнаты
+	PublishTo(ctx context.Context, roomID uuid.UUID, participantIDs []uuid.UUID, eventType string, payload interface{}) error
+	// Connect регистрирует локальное подключение участника в хабе комнаты
+	Connect(ctx context.Context, participant *domain.RoomParticipant) (*RoomConnection, error)
+	// Listen подписывает локального слушателя на события комнаты без участия в присутствии
+	// (например, гостя в waiting room, который еще не стал участником). Кроме общих событий
+	// слушатель получает адресные события, в получателях которых указан listenerID.
+	Listen(ctx context.Context, roomID uuid.UUID, listenerID uuid.UUID) (*RoomConnection, error)
+	// Disconnect удаляет подключение и закрывает его канал событий
+	Disconnect(ctx context.Context, conn *RoomConnection)
+}
+
+// RoomConnection - локальное real-time подключение к комнате.
+// Participant равен nil для слушателей, подключенных через Listen.
+type RoomConnection struct {
+	ID          uuid.UUID
+	RoomID      uuid.UUID
+	Participant *domain.RoomParticipant
+	// ListenerID - адрес слушателя для адресных событий (например, ID заявки в waiting room)
+	ListenerID uuid.UUID
+	send       chan *domain.RoomEvent
+}
+
+// Events возвращает канал событий для отправки клиенту
+func (c *RoomConnection) Events() <-chan *domain.RoomEvent {
+	return c.send
+}
+
+// Deliver ставит событие в очередь только этого подключения (без fan-out)
+func (c *RoomConnection) Deliver(event *domain.RoomEvent) bool {
+	select {
+	case c.send <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// receives - адресовано ли событие этому подключению. Адресные события получают
+// только подключения участников из списка и слушатели, чей ListenerID в нем указан.
+func (c *RoomConnection) receives(event *domain.RoomEvent) bool {
+	if len(event.Recipients) == 0 {
+		return true
+	}
+	if c.Participant == nil {
+		return c.ListenerID != uuid.Nil && slices.Contains(event.Recipients, c.ListenerID)
+	}
+	return slices.Contains(event.Recipients, c.Participant.ID)
+}
+
+// roomHub - локальные подключения одной комнаты и ее подписка в Redis
+type roomHub struct {
+	conns       map[uuid.UUID]*RoomConnection
+	unsubscribe func() error
+}
+
+type realtimeService struct {
+	realtimeRepo repository.RealtimeRepository
+	log          logger.Logger
+
+	mu    sync.Mutex
+	rooms map[uuid.UUID]*roomHub
+}
+
+func NewRealtimeService(realtimeRepo repository.RealtimeRepository, log logger.Logger) RealtimeService {
+	return &realtimeService{
+		realtimeRepo: realtimeRepo,
+		log:          log,
+		rooms:        make(map[uuid.UUID]*roomHub),
+	}
+}
+
+func (s *realtimeService) Publish(ctx context.Context, roomID uuid.UUID, eventType string, payload interface{}) error {
+	event, err := NewRoomEvent(roomID, eventType, payload)
+	if err != nil {
+		return err
+	}
+	return s.realtimeRepo.Publish(ctx, event)
+}
+
+func (s *realtimeService) PublishTo(ctx context.Context, roomID uuid.UUID, participantIDs []uuid.UUID, eventType string, payload interface{}) error {
+	if len(participantIDs) == 0 {
+		return nil
+	}
+
+	event, err := NewRoomEvent(roomID, eventType, payload)
+	if err != nil {
+		return err
+	}
+	event.Recipients = participantIDs
+	return s.realtimeRepo.Publish(ctx, event)
+}
+
+func (s *realtimeService) Connect(ctx context.Context, participant *domain.RoomParticipant) (*RoomConnection, error) {
+	conn, err := s.register(participant.RoomID, participant, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Publish(ctx, conn.RoomID, domain.RoomEventTypePresence, s.presence(participant, domain.PresenceStatusOnline)); err != nil {
+		s.log.Warn("Failed to publish presence", "error", err, "room_id", conn.RoomID)
+	}
+
+	return conn, nil
+}
+
+func (s *realtimeService) Listen(ctx context.Context, roomID uuid.UUID, listenerID uuid.UUID) (*RoomConnection, error) {
+	return s.register(roomID, nil, listenerID)
+}
+
+func (s *realtimeService) Disconnect(ctx context.Context, conn *RoomConnection) {
+	var unsubscribe func() error
+
+	s.mu.Lock()
+	if hub, ok := s.rooms[conn.RoomID]; ok {
+		if _, exists := hub.conns[conn.ID]; exists {
+			delete(hub.conns, conn.ID)
+			close(conn.send)
+		}
+		if len(hub.conns) == 0 {
+			delete(s.rooms, conn.RoomID)
+			unsubscribe = hub.unsubscribe
+		}
+	}
+	s.mu.Unlock()
+
+	if unsubscribe != nil {
+		if err := unsubscribe(); err != nil {
+			s.log.Warn("Failed to unsubscribe from room events", "error", err, "room_id", conn.RoomID)
+		}
+	}
+
+	if conn.Participant == nil {
+		return
+	}
+
+	if err := s.Publish(ctx, conn.RoomID, domain.RoomEventTypePresence, s.presence(conn.Participant, domain.PresenceStatusOffline)); err != nil {
+		s.log.Warn("Failed to publish presence", "error", err, "room_id", conn.RoomID)
+	}
+}
+
+// register добавляет подключение в хаб комнаты, подписываясь на Redis при первом подключении
+func (s *realtimeService) register(roomID uuid.UUID, participant *domain.RoomParticipant, listenerID uuid.UUID) (*RoomConnection, error) {
+	conn := &RoomConnection{
+		ID:          uuid.New(),
+		RoomID:      roomID,
+		Participant: participant,
+		ListenerID:  listenerID,
+		send:        make(chan *domain.RoomEvent, roomConnectionBufferSize),
+	}
+
+	s.mu.Lock()
+	if hub, ok := s.rooms[roomID]; ok {
+		hub.conns[conn.ID] = conn
+		s.mu.Unlock()
+		return conn, nil
+	}
+	s.mu.Unlock()
+
+	// Подписываемся без блокировки: поход в Redis не должен задерживать остальные комнаты.
+	// Подписка живет дольше запроса, поэтому не используем его контекст
+	events, unsubscribe, err := s.realtimeRepo.Subscribe(context.Background(), roomID)
+	if err != nil {
+		return nil, errors.New("failed to subscribe to room events")
+	}
+
+	s.mu.Lock()
+	hub, ok := s.rooms[roomID]
+	if !ok {
+		hub = &roomHub{
+			conns:       make(map[uuid.UUID]*RoomConnection),
+			unsubscribe: unsubscribe,
+		}
+		s.rooms[roomID] = hub
+		go s.dispatch(hub, events)
+	}
+	hub.conns[conn.ID] = conn
+	s.mu.Unlock()
+
+	if ok {
+		// Параллельное подключение успело подписаться первым, лишняя подписка не нужна
+		s.discard(roomID, events, unsubscribe)
+	}
+
+	return conn, nil
+}
+
+// discard закрывает лишнюю подписку и вычитывает события, уже переданные в ее канал
+func (s *realtimeService) discard(roomID uuid.UUID, events <-chan *domain.RoomEvent, unsubscribe func() error) {
+	go func() {
+		for range events {
+		}
+	}()
+	if err := unsubscribe(); err != nil {
+		s.log.Warn("Failed to unsubscribe from room events", "error", err, "room_id", roomID)
+	}
+}
+
+// dispatch раздает события из Redis локальным подключениям комнаты
+func (s *realtimeService) dispatch(hub *roomHub, events <-chan *domain.RoomEvent) {
+	for event := range events {
+		s.mu.Lock()
+		for _, conn := range hub.conns {
+			if !conn.receives(event) {
+				continue
+			}
+			if !conn.Deliver(event) {
+				s.log.Warn("Dropping event for slow connection", "room_id", conn.RoomID, "connection_id", conn.ID)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *realtimeService) presence(participant *domain.RoomParticipant, status string) *domain.PresencePayload {
+	return &domain.PresencePayload{
+		ParticipantID: participant.ID,
+		UserID:        participant.UserID,
+		DisplayName:   participant.DisplayName,
+		Status:        status,
+	}
+}
+
+// NewRoomEvent собирает конверт события с сериализованным payload
+func NewRoomEvent(roomID uuid.UUID, eventType string, payload interface{}) (*domain.RoomEvent, error) {
+	var raw json.RawMessage
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, errors.New("failed to marshal event payload")
+		}
+		raw = data
+	}
+
+	return &domain.RoomEvent{
+		Type:    eventType,
+		RoomID:  roomID,
+		Payload: raw,
+		SentAt:  time.Now(),
+	}, nil
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livekit/protocol/livekit"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// Служебные сообщения чата о записи
+const (
+	recordingStartedMessage = "Началась запись встречи"
+	recordingStoppedMessage = "Запись встречи остановлена"
+)
+
+// StartRecordingParams - что записывать: всю комнату или одну дорожку (TrackSID)
+type StartRecordingParams struct {
+	TrackSID  string
+	AudioOnly bool
+}
+
+// RecordingService управляет записью встреч через LiveKit Egress. Запуск и остановка
+// доступны хосту; итоговый статус, файл и длительность приходят webhook-ами Egress.
+type RecordingService interface {
+	Start(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params StartRecordingParams) (*domain.Recording, error)
+	Stop(ctx context.Context, roomID uuid.UUID, recordingID uuid.UUID, userID uuid.UUID) (*domain.Recording, error)
+	List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.Recording, error)
+	// HandleEgressUpdate применяет состояние Egress из webhook-а к записи
+	HandleEgressUpdate(ctx context.Context, info *livekit.EgressInfo) error
+}
+
+type recordingService struct {
+	recordingRepo repository.RecordingRepository
+	roomRepo      repository.RoomRepository
+	auditRepo     repository.AuditRepository
+	chat          ChatService
+	livekit       LiveKitService
+	perms         *roomPermissions
+	recordingDir  string
+	log           logger.Logger
+}
+
+func NewRecordingService(recordingRepo repository.RecordingRepository, roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, chat ChatService, livekit LiveKitService, cfg config.LiveKitConfig, log logger.Logger) RecordingService {
+	return &recordingService{
+		recordingRepo: recordingRepo,
+		roomRepo:      roomRepo,
+		auditRepo:     auditRepo,
+		chat:          chat,
+		livekit:       livekit,
+		perms:         newRoomPermissions(roomRepo),
+		recordingDir:  cfg.RecordingDir,
+		log:           log,
+	}
+}
+
+func (s *recordingService) Start(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, params StartRecordingParams) (*domain.Recording, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageRecordings); err != nil {
+		return nil, err
+	}
+
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	kind := domain.RecordingKindRoomComposite
+	if params.TrackSID != "" {
+		kind = domain.RecordingKindTrack
+	}
+
+	// Одновременно допускается одна запись комнаты и одна запись каждой дорожки
+	recordings, err := s.recordingRepo.ListByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range recordings {
+		if existing.IsFinished() || existing.Kind != kind {
+			continue
+		}
+		if kind == domain.RecordingKindRoomComposite || (existing.TrackSID != nil && *existing.TrackSID == params.TrackSID) {
+			return nil, errors.New("recording already in progress")
+		}
+	}
+
+	// Строка создается до запуска Egress: webhook egress_started может прийти раньше ответа API
+	// и находит запись через GetPending
+	now := time.Now()
+	recording := &domain.Recording{
+		ID:              uuid.New(),
+		RoomID:          roomID,
+		Kind:            kind,
+		Status:          domain.RecordingStatusStarting,
+		StartedByUserID: &userID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if params.TrackSID != "" {
+		recording.TrackSID = &params.TrackSID
+	}
+	if err := s.recordingRepo.Create(ctx, recording); err != nil {
+		return nil, errors.New("failed to save recording")
+	}
+
+	var info *livekit.EgressInfo
+	if kind == domain.RecordingKindTrack {
+		filepath := s.recordingDir + "/{room_name}/{track_id}-{time}"
+		info, err = s.livekit.StartTrackRecording(ctx, room.LiveKitRoomName, params.TrackSID, filepath)
+	} else {
+		filepath := s.recordingDir + "/{room_name}/{time}"
+		info, err = s.livekit.StartRoomRecording(ctx, room.LiveKitRoomName, filepath, params.AudioOnly)
+	}
+	if err != nil {
+		message := err.Error()
+		recording.Status = domain.RecordingStatusFailed
+		recording.Error = &message
+		recording.UpdatedAt = time.Now()
+		if updateErr := s.recordingRepo.Update(ctx, recording); updateErr != nil {
+			s.log.Warn("Failed to mark recording as failed", "error", updateErr, "recording_id", recording.ID)
+		}
+		return nil, err
+	}
+
+	if err := s.recordingRepo.AttachEgress(ctx, recording.ID, info.EgressId); err != nil {
+		// Egress уже запущен - без записи в БД его нельзя будет остановить через API
+		if _, stopErr := s.livekit.StopEgress(ctx, info.EgressId); stopErr != nil {
+			s.log.Warn("Failed to stop orphaned egress", "error", stopErr, "egress_id", info.EgressId)
+		}
+		return nil, errors.New("failed to save recording")
+	}
+	recording.EgressID = info.EgressId
+
+	// Состояние могли уже обновить webhook-и Egress
+	if current, err := s.recordingRepo.GetByID(ctx, recording.ID); err == nil {
+		recording = current
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   now,
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeRecordingStarted,
+		Payload:     map[string]interface{}{"recording_id": recording.ID, "egress_id": recording.EgressID, "kind": kind},
+	})
+
+	s.postSystemMessage(ctx, roomID, recordingStartedMessage)
+
+	return recording, nil
+}
+
+func (s *recordingService) Stop(ctx context.Context, roomID uuid.UUID, recordingID uuid.UUID, userID uuid.UUID) (*domain.Recording, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageRecordings); err != nil {
+		return nil, err
+	}
+
+	recording, err := s.recordingRepo.GetByID(ctx, recordingID)
+	if err != nil {
+		return nil, err
+	}
+	if recording.RoomID != roomID {
+		return nil, errors.New("recording not found")
+	}
+	if recording.IsFinished() || recording.Status == domain.RecordingStatusEnding {
+		return nil, errors.New("recording is not active")
+	}
+
+	info, err := s.livekit.StopEgress(ctx, recording.EgressID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyEgressInfo(recording, info)
+	recording.UpdatedAt = time.Now()
+	if err := s.recordingRepo.Update(ctx, recording); err != nil {
+		return nil, errors.New("failed to update recording")
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeRecordingStopped,
+		Payload:     map[string]interface{}{"recording_id": recording.ID, "egress_id": recording.EgressID},
+	})
+
+	s.postSystemMessage(ctx, roomID, recordingStoppedMessage)
+
+	return recording, nil
+}
+
+func (s *recordingService) List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.Recording, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageRecordings); err != nil {
+		return nil, err
+	}
+
+	recordings, err := s.recordingRepo.ListByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if recordings == nil {
+		recordings = []*domain.Recording{}
+	}
+
+	return recordings, nil
+}
+
+func (s *recordingService) HandleEgressUpdate(ctx context.Context, info *livekit.EgressInfo) error {
+	recording, err := s.recordingRepo.GetByEgressID(ctx, info.EgressId)
+	if err != nil && err.Error() == "recording not found" {
+		recording, err = s.pendingRecording(ctx, info)
+	}
+	if err != nil {
+		// Egress запущен не через API записи (например, вручную) - не отслеживаем
+		if err.Error() == "recording not found" {
+			return nil
+		}
+		return err
+	}
+
+	// Webhook-и могут прийти не по порядку: завершенную запись не возвращаем в активную
+	if recording.IsFinished() {
+		return nil
+	}
+
+	applyEgressInfo(recording, info)
+	recording.UpdatedAt = time.Now()
+
+	return s.recordingRepo.Update(ctx, recording)
+}
+
+// pendingRecording находит запись, для которой Start еще не получил ответ Egress, и привязывает
+// к ней egress_id из webhook-а. Неоднозначности нет: одновременно запускается одна запись
+// комнаты и одна запись каждой дорожки.
+func (s *recordingService) pendingRecording(ctx context.Context, info *livekit.EgressInfo) (*domain.Recording, error) {
+	if info.RoomName == "" {
+		return nil, errors.New("recording not found")
+	}
+
+	room, err := s.roomRepo.GetByLiveKitRoomName(ctx, info.RoomName)
+	if err != nil {
+		return nil, errors.New("recording not found")
+	}
+
+	// Трансляции тоже используют room composite, но без файлового вывода
+	kind, trackSID := domain.RecordingKindRoomComposite, (*string)(nil)
+	if track := info.GetTrack(); track != nil {
+		kind, trackSID = domain.RecordingKindTrack, &track.TrackId
+	} else if len(info.GetRoomComposite().GetFileOutputs()) == 0 {
+		return nil, errors.New("recording not found")
+	}
+
+	recording, err := s.recordingRepo.GetPending(ctx, room.ID, kind, trackSID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recordingRepo.AttachEgress(ctx, recording.ID, info.EgressId); err != nil {
+		return nil, err
+	}
+	recording.EgressID = info.EgressId
+
+	return recording, nil
+}
+
+func (s *recordingService) postSystemMessage(ctx context.Context, roomID uuid.UUID, content string) {
+	if _, err := s.chat.PostSystemMessage(ctx, roomID, content); err != nil {
+		s.log.Warn("Failed to post recording system message", "error", err, "room_id", roomID)
+	}
+}
+
+// applyEgressInfo переносит в запись статус, время и результат Egress. Время в EgressInfo - в наносекундах.
+func applyEgressInfo(recording *domain.Recording, info *livekit.EgressInfo) {
+	if info == nil {
+		return
+	}
+
+	recording.Status = recordingStatus(info.Status)
+	if startedAt := egressTime(info.StartedAt); startedAt != nil {
+		recording.StartedAt = startedAt
+	}
+	if endedAt := egressTime(info.EndedAt); endedAt != nil {
+		recording.EndedAt = endedAt
+	}
+	if info.Error != "" {
+		recording.Error = &info.Error
+	}
+
+	file := info.GetFile()
+	if len(info.FileResults) > 0 {
+		file = info.FileResults[0]
+	}
+	if file == nil {
+		return
+	}
+
+	location := file.Location
+	if location == "" {
+		location = file.Filename
+	}
+	if location != "" {
+		recording.FileLocation = &location
+	}
+	if file.Duration > 0 {
+		duration := int(time.Duration(file.Duration) / time.Second)
+		recording.DurationSeconds = &duration
+	}
+	if file.Size > 0 {
+		size := file.Size
+		recording.SizeBytes = &size
+	}
+}
+
+// egressTime переводит время Egress (наносекунды) в time.Time, 0 - время не задано
+func egressTime(nanos int64) *time.Time {
+	if nanos <= 0 {
+		return nil
+	}
+	t := time.Unix(0, nanos)
+	return &t
+}
+
+// recordingStatus - статус записи по статусу Egress. Остановка по лимиту длительности
+// считается завершенной записью: файл сохраняется, причина остается в поле error.
+func recordingStatus(status livekit.EgressStatus) string {
+	switch status {
+	case livekit.EgressStatus_EGRESS_ACTIVE:
+		return domain.RecordingStatusActive
+	case livekit.EgressStatus_EGRESS_ENDING:
+		return domain.RecordingStatusEnding
+	case livekit.EgressStatus_EGRESS_COMPLETE, livekit.EgressStatus_EGRESS_LIMIT_REACHED:
+		return domain.RecordingStatusCompleted
+	case livekit.EgressStatus_EGRESS_FAILED:
+		return domain.RecordingStatusFailed
+	case livekit.EgressStatus_EGRESS_ABORTED:
+		return domain.RecordingStatusAborted
+	default:
+		return domain.RecordingStatusStarting
+	}
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/livekit/protocol/livekit"
+	"github.com/twitchtv/twirp"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+)
+
+type fakeRecordingRepo struct {
+	repository.RecordingRepository
+
+	mu         sync.Mutex
+	recordings map[uuid.UUID]*domain.Recording
+}
+
+func (r *fakeRecordingRepo) Create(ctx context.Context, recording *domain.Recording) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copy := *recording
+	r.recordings[recording.ID] = &copy
+	return nil
+}
+
+func (r *fakeRecordingRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Recording, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recording, ok := r.recordings[id]
+	if !ok {
+		return nil, errors.New("recording not found")
+	}
+	copy := *recording
+	return &copy, nil
+}
+
+func (r *fakeRecordingRepo) GetByEgressID(ctx context.Context, egressID string) (*domain.Recording, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, recording := range r.recordings {
+		if recording.EgressID == egressID {
+			copy := *recording
+			return &copy, nil
+		}
+	}
+	return nil, errors.New("recording not found")
+}
+
+func (r *fakeRecordingRepo) GetPending(ctx context.Context, roomID uuid.UUID, kind string, trackSID *string) (*domain.Recording, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, recording := range r.recordings {
+		sameTrack := (recording.TrackSID == nil && trackSID == nil) ||
+			(recording.TrackSID != nil && trackSID != nil && *recording.TrackSID == *trackSID)
+		if recording.RoomID == roomID && recording.Kind == kind && sameTrack &&
+			recording.EgressID == "" && recording.Status == domain.RecordingStatusStarting {
+			copy := *recording
+			return &copy, nil
+		}
+	}
+	return nil, errors.New("recording not found")
+}
+
+func (r *fakeRecordingRepo) AttachEgress(ctx context.Context, recordingID uuid.UUID, egressID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recording, ok := r.recordings[recordingID]
+	if !ok || (recording.EgressID != "" && recording.EgressID != egressID) {
+		return errors.New("recording not found")
+	}
+	recording.EgressID = egressID
+	return nil
+}
+
+func (r *fakeRecordingRepo) ListByRoom(ctx context.Context, roomID uuid.UUID) ([]*domain.Recording, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*domain.Recording
+	for _, recording := range r.recordings {
+		if recording.RoomID == roomID {
+			copy := *recording
+			result = append(result, &copy)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeRecordingRepo) Update(ctx context.Context, recording *domain.Recording) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.recordings[recording.ID]
+	if !ok {
+		return errors.New("recording not found")
+	}
+	// Как и SQL Update, egress_id не меняется
+	copy := *recording
+	copy.EgressID = stored.EgressID
+	r.recordings[recording.ID] = &copy
+	return nil
+}
+
+type fakeChat struct {
+	ChatService
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (c *fakeChat) PostSystemMessage(ctx context.Context, roomID uuid.UUID, content string) (*domain.ChatMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, content)
+	return &domain.ChatMessage{}, nil
+}
+
+type recordingFixture struct {
+	*roomFixture
+	service RecordingService
+	repo    *fakeRecordingRepo
+}
+
+func newRecordingFixture(t *testing.T) *recordingFixture {
+	t.Helper()
+
+	f := &recordingFixture{
+		roomFixture: newRoomFixture(t),
+		repo:        &fakeRecordingRepo{recordings: make(map[uuid.UUID]*domain.Recording)},
+	}
+	f.service = NewRecordingService(f.repo, f.roomRepo, f.audit, &fakeChat{}, f.lkService, config.LiveKitConfig{RecordingDir: "recordings"}, nopLogger{})
+
+	return f
+}
+
+func TestStartRecordingAttachesEgress(t *testing.T) {
+	f := newRecordingFixture(t)
+
+	recording, err := f.service.Start(context.Background(), f.room.ID, f.room.HostUserID, StartRecordingParams{})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	if recording.EgressID == "" || recording.Status != domain.RecordingStatusStarting {
+		t.Errorf("recording egress_id = %q status = %q, want attached starting recording", recording.EgressID, recording.Status)
+	}
+	stored, _ := f.repo.GetByEgressID(context.Background(), recording.EgressID)
+	if stored == nil || stored.ID != recording.ID {
+		t.Error("recording is not stored under its egress id")
+	}
+}
+
+func TestEgressStartedBeforeStartReturns(t *testing.T) {
+	tests := []struct {
+		name   string
+		params StartRecordingParams
+	}{
+		{name: "room composite"},
+		{name: "track", params: StartRecordingParams{TrackSID: "TR_video"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newRecordingFixture(t)
+			f.livekit.egress.onStart = func(info *livekit.EgressInfo) {
+				started := &livekit.EgressInfo{
+					EgressId:  info.EgressId,
+					RoomName:  info.RoomName,
+					Request:   info.Request,
+					Status:    livekit.EgressStatus_EGRESS_ACTIVE,
+					StartedAt: 1_700_000_000_000_000_000,
+				}
+				if err := f.service.HandleEgressUpdate(context.Background(), started); err != nil {
+					t.Errorf("HandleEgressUpdate: %v", err)
+				}
+			}
+
+			recording, err := f.service.Start(context.Background(), f.room.ID, f.room.HostUserID, tt.params)
+			if err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+
+			if recording.Status != domain.RecordingStatusActive || recording.StartedAt == nil {
+				t.Errorf("recording status = %q started_at = %v, want active from early webhook", recording.Status, recording.StartedAt)
+			}
+		})
+	}
+}
+
+func TestStreamEgressIsNotAttachedToPendingRecording(t *testing.T) {
+	f := newRecordingFixture(t)
+	pending := &domain.Recording{ID: uuid.New(), RoomID: f.room.ID, Kind: domain.RecordingKindRoomComposite, Status: domain.RecordingStatusStarting}
+	f.repo.Create(context.Background(), pending)
+
+	stream := &livekit.EgressInfo{
+		EgressId: "EG_stream",
+		RoomName: f.room.LiveKitRoomName,
+		Status:   livekit.EgressStatus_EGRESS_ACTIVE,
+		Request: &livekit.EgressInfo_RoomComposite{RoomComposite: &livekit.RoomCompositeEgressRequest{
+			StreamOutputs: []*livekit.StreamOutput{{Protocol: livekit.StreamProtocol_RTMP}},
+		}},
+	}
+	if err := f.service.HandleEgressUpdate(context.Background(), stream); err != nil {
+		t.Fatalf("HandleEgressUpdate: %v", err)
+	}
+
+	stored, _ := f.repo.GetByID(context.Background(), pending.ID)
+	if stored.EgressID != "" || stored.Status != domain.RecordingStatusStarting {
+		t.Errorf("pending recording changed by stream egress: %+v", stored)
+	}
+}
+
+func TestStartRecordingEgressFailure(t *testing.T) {
+	f := newRecordingFixture(t)
+	f.livekit.egress.startErr = twirp.InternalError("egress unavailable")
+
+	if _, err := f.service.Start(context.Background(), f.room.ID, f.room.HostUserID, StartRecordingParams{}); err == nil {
+		t.Fatal("expected error when egress fails to start")
+	}
+
+	recordings, _ := f.repo.ListByRoom(context.Background(), f.room.ID)
+	if len(recordings) != 1 || recordings[0].Status != domain.RecordingStatusFailed {
+		t.Fatalf("recordings = %+v, want one failed recording", recordings)
+	}
+
+	// Неудачный запуск не блокирует следующий
+	f.livekit.egress.startErr = nil
+	if _, err := f.service.Start(context.Background(), f.room.ID, f.room.HostUserID, StartRecordingParams{}); err != nil {
+		t.Fatalf("Start after failure: %v", err)
+	}
+}
+
+func TestEgressEndedCompletesRecording(t *testing.T) {
+	f := newRecordingFixture(t)
+
+	recording, err := f.service.Start(context.Background(), f.room.ID, f.room.HostUserID, StartRecordingParams{})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	ended := &livekit.EgressInfo{
+		EgressId: recording.EgressID,
+		RoomName: f.room.LiveKitRoomName,
+		Status:   livekit.EgressStatus_EGRESS_COMPLETE,
+		EndedAt:  1_700_000_060_000_000_000,
+		FileResults: []*livekit.FileInfo{{
+			Location: "s3://bucket/recordings/room.mp4",
+			Duration: int64(60 * 1e9),
+			Size:     1024,
+		}},
+	}
+	if err := f.service.HandleEgressUpdate(context.Background(), ended); err != nil {
+		t.Fatalf("HandleEgressUpdate: %v", err)
+	}
+
+	// Запоздавший egress_updated не возвращает запись в активную
+	late := &livekit.EgressInfo{EgressId: recording.EgressID, Status: livekit.EgressStatus_EGRESS_ACTIVE}
+	if err := f.service.HandleEgressUpdate(context.Background(), late); err != nil {
+		t.Fatalf("HandleEgressUpdate: %v", err)
+	}
+
+	stored, _ := f.repo.GetByID(context.Background(), recording.ID)
+	if stored.Status != domain.RecordingStatusCompleted || stored.DurationSeconds == nil || *stored.DurationSeconds != 60 {
+		t.Errorf("stored recording = %+v, want completed 60s recording", stored)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Поддерживаемые частоты RRULE
+const (
+	recurrenceDaily  = "DAILY"
+	recurrenceWeekly = "WEEKLY"
+)
+
+// Ограничение перебора периодов, чтобы ошибочное правило не зациклило расчет
+const maxRecurrenceIterations = 10000
+
+var recurrenceWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// recurrenceRule - подмножество RFC 5545 RRULE: FREQ=DAILY|WEEKLY, INTERVAL, BYDAY (без номеров), COUNT, UNTIL
+type recurrenceRule struct {
+	freq     string
+	interval int
+	byDay    []time.Weekday
+	count    int
+	until    *time.Time
+}
+
+// parseRecurrenceRule разбирает правило вида "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10" (префикс "RRULE:" допустим).
+// UNTIL в виде даты без времени включает весь день в зоне loc.
+func parseRecurrenceRule(value string, loc *time.Location) (*recurrenceRule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, errors.New("recurrence rule is required")
+	}
+
+	rule := &recurrenceRule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, errors.New("invalid recurrence rule")
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+			if rule.freq != recurrenceDaily && rule.freq != recurrenceWeekly {
+				return nil, errors.New("unsupported recurrence frequency")
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, errors.New("invalid recurrence interval")
+			}
+			rule.interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				weekday, ok := recurrenceWeekdays[day]
+				if !ok {
+					return nil, errors.New("invalid recurrence weekday")
+				}
+				rule.byDay = append(rule.byDay, weekday)
+			}
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return nil, errors.New("invalid recurrence count")
+			}
+			rule.count = count
+		case "UNTIL":
+			until, err := parseRecurrenceUntil(val, loc)
+			if err != nil {
+				return nil, err
+			}
+			rule.until = &until
+		case "WKST":
+			// Неделя всегда начинается с понедельника
+		default:
+			return nil, errors.New("unsupported recurrence rule part " + key)
+		}
+	}
+
+	if rule.freq == "" {
+		return nil, errors.New("recurrence frequency is required")
+	}
+	if rule.count > 0 && rule.until != nil {
+		return nil, errors.New("recurrence COUNT and UNTIL are mutually exclusive")
+	}
+
+	return rule, nil
+}
+
+func parseRecurrenceUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, errors.New("invalid recurrence UNTIL")
+}
+
+// between возвращает вхождения с началом в [from, to]. DTSTART всегда первое вхождение
+// (RFC 5545, 3.8.5.3), даже если не подходит под BYDAY, и отсчет COUNT ведется от него.
+// Время начала сохраняет локальное время dtstart в зоне loc (с учетом перехода на летнее время).
+func (r *recurrenceRule) between(dtstart time.Time, loc *time.Location, from time.Time, to time.Time) []time.Time {
+	start := dtstart.In(loc)
+	weekdays := r.weekdays(start.Weekday())
+
+	var occurrences []time.Time
+	generated := 0
+	// emit учитывает вхождение и сообщает, нужно ли продолжать перебор
+	emit := func(day time.Time) bool {
+		if day.After(to) || (r.until != nil && day.After(*r.until)) {
+			return false
+		}
+		generated++
+		if !day.Before(from) {
+			occurrences = append(occurrences, day)
+		}
+		return r.count == 0 || generated < r.count
+	}
+
+	if !emit(start) {
+		return occurrences
+	}
+
+	// Перебираем периоды правила (день или неделя с понедельника) с шагом INTERVAL
+	periodStart := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	periodDays := 1
+	if r.freq == recurrenceWeekly {
+		periodStart = periodStart.AddDate(0, 0, -(int(start.Weekday()+6) % 7))
+		periodDays = 7
+	}
+	step := periodDays * r.interval
+
+	// Без COUNT вхождения до from считать не нужно: сразу переходим к периоду, содержащему from
+	first := 0
+	if r.count == 0 && from.After(start) {
+		first = daysBetween(periodStart, from.In(loc)) / step
+	}
+
+	for period := first; period < first+maxRecurrenceIterations; period++ {
+		for offset := 0; offset < periodDays; offset++ {
+			date := periodStart.AddDate(0, 0, period*step+offset)
+			if !weekdays[date.Weekday()] {
+				continue
+			}
+
+			day := time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+			if !day.After(start) {
+				continue
+			}
+			if !emit(day) {
+				return occurrences
+			}
+		}
+	}
+
+	return occurrences
+}
+
+// weekdays возвращает дни недели вхождений: BYDAY, для DAILY без BYDAY - все дни,
+// для WEEKLY без BYDAY - день недели dtstart
+func (r *recurrenceRule) weekdays(startWeekday time.Weekday) map[time.Weekday]bool {
+	days := make(map[time.Weekday]bool)
+	switch {
+	case len(r.byDay) > 0:
+		for _, day := range r.byDay {
+			days[day] = true
+		}
+	case r.freq == recurrenceDaily:
+		for _, day := range recurrenceWeekdays {
+			days[day] = true
+		}
+	default:
+		days[startWeekday] = true
+	}
+	return days
+}
+
+// daysBetween - число календарных дней между датами (без учета времени и перехода на летнее время)
+func daysBetween(from time.Time, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
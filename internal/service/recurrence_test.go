@@ -0,0 +1,207 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	utc := time.UTC
+
+	tests := []struct {
+		name    string
+		value   string
+		want    *recurrenceRule
+		wantErr string
+	}{
+		{
+			name:  "daily",
+			value: "FREQ=DAILY",
+			want:  &recurrenceRule{freq: recurrenceDaily, interval: 1},
+		},
+		{
+			name:  "weekly with prefix and lowercase",
+			value: "RRULE:freq=weekly;interval=2;byday=mo,we",
+			want:  &recurrenceRule{freq: recurrenceWeekly, interval: 2, byDay: []time.Weekday{time.Monday, time.Wednesday}},
+		},
+		{
+			name:  "count",
+			value: "FREQ=WEEKLY;COUNT=10;WKST=MO",
+			want:  &recurrenceRule{freq: recurrenceWeekly, interval: 1, count: 10},
+		},
+		{
+			name:  "until date includes whole day",
+			value: "FREQ=DAILY;UNTIL=20260131",
+			want:  &recurrenceRule{freq: recurrenceDaily, interval: 1, until: timePtr(time.Date(2026, 1, 31, 23, 59, 59, 0, utc))},
+		},
+		{
+			name:  "until utc date-time",
+			value: "FREQ=DAILY;UNTIL=20260131T100000Z",
+			want:  &recurrenceRule{freq: recurrenceDaily, interval: 1, until: timePtr(time.Date(2026, 1, 31, 10, 0, 0, 0, utc))},
+		},
+		{name: "empty", value: " ", wantErr: "recurrence rule is required"},
+		{name: "missing freq", value: "COUNT=3", wantErr: "recurrence frequency is required"},
+		{name: "monthly", value: "FREQ=MONTHLY", wantErr: "unsupported recurrence frequency"},
+		{name: "zero interval", value: "FREQ=DAILY;INTERVAL=0", wantErr: "invalid recurrence interval"},
+		{name: "numbered weekday", value: "FREQ=WEEKLY;BYDAY=1MO", wantErr: "invalid recurrence weekday"},
+		{name: "zero count", value: "FREQ=DAILY;COUNT=0", wantErr: "invalid recurrence count"},
+		{name: "bad until", value: "FREQ=DAILY;UNTIL=tomorrow", wantErr: "invalid recurrence UNTIL"},
+		{name: "count and until", value: "FREQ=DAILY;COUNT=2;UNTIL=20260131", wantErr: "recurrence COUNT and UNTIL are mutually exclusive"},
+		{name: "unsupported part", value: "FREQ=DAILY;BYMONTH=1", wantErr: "unsupported recurrence rule part BYMONTH"},
+		{name: "part without value", value: "FREQ=DAILY;COUNT", wantErr: "invalid recurrence rule"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRecurrenceRule(tt.value, utc)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rule = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+	utc := time.UTC
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 10, 0, 0, 0, utc)
+	}
+	// 2026-01-05 - понедельник
+	monday := at(2026, 1, 5)
+	wide := at(2030, 1, 1)
+
+	tests := []struct {
+		name     string
+		rule     string
+		dtstart  time.Time
+		from, to time.Time
+		want     []time.Time
+	}{
+		{
+			name:    "daily count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: monday,
+			from:    monday, to: wide,
+			want: []time.Time{at(2026, 1, 5), at(2026, 1, 6), at(2026, 1, 7)},
+		},
+		{
+			name:    "daily interval until",
+			rule:    "FREQ=DAILY;INTERVAL=2;UNTIL=20260111",
+			dtstart: monday,
+			from:    monday, to: wide,
+			want: []time.Time{at(2026, 1, 5), at(2026, 1, 7), at(2026, 1, 9), at(2026, 1, 11)},
+		},
+		{
+			name:    "daily byday",
+			rule:    "FREQ=DAILY;BYDAY=SA,SU;COUNT=3",
+			dtstart: at(2026, 1, 10),
+			from:    monday, to: wide,
+			want: []time.Time{at(2026, 1, 10), at(2026, 1, 11), at(2026, 1, 17)},
+		},
+		{
+			name:    "weekly without byday uses dtstart weekday",
+			rule:    "FREQ=WEEKLY;COUNT=3",
+			dtstart: at(2026, 1, 7),
+			from:    monday, to: wide,
+			want: []time.Time{at(2026, 1, 7), at(2026, 1, 14), at(2026, 1, 21)},
+		},
+		{
+			name:    "weekly byday",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=5",
+			dtstart: monday,
+			from:    monday, to: wide,
+			want: []time.Time{at(2026, 1, 5), at(2026, 1, 7), at(2026, 1, 9), at(2026, 1, 12), at(2026, 1, 14)},
+		},
+		{
+			name:    "weekly interval counts weeks from monday of dtstart",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=4",
+			dtstart: at(2026, 1, 8),
+			from:    monday, to: wide,
+			want: []time.Time{at(2026, 1, 8), at(2026, 1, 19), at(2026, 1, 22), at(2026, 2, 2)},
+		},
+		{
+			name:    "dtstart not matching byday is still first occurrence",
+			rule:    "FREQ=WEEKLY;BYDAY=TU;COUNT=3",
+			dtstart: monday,
+			from:    monday, to: wide,
+			want: []time.Time{at(2026, 1, 5), at(2026, 1, 6), at(2026, 1, 13)},
+		},
+		{
+			name:    "weekly until",
+			rule:    "FREQ=WEEKLY;BYDAY=MO;UNTIL=20260119T100000Z",
+			dtstart: monday,
+			from:    monday, to: wide,
+			want: []time.Time{at(2026, 1, 5), at(2026, 1, 12), at(2026, 1, 19)},
+		},
+		{
+			name:    "count includes occurrences before from",
+			rule:    "FREQ=DAILY;COUNT=4",
+			dtstart: monday,
+			from:    at(2026, 1, 7), to: wide,
+			want: []time.Time{at(2026, 1, 7), at(2026, 1, 8)},
+		},
+		{
+			name:    "window in the far future",
+			rule:    "FREQ=DAILY",
+			dtstart: at(2000, 1, 1),
+			from:    at(2060, 3, 1), to: at(2060, 3, 3),
+			want: []time.Time{at(2060, 3, 1), at(2060, 3, 2), at(2060, 3, 3)},
+		},
+		{
+			name:    "to before dtstart",
+			rule:    "FREQ=DAILY",
+			dtstart: monday,
+			from:    at(2025, 12, 1), to: at(2025, 12, 31),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRecurrenceRule(tt.rule, utc)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.rule, err)
+			}
+			got := rule.between(tt.dtstart, utc, tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("between = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceBetweenKeepsLocalTimeAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("tzdata not available:", err)
+	}
+
+	rule, err := parseRecurrenceRule("FREQ=WEEKLY;COUNT=2", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Переход на летнее время 2026-03-29
+	dtstart := time.Date(2026, 3, 23, 9, 0, 0, 0, loc)
+	got := rule.between(dtstart, loc, dtstart, dtstart.AddDate(0, 1, 0))
+	if len(got) != 2 {
+		t.Fatalf("occurrences = %v, want 2", got)
+	}
+	if got[1].Hour() != 9 || got[1].Sub(got[0]) != 7*24*time.Hour-time.Hour {
+		t.Errorf("second occurrence = %v, want 09:00 local a week later", got[1])
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,38 +15,58 @@ import (
 )
 
 type RoomService interface {
-	Create(ctx context.Context, hostUserID uuid.UUID, title string, description *string, maxParticipants int) (*domain.Room, error)
+	Create(ctx context.Context, hostUserID uuid.UUID, title string, description *string, maxParticipants int, scheduledStartAt *time.Time, scheduledEndAt *time.Time) (*domain.Room, error)
 	GetByID(ctx context.Context, roomID uuid.UUID) (*domain.Room, error)
 	List(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*domain.Room, error)
-	Update(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, title *string, description *string, maxParticipants *int) (*domain.Room, error)
+	Update(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, title *string, description *string, maxParticipants *int, password *string, settings map[string]interface{}, scheduledStartAt *time.Time, scheduledEndAt *time.Time) (*domain.Room, error)
 	Delete(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error
-	Join(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, displayName string) (*domain.RoomParticipant, error)
+	Join(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, displayName string, creds JoinCredentials) (*domain.RoomParticipant, *domain.WaitingRoomEntry, error)
 	Leave(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error
 	CreateInvite(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, label *string, expiresAt *time.Time, maxUses *int) (*domain.RoomInvite, error)
+	ListInvites(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.RoomInvite, error)
+	RevokeInvite(ctx context.Context, roomID uuid.UUID, inviteID uuid.UUID, userID uuid.UUID) error
+	RedeemInvite(ctx context.Context, token string, userID uuid.UUID, displayName string) (*domain.Room, *domain.RoomParticipant, error)
 	GetParticipants(ctx context.Context, roomID uuid.UUID) ([]*domain.RoomParticipant, error)
+	GetParticipant(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.RoomParticipant, error)
+	PromoteCoHost(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID) (*domain.RoomParticipant, error)
+	DemoteCoHost(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID) (*domain.RoomParticipant, error)
 }
 
 type roomService struct {
 	roomRepo repository.RoomRepository
 	auditRepo repository.AuditRepository
+	realtime RealtimeService
+	dialIn   DialInService
+	media    MediaService
+	password *roomPasswordChecker
+	perms    *roomPermissions
 	cfg      *config.Config
 	log      logger.Logger
 }
 
-func NewRoomService(roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, cfg *config.Config, log logger.Logger) RoomService {
+func NewRoomService(roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, realtime RealtimeService, rateLimit RateLimitService, dialIn DialInService, media MediaService, cfg *config.Config, log logger.Logger) RoomService {
 	return &roomService{
 		roomRepo:  roomRepo,
 		auditRepo: auditRepo,
+		realtime:  realtime,
+		dialIn:    dialIn,
+		media:     media,
+		password:  newRoomPasswordChecker(roomRepo, rateLimit, cfg.Room, log),
+		perms:     newRoomPermissions(roomRepo),
 		cfg:       cfg,
 		log:       log,
 	}
 }
 
-func (s *roomService) Create(ctx context.Context, hostUserID uuid.UUID, title string, description *string, maxParticipants int) (*domain.Room, error) {
+func (s *roomService) Create(ctx context.Context, hostUserID uuid.UUID, title string, description *string, maxParticipants int, scheduledStartAt *time.Time, scheduledEndAt *time.Time) (*domain.Room, error) {
 	if maxParticipants <= 0 || maxParticipants > 500 {
 		maxParticipants = 10
 	}
 
+	if err := validateSchedule(scheduledStartAt, scheduledEndAt); err != nil {
+		return nil, err
+	}
+
 	room := &domain.Room{
 		ID:                 uuid.New(),
 		LiveKitRoomName:    uuid.New().String(),
@@ -52,6 +74,8 @@ func (s *roomService) Create(ctx context.Context, hostUserID uuid.UUID, title st
 		Title:              title,
 		Description:        description,
 		Status:             domain.RoomStatusScheduled,
+		ScheduledStartAt:   scheduledStartAt,
+		ScheduledEndAt:     scheduledEndAt,
 		MaxParticipants:    maxParticipants,
 		WaitingRoomEnabled: true,
 		IsLocked:           false,
@@ -69,7 +93,7 @@ func (s *roomService) Create(ctx context.Context, hostUserID uuid.UUID, title st
 	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
 		EventTime:   time.Now(),
 		ActorUserID: &hostUserID,
-		ActorRole:   domain.ActorRoleHost,
+		ActorRole:   s.perms.ActorRole(ctx, room, hostUserID),
 		RoomID:      &room.ID,
 		EventType:   domain.EventTypeRoomCreated,
 		Payload:     map[string]interface{}{"title": title},
@@ -89,14 +113,17 @@ func (s *roomService) List(ctx context.Context, userID uuid.UUID, limit, offset
 	return s.roomRepo.List(ctx, userID, limit, offset)
 }
 
-func (s *roomService) Update(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, title *string, description *string, maxParticipants *int) (*domain.Room, error) {
+// Update изменяет настройки комнаты. Пустой password снимает пароль с комнаты,
+// settings сливаются с текущими (nil значение удаляет ключ). Время начала можно
+// менять только до старта встречи, время окончания - пока комната не завершена.
+func (s *roomService) Update(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, title *string, description *string, maxParticipants *int, password *string, settings map[string]interface{}, scheduledStartAt *time.Time, scheduledEndAt *time.Time) (*domain.Room, error) {
 	room, err := s.roomRepo.GetByID(ctx, roomID)
 	if err != nil {
 		return nil, err
 	}
 
-	if room.HostUserID != userID {
-		return nil, errors.New("only host can update room")
+	if err := s.perms.Authorize(ctx, room, userID, ActionUpdateRoom); err != nil {
+		return nil, err
 	}
 
 	if title != nil {
@@ -110,11 +137,47 @@ func (s *roomService) Update(ctx context.Context, roomID uuid.UUID, userID uuid.
 			room.MaxParticipants = *maxParticipants
 		}
 	}
+	if password != nil {
+		hash, err := hashRoomPassword(*password)
+		if err != nil {
+			return nil, err
+		}
+		room.PasswordHash = hash
+	}
+	if scheduledStartAt != nil || scheduledEndAt != nil {
+		if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+			return nil, errors.New("room is not available")
+		}
+		if scheduledStartAt != nil {
+			if room.Status != domain.RoomStatusScheduled {
+				return nil, errors.New("cannot reschedule started room")
+			}
+			room.ScheduledStartAt = scheduledStartAt
+		}
+		if scheduledEndAt != nil {
+			room.ScheduledEndAt = scheduledEndAt
+		}
+		if err := validateSchedule(room.ScheduledStartAt, room.ScheduledEndAt); err != nil {
+			return nil, err
+		}
+	}
+	// Настройки пишутся отдельно по ключам: ограничения чата и вход по телефону меняются
+	// другими сервисами, и перезапись settings целиком затерла бы их изменения
+	set, unset, err := roomSettingsChanges(room, settings)
+	if err != nil {
+		return nil, err
+	}
 	room.UpdatedAt = time.Now()
 
 	if err := s.roomRepo.Update(ctx, room); err != nil {
 		return nil, err
 	}
+	if len(set) > 0 || len(unset) > 0 {
+		room.Settings, err = s.roomRepo.UpdateSettings(ctx, roomID, set, unset)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return room, nil
 }
@@ -125,37 +188,70 @@ func (s *roomService) Delete(ctx context.Context, roomID uuid.UUID, userID uuid.
 		return err
 	}
 
-	if room.HostUserID != userID {
-		return errors.New("only host can delete room")
+	if err := s.perms.Authorize(ctx, room, userID, ActionDeleteRoom); err != nil {
+		return err
+	}
+
+	// Удаленное вхождение серии было бы создано заново, поэтому его можно только отменить
+	if room.SeriesID != nil {
+		return errors.New("series occurrence cannot be deleted, cancel it instead")
+	}
+
+	// Строки room_dial_ins и room_ingresses удалятся каскадом, а правило звонков и входы в LiveKit - нет
+	if err := s.dialIn.Release(ctx, room); err != nil && err.Error() != "dial-in not found" {
+		s.log.Warn("Failed to release room dial-in", "error", err, "room_id", roomID)
+	}
+	if err := s.media.RevokeRoomIngresses(ctx, room); err != nil {
+		s.log.Warn("Failed to revoke room ingresses", "error", err, "room_id", roomID)
 	}
 
 	return s.roomRepo.Delete(ctx, roomID)
 }
 
-func (s *roomService) Join(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, displayName string) (*domain.RoomParticipant, error) {
+// Join добавляет пользователя в комнату. Если включена waiting room, вместо участника
+// возвращается заявка на вход, о решении по которой гость узнает через события комнаты.
+func (s *roomService) Join(ctx context.Context, roomID uuid.UUID, userID uuid.UUID, displayName string, creds JoinCredentials) (*domain.RoomParticipant, *domain.WaitingRoomEntry, error) {
 	// First check if room exists in database
 	room, err := s.roomRepo.GetByID(ctx, roomID)
 	if err != nil {
 		s.log.Error("Failed to get room by ID", "room_id", roomID, "error", err)
 		// Return the error as-is (will be "room not found" if room doesn't exist)
-		return nil, err
+		return nil, nil, err
 	}
 
 	s.log.Info("Room found in database", "room_id", roomID, "title", room.Title, "status", room.Status)
 
 	if room.Status != domain.RoomStatusActive && room.Status != domain.RoomStatusScheduled {
-		return nil, errors.New("room is not available")
+		return nil, nil, errors.New("room is not available")
+	}
+
+	// В сессионный зал попадают только по распределению через токен зала
+	if room.ParentRoomID != nil {
+		return nil, nil, errors.New("breakout rooms are joined via breakout token")
 	}
 
 	// Проверка на уже существующего участника
 	existingParticipant, err := s.roomRepo.GetParticipant(ctx, roomID, userID)
 	if err == nil && existingParticipant.LeftAt == nil {
 		// Участник уже в комнате
-		return existingParticipant, nil
+		return existingParticipant, nil, nil
+	}
+
+	if err := s.checkAdmission(ctx, room, userID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.password.Check(ctx, room, userID, creds); err != nil {
+		return nil, nil, err
 	}
 
 	// Проверка waiting room
 	if room.WaitingRoomEnabled && room.HostUserID != userID {
+		// Повторный запрос не создает новую заявку, пока старая не обработана
+		if entry, err := s.roomRepo.GetPendingWaitingRoomEntry(ctx, roomID, userID); err == nil {
+			return nil, entry, nil
+		}
+
 		entry := &domain.WaitingRoomEntry{
 			ID:          uuid.New(),
 			RoomID:      roomID,
@@ -165,43 +261,226 @@ func (s *roomService) Join(ctx context.Context, roomID uuid.UUID, userID uuid.UU
 			RequestedAt: time.Now(),
 		}
 		if err := s.roomRepo.CreateWaitingRoomEntry(ctx, entry); err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		publishWaitingRoomEntry(ctx, s.roomRepo, s.realtime, s.log, entry)
+		return nil, entry, nil
+	}
+
+	participant, err := s.addParticipant(ctx, room, userID, displayName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return participant, nil, nil
+}
+
+// Настройки комнаты, которые должны быть булевыми
+var boolRoomSettings = map[string]bool{
+	domain.RoomSettingWebinarMode:      true,
+	domain.RoomSettingAllowCamera:      true,
+	domain.RoomSettingAllowMicrophone:  true,
+	domain.RoomSettingAllowScreenShare: true,
+}
+
+// Настройки комнаты, которые должны быть неотрицательными целыми числами, и их максимум.
+// Граница нужна и SQL: attachment_retention_days приводится к числу в запросе очистки вложений.
+var intRoomSettings = map[string]int{
+	domain.RoomSettingJoinBeforeStartMinutes:  24 * 60,
+	domain.RoomSettingAttachmentRetentionDays: 3650,
+}
+
+// Настройки, которые выставляет DialInService при включении входа по телефону, и ограничения
+// чата, которые меняются через ChatService с аудитом. Передать их можно только с текущим
+// значением (клиент отправил настройки целиком).
+var readOnlyRoomSettings = map[string]bool{
+	domain.RoomSettingDialInEnabled:       true,
+	domain.RoomSettingDialInNumber:        true,
+	domain.RoomSettingChatEnabled:         true,
+	domain.RoomSettingChatSlowModeSeconds: true,
+	domain.RoomSettingChatDisabledUsers:   true,
+}
+
+func validateRoomSettings(room *domain.Room, settings map[string]interface{}) error {
+	for key, value := range settings {
+		if readOnlyRoomSettings[key] && !reflect.DeepEqual(value, room.Settings[key]) {
+			return errors.New("setting " + key + " is read-only")
+		}
+		if _, ok := value.(bool); boolRoomSettings[key] && value != nil && !ok {
+			return errors.New("setting " + key + " must be a boolean")
 		}
-		return nil, errors.New("waiting for approval")
+		if max, limited := intRoomSettings[key]; limited && value != nil {
+			number, ok := value.(float64)
+			if !ok || number < 0 || number > float64(max) || number != float64(int(number)) {
+				return errors.New("setting " + key + " must be an integer from 0 to " + strconv.Itoa(max))
+			}
+		}
+	}
+	return nil
+}
+
+func mergeRoomSettings(room *domain.Room, settings map[string]interface{}) error {
+	if err := validateRoomSettings(room, settings); err != nil {
+		return err
+	}
+
+	if room.Settings == nil {
+		room.Settings = make(map[string]interface{})
+	}
+	for key, value := range settings {
+		if value == nil {
+			delete(room.Settings, key)
+			continue
+		}
+		room.Settings[key] = value
+	}
+
+	return nil
+}
+
+// roomSettingsChanges проверяет настройки и раскладывает их на ключи для UpdateSettings:
+// null удаляет ключ, настройки только для чтения пропускаются
+func roomSettingsChanges(room *domain.Room, settings map[string]interface{}) (map[string]interface{}, []string, error) {
+	if err := validateRoomSettings(room, settings); err != nil {
+		return nil, nil, err
+	}
+
+	set := make(map[string]interface{})
+	var unset []string
+	for key, value := range settings {
+		if readOnlyRoomSettings[key] {
+			continue
+		}
+		if value == nil {
+			unset = append(unset, key)
+			continue
+		}
+		set[key] = value
+	}
+
+	return set, unset, nil
+}
+
+// validateSchedule проверяет время встречи: окончание в будущем и позже начала
+func validateSchedule(startAt *time.Time, endAt *time.Time) error {
+	if endAt == nil {
+		return nil
+	}
+	if !endAt.After(time.Now()) {
+		return errors.New("scheduled end must be in the future")
+	}
+	if startAt != nil && !endAt.After(*startAt) {
+		return errors.New("scheduled end must be after start")
+	}
+	return nil
+}
+
+// checkJoinWindow не пускает в комнату раньше, чем за join_before_start_minutes до начала,
+// и после запланированного окончания
+func checkJoinWindow(room *domain.Room, now time.Time) error {
+	if room.ScheduledEndAt != nil && now.After(*room.ScheduledEndAt) {
+		return errors.New("room is not available")
+	}
+
+	minutes, ok := room.SettingInt(domain.RoomSettingJoinBeforeStartMinutes)
+	if !ok || room.Status != domain.RoomStatusScheduled || room.ScheduledStartAt == nil {
+		return nil
+	}
+	if now.Before(room.ScheduledStartAt.Add(-time.Duration(minutes) * time.Minute)) {
+		return errors.New("room has not started yet")
+	}
+	return nil
+}
+
+// checkRoomCapacity не пускает нового участника, когда в комнате уже max_participants активных
+func checkRoomCapacity(ctx context.Context, roomRepo repository.RoomRepository, room *domain.Room) error {
+	if room.MaxParticipants <= 0 {
+		return nil
+	}
+
+	participants, err := roomRepo.GetParticipantsByRoom(ctx, room.ID)
+	if err != nil {
+		return errors.New("failed to check room capacity")
+	}
+	if len(participants) >= room.MaxParticipants {
+		return errors.New("room is full")
+	}
+
+	return nil
+}
+
+// checkAdmission запрещает новый вход в закрытую или заполненную комнату, вне расписания встречи
+// и пользователям с баном
+func (s *roomService) checkAdmission(ctx context.Context, room *domain.Room, userID uuid.UUID) error {
+	if room.HostUserID == userID {
+		return nil
+	}
+
+	if room.IsLocked {
+		return errors.New("room is locked")
+	}
+
+	if err := checkJoinWindow(room, time.Now()); err != nil {
+		return err
+	}
+
+	if err := checkRoomCapacity(ctx, s.roomRepo, room); err != nil {
+		return err
+	}
+
+	banned, err := s.roomRepo.IsBanned(ctx, room.ID, userID)
+	if err != nil {
+		return errors.New("failed to check participant ban")
+	}
+	if banned {
+		return errors.New("you are banned from this room")
+	}
+
+	return nil
+}
+
+// addParticipant создает участника и активирует комнату при первом присоединении
+func (s *roomService) addParticipant(ctx context.Context, room *domain.Room, userID uuid.UUID, displayName string) (*domain.RoomParticipant, error) {
+	participant := newRoomParticipant(room, userID, displayName)
+	if err := s.roomRepo.CreateParticipant(ctx, participant); err != nil {
+		return nil, err
 	}
 
+	s.activate(ctx, room)
+	return participant, nil
+}
+
+// newRoomParticipant готовит запись участника; хост комнаты получает роль host
+func newRoomParticipant(room *domain.Room, userID uuid.UUID, displayName string) *domain.RoomParticipant {
 	// Определяем роль
 	role := domain.ParticipantRoleParticipant
 	if room.HostUserID == userID {
 		role = domain.ParticipantRoleHost
 	}
 
-	// Создание участника
-	participant := &domain.RoomParticipant{
+	return &domain.RoomParticipant{
 		ID:           uuid.New(),
-		RoomID:       roomID,
+		RoomID:       room.ID,
 		UserID:       &userID,
 		Role:         role,
 		DisplayName:  displayName,
 		JoinedAt:     time.Now(),
 		InitialMuted: false,
 	}
+}
 
-	if err := s.roomRepo.CreateParticipant(ctx, participant); err != nil {
-		return nil, err
+// activate обновляет статус комнаты на active при первом присоединении
+func (s *roomService) activate(ctx context.Context, room *domain.Room) {
+	if room.Status != domain.RoomStatusScheduled {
+		return
 	}
 
-	// Обновляем статус комнаты на active при первом присоединении
-	if room.Status == domain.RoomStatusScheduled {
-		room.Status = domain.RoomStatusActive
-		now := time.Now()
-		room.ActualStartAt = &now
-		if err := s.roomRepo.Update(ctx, room); err != nil {
-			s.log.Warn("Failed to update room status", "error", err)
-		}
+	room.Status = domain.RoomStatusActive
+	now := time.Now()
+	room.ActualStartAt = &now
+	if err := s.roomRepo.Update(ctx, room); err != nil {
+		s.log.Warn("Failed to update room status", "error", err)
 	}
-
-	return participant, nil
 }
 
 func (s *roomService) Leave(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) error {
@@ -224,8 +503,8 @@ func (s *roomService) CreateInvite(ctx context.Context, roomID uuid.UUID, userID
 		return nil, err
 	}
 
-	if room.HostUserID != userID {
-		return nil, errors.New("only host can create invites")
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageInvites); err != nil {
+		return nil, err
 	}
 
 	invite := &domain.RoomInvite{
@@ -247,7 +526,173 @@ func (s *roomService) CreateInvite(ctx context.Context, roomID uuid.UUID, userID
 	return invite, nil
 }
 
+func (s *roomService) ListInvites(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.RoomInvite, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageInvites); err != nil {
+		return nil, err
+	}
+
+	invites, err := s.roomRepo.ListInvites(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if invites == nil {
+		invites = []*domain.RoomInvite{}
+	}
+
+	return invites, nil
+}
+
+func (s *roomService) RevokeInvite(ctx context.Context, roomID uuid.UUID, inviteID uuid.UUID, userID uuid.UUID) error {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageInvites); err != nil {
+		return err
+	}
+
+	invite, err := s.roomRepo.GetInviteByID(ctx, inviteID)
+	if err != nil {
+		return err
+	}
+	if invite.RoomID != roomID {
+		return errors.New("invite not found")
+	}
+
+	return s.roomRepo.RevokeInvite(ctx, inviteID)
+}
+
+// RedeemInvite добавляет держателя приглашения в комнату в обход waiting room.
+// Использование засчитывается только при реальном входе: участник, уже находящийся
+// в комнате, не расходует лимит приглашения.
+func (s *roomService) RedeemInvite(ctx context.Context, token string, userID uuid.UUID, displayName string) (*domain.Room, *domain.RoomParticipant, error) {
+	invite, err := s.roomRepo.GetInviteByToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkInvite(invite); err != nil {
+		return nil, nil, err
+	}
+
+	room, err := s.roomRepo.GetByID(ctx, invite.RoomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if room.Status != domain.RoomStatusActive && room.Status != domain.RoomStatusScheduled {
+		return nil, nil, errors.New("room is not available")
+	}
+
+	if existing, err := s.roomRepo.GetParticipant(ctx, room.ID, userID); err == nil && existing.LeftAt == nil {
+		return room, existing, nil
+	}
+
+	if err := s.checkAdmission(ctx, room, userID); err != nil {
+		return nil, nil, err
+	}
+
+	// Использование засчитывается в одной транзакции с созданием участника
+	participant := newRoomParticipant(room, userID, displayName)
+	if err := s.roomRepo.RedeemInvite(ctx, invite.ID, participant); err != nil {
+		return nil, nil, err
+	}
+	s.activate(ctx, room)
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   domain.ActorRoleUser,
+		RoomID:      &room.ID,
+		EventType:   domain.EventTypeRoomJoined,
+		Payload:     map[string]interface{}{"invite_id": invite.ID, "participant_id": participant.ID},
+	})
+
+	return room, participant, nil
+}
+
+// checkInvite проверяет, что приглашение еще можно использовать
+func checkInvite(invite *domain.RoomInvite) error {
+	if invite.RevokedAt != nil {
+		return errors.New("invite revoked")
+	}
+	if invite.ExpiresAt != nil && !invite.ExpiresAt.After(time.Now()) {
+		return errors.New("invite expired")
+	}
+	if invite.MaxUses != nil && invite.UsedCount >= *invite.MaxUses {
+		return errors.New("invite usage limit reached")
+	}
+	return nil
+}
+
 func (s *roomService) GetParticipants(ctx context.Context, roomID uuid.UUID) ([]*domain.RoomParticipant, error) {
 	return s.roomRepo.GetParticipantsByRoom(ctx, roomID)
 }
 
+func (s *roomService) GetParticipant(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.RoomParticipant, error) {
+	return s.roomRepo.GetParticipant(ctx, roomID, userID)
+}
+
+func (s *roomService) PromoteCoHost(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID) (*domain.RoomParticipant, error) {
+	return s.setParticipantRole(ctx, roomID, participantID, userID, domain.ParticipantRoleCoHost)
+}
+
+func (s *roomService) DemoteCoHost(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID) (*domain.RoomParticipant, error) {
+	return s.setParticipantRole(ctx, roomID, participantID, userID, domain.ParticipantRoleParticipant)
+}
+
+// setParticipantRole назначает или снимает co-host у активного участника комнаты
+func (s *roomService) setParticipantRole(ctx context.Context, roomID uuid.UUID, participantID uuid.UUID, userID uuid.UUID, role string) (*domain.RoomParticipant, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageCoHosts); err != nil {
+		return nil, err
+	}
+
+	participant, err := s.roomRepo.GetParticipantByID(ctx, participantID)
+	if err != nil || participant.RoomID != roomID {
+		return nil, errors.New("participant not found")
+	}
+	if participant.LeftAt != nil || participant.UserID == nil {
+		return nil, errors.New("participant is not in the room")
+	}
+	if participant.Role == domain.ParticipantRoleHost {
+		return nil, errors.New("cannot change host role")
+	}
+	if participant.Role == role {
+		return participant, nil
+	}
+
+	participant.Role = role
+	if err := s.roomRepo.UpdateParticipant(ctx, participant); err != nil {
+		return nil, errors.New("failed to update participant")
+	}
+
+	if err := s.realtime.Publish(ctx, roomID, domain.RoomEventTypeRole, participant); err != nil {
+		s.log.Warn("Failed to publish role change", "error", err, "room_id", roomID)
+	}
+
+	eventType := domain.EventTypeCoHostDemoted
+	if role == domain.ParticipantRoleCoHost {
+		eventType = domain.EventTypeCoHostPromoted
+	}
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   eventType,
+		Payload:     map[string]interface{}{"participant_id": participant.ID, "user_id": *participant.UserID},
+	})
+
+	return participant, nil
+}
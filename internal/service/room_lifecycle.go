@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// Причины завершения комнаты
+const (
+	RoomEndReasonScheduleEnded = "schedule_ended"
+	RoomEndReasonInactive      = "inactive"
+	RoomEndReasonCancelled     = "cancelled"
+	// Сессионный зал закрыт хостом или вместе с основной комнатой
+	RoomEndReasonBreakoutClosed = "breakout_closed"
+)
+
+// Сколько комнат завершается за один проход
+const roomSweepBatchSize = 100
+
+// RoomLifecycleService завершает встречи: отмена хостом и автоматическое завершение
+// по расписанию или после простоя
+type RoomLifecycleService interface {
+	Cancel(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.Room, error)
+	// EndExpiredRooms завершает комнаты, у которых вышло время или которые простаивают, возвращает их число
+	EndExpiredRooms(ctx context.Context) (int, error)
+	// EndRoom завершает комнату без проверки прав (например, сессионный зал)
+	EndRoom(ctx context.Context, room *domain.Room, reason string) error
+}
+
+type roomLifecycleService struct {
+	roomRepo  repository.RoomRepository
+	pollRepo  repository.PollRepository
+	auditRepo repository.AuditRepository
+	realtime  RealtimeService
+	livekit   LiveKitService
+	dialIn    DialInService
+	media     MediaService
+	perms     *roomPermissions
+	cfg       config.RoomConfig
+	log       logger.Logger
+}
+
+func NewRoomLifecycleService(roomRepo repository.RoomRepository, pollRepo repository.PollRepository, auditRepo repository.AuditRepository, realtime RealtimeService, livekit LiveKitService, dialIn DialInService, media MediaService, cfg config.RoomConfig, log logger.Logger) RoomLifecycleService {
+	return &roomLifecycleService{
+		roomRepo:  roomRepo,
+		pollRepo:  pollRepo,
+		auditRepo: auditRepo,
+		realtime:  realtime,
+		livekit:   livekit,
+		dialIn:    dialIn,
+		media:     media,
+		perms:     newRoomPermissions(roomRepo),
+		cfg:       cfg,
+		log:       log,
+	}
+}
+
+func (s *roomLifecycleService) Cancel(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) (*domain.Room, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionCancelRoom); err != nil {
+		return nil, err
+	}
+
+	if room.Status != domain.RoomStatusScheduled && room.Status != domain.RoomStatusActive {
+		return nil, errors.New("room is not available")
+	}
+
+	if err := s.endRoom(ctx, room, domain.RoomStatusCancelled, RoomEndReasonCancelled); err != nil {
+		return nil, err
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &room.ID,
+		EventType:   domain.EventTypeRoomCancelled,
+		Payload:     map[string]interface{}{"title": room.Title},
+	})
+
+	return room, nil
+}
+
+func (s *roomLifecycleService) EndExpiredRooms(ctx context.Context) (int, error) {
+	now := time.Now()
+	rooms, err := s.roomRepo.ListRoomsToEnd(ctx, now, now.Add(-s.cfg.InactivityTimeout), roomSweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	ended := 0
+	for _, room := range rooms {
+		reason := RoomEndReasonInactive
+		if room.ScheduledEndAt != nil && room.ScheduledEndAt.Before(now) {
+			reason = RoomEndReasonScheduleEnded
+		}
+		// У зала scheduled_end_at выставляется обратным отсчетом закрытия
+		if room.ParentRoomID != nil && reason == RoomEndReasonScheduleEnded {
+			reason = RoomEndReasonBreakoutClosed
+		}
+
+		if err := s.endRoom(ctx, room, domain.RoomStatusEnded, reason); err != nil {
+			s.log.Warn("Failed to end room", "error", err, "room_id", room.ID)
+			continue
+		}
+
+		s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+			EventTime: time.Now(),
+			ActorRole: domain.ActorRoleSystem,
+			RoomID:    &room.ID,
+			EventType: domain.EventTypeRoomEnded,
+			Payload:   map[string]interface{}{"reason": reason},
+		})
+		ended++
+	}
+
+	return ended, nil
+}
+
+func (s *roomLifecycleService) EndRoom(ctx context.Context, room *domain.Room, reason string) error {
+	return s.endRoom(ctx, room, domain.RoomStatusEnded, reason)
+}
+
+// endRoom переводит комнату в конечный статус, закрывает участия и заявки в waiting room
+// и отключает участников от медиасервера
+func (s *roomLifecycleService) endRoom(ctx context.Context, room *domain.Room, status string, reason string) error {
+	now := time.Now()
+
+	room.Status = status
+	if room.ActualStartAt != nil && room.ActualEndAt == nil {
+		room.ActualEndAt = &now
+	}
+	room.UpdatedAt = now
+	if err := s.roomRepo.Update(ctx, room); err != nil {
+		return errors.New("failed to update room")
+	}
+
+	if _, err := s.roomRepo.CloseOpenParticipants(ctx, room.ID, now, "room_"+reason); err != nil {
+		s.log.Warn("Failed to close room participants", "error", err, "room_id", room.ID)
+	}
+
+	expired, err := s.roomRepo.ExpireWaitingRoomEntries(ctx, room.ID, now)
+	if err != nil {
+		s.log.Warn("Failed to expire waiting room entries", "error", err, "room_id", room.ID)
+	}
+	for _, entry := range expired {
+		publishWaitingRoomEntry(ctx, s.roomRepo, s.realtime, s.log, entry)
+	}
+
+	// Результаты опросов фиксируются на момент окончания встречи
+	if _, err := s.pollRepo.CloseOpenPolls(ctx, room.ID, now); err != nil {
+		s.log.Warn("Failed to close room polls", "error", err, "room_id", room.ID)
+	}
+
+	if err := s.realtime.Publish(ctx, room.ID, domain.RoomEventTypeRoomStatus, &domain.RoomStatusPayload{
+		Status: status,
+		Reason: reason,
+	}); err != nil {
+		s.log.Warn("Failed to publish room status", "error", err, "room_id", room.ID)
+	}
+
+	// PIN освобождается, чтобы звонки не открывали завершенную комнату в LiveKit заново
+	if err := s.dialIn.Release(ctx, room); err != nil && err.Error() != "dial-in not found" {
+		s.log.Warn("Failed to release room dial-in", "error", err, "room_id", room.ID)
+	}
+	if err := s.media.RevokeRoomIngresses(ctx, room); err != nil {
+		s.log.Warn("Failed to revoke room ingresses", "error", err, "room_id", room.ID)
+	}
+
+	// Сессионные залы не переживают основную комнату
+	breakouts, err := s.roomRepo.ListBreakouts(ctx, room.ID)
+	if err != nil {
+		s.log.Warn("Failed to list breakouts", "error", err, "room_id", room.ID)
+	}
+	for _, breakout := range breakouts {
+		if err := s.endRoom(ctx, breakout, domain.RoomStatusEnded, RoomEndReasonBreakoutClosed); err != nil {
+			s.log.Warn("Failed to end breakout", "error", err, "room_id", breakout.ID)
+		}
+	}
+
+	// Медиасервер закрываем в последнюю очередь: статус в БД уже не даст переподключиться
+	if err := s.livekit.DeleteRoom(ctx, room.LiveKitRoomName); err != nil {
+		s.log.Warn("Failed to close LiveKit room", "error", err, "room_id", room.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+const (
+	roomPasswordMinLength = 4
+	// bcrypt учитывает только первые 72 байта
+	roomPasswordMaxBytes = 72
+)
+
+// JoinCredentials - необязательные данные для входа в комнату, защищенную паролем
+type JoinCredentials struct {
+	Password    string
+	InviteToken string
+	ClientIP    string
+}
+
+// roomPasswordChecker проверяет пароль комнаты с ограничением числа неудачных попыток по room+IP
+type roomPasswordChecker struct {
+	roomRepo  repository.RoomRepository
+	rateLimit RateLimitService
+	cfg       config.RoomConfig
+	log       logger.Logger
+}
+
+func newRoomPasswordChecker(roomRepo repository.RoomRepository, rateLimit RateLimitService, cfg config.RoomConfig, log logger.Logger) *roomPasswordChecker {
+	return &roomPasswordChecker{
+		roomRepo:  roomRepo,
+		rateLimit: rateLimit,
+		cfg:       cfg,
+		log:       log,
+	}
+}
+
+// Check пропускает хоста и держателя действующего приглашения, остальные должны знать пароль
+func (c *roomPasswordChecker) Check(ctx context.Context, room *domain.Room, userID uuid.UUID, creds JoinCredentials) error {
+	if room.PasswordHash == nil || room.HostUserID == userID {
+		return nil
+	}
+
+	if creds.InviteToken != "" {
+		invite, err := c.roomRepo.GetInviteByToken(ctx, creds.InviteToken)
+		if err == nil && invite.RoomID == room.ID && checkInvite(invite) == nil {
+			return nil
+		}
+	}
+
+	if creds.Password == "" {
+		return errors.New("room password required")
+	}
+
+	key := fmt.Sprintf("room_password:%s:%s", room.ID, creds.ClientIP)
+	window := int(c.cfg.PasswordAttemptWindow.Seconds())
+
+	allowed, err := c.rateLimit.CheckLimit(ctx, key, c.cfg.PasswordMaxAttempts, window)
+	if err != nil {
+		c.log.Error("Failed to check room password rate limit", "error", err, "room_id", room.ID)
+		return errors.New("failed to verify room password")
+	}
+	if !allowed {
+		return errors.New("too many password attempts")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(*room.PasswordHash), []byte(creds.Password)); err != nil {
+		if _, err := c.rateLimit.Increment(ctx, key, window); err != nil {
+			c.log.Warn("Failed to count room password attempt", "error", err, "room_id", room.ID)
+		}
+		c.log.Warn("Invalid room password", "room_id", room.ID, "client_ip", creds.ClientIP)
+		return errors.New("invalid room password")
+	}
+
+	return nil
+}
+
+// hashRoomPassword возвращает bcrypt-хеш пароля комнаты, пустой пароль снимает защиту
+func hashRoomPassword(password string) (*string, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	if utf8.RuneCountInString(password) < roomPasswordMinLength {
+		return nil, fmt.Errorf("room password must be at least %d characters", roomPasswordMinLength)
+	}
+	if len(password) > roomPasswordMaxBytes {
+		return nil, errors.New("room password is too long")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("failed to hash room password")
+	}
+
+	hashStr := string(hash)
+	return &hashStr, nil
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+// Сколько вхождений серии создается за один раз
+const maxSeriesOccurrencesPerRun = 100
+
+// CreateSeriesParams - параметры новой серии встреч
+type CreateSeriesParams struct {
+	Title              string
+	Description        *string
+	MaxParticipants    int
+	WaitingRoomEnabled *bool
+	Settings           map[string]interface{}
+	RRule              string
+	StartAt            time.Time
+	DurationMinutes    int
+	Timezone           string
+}
+
+// RoomSeriesService управляет повторяющимися встречами. Вхождения создаются заранее
+// на ROOM_SERIES_HORIZON вперед как обычные комнаты, поэтому отдельное вхождение
+// изменяется (PUT /rooms/:id) и отменяется (POST /rooms/:id/cancel) без изменения серии.
+type RoomSeriesService interface {
+	Create(ctx context.Context, hostUserID uuid.UUID, params CreateSeriesParams) (*domain.RoomSeries, []*domain.Room, error)
+	GetByID(ctx context.Context, seriesID uuid.UUID, userID uuid.UUID) (*domain.RoomSeries, []*domain.Room, error)
+	// Cancel отменяет серию и все ее еще не начавшиеся вхождения
+	Cancel(ctx context.Context, seriesID uuid.UUID, userID uuid.UUID) error
+	// ListUpcoming возвращает предстоящие встречи пользователя, включая вхождения его серий
+	ListUpcoming(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.Room, error)
+	// MaterializeActive продлевает горизонт всех активных серий; вызывается фоновым процессом,
+	// чтобы вхождения появлялись, даже если серию никто не открывает
+	MaterializeActive(ctx context.Context) (int, error)
+}
+
+type roomSeriesService struct {
+	seriesRepo repository.RoomSeriesRepository
+	roomRepo   repository.RoomRepository
+	auditRepo  repository.AuditRepository
+	cfg        config.RoomConfig
+	log        logger.Logger
+}
+
+func NewRoomSeriesService(seriesRepo repository.RoomSeriesRepository, roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, cfg config.RoomConfig, log logger.Logger) RoomSeriesService {
+	return &roomSeriesService{
+		seriesRepo: seriesRepo,
+		roomRepo:   roomRepo,
+		auditRepo:  auditRepo,
+		cfg:        cfg,
+		log:        log,
+	}
+}
+
+func (s *roomSeriesService) Create(ctx context.Context, hostUserID uuid.UUID, params CreateSeriesParams) (*domain.RoomSeries, []*domain.Room, error) {
+	if params.Timezone == "" {
+		params.Timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(params.Timezone)
+	if err != nil {
+		return nil, nil, errors.New("invalid timezone")
+	}
+	if _, err := parseRecurrenceRule(params.RRule, loc); err != nil {
+		return nil, nil, err
+	}
+	if params.DurationMinutes <= 0 || params.DurationMinutes > 24*60 {
+		return nil, nil, errors.New("duration must be between 1 and 1440 minutes")
+	}
+	if params.MaxParticipants <= 0 || params.MaxParticipants > 500 {
+		params.MaxParticipants = 10
+	}
+
+	series := &domain.RoomSeries{
+		ID:                 uuid.New(),
+		HostUserID:         hostUserID,
+		Title:              params.Title,
+		Description:        params.Description,
+		MaxParticipants:    params.MaxParticipants,
+		WaitingRoomEnabled: params.WaitingRoomEnabled == nil || *params.WaitingRoomEnabled,
+		Settings:           make(map[string]interface{}),
+		RRule:              params.RRule,
+		StartAt:            params.StartAt,
+		DurationMinutes:    params.DurationMinutes,
+		Timezone:           params.Timezone,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	// Настройки проверяются так же, как при обновлении комнаты
+	if params.Settings != nil {
+		template := &domain.Room{Settings: series.Settings}
+		if err := mergeRoomSettings(template, params.Settings); err != nil {
+			return nil, nil, err
+		}
+		series.Settings = template.Settings
+	}
+
+	if err := s.seriesRepo.Create(ctx, series); err != nil {
+		return nil, nil, errors.New("failed to create series")
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &hostUserID,
+		ActorRole:   domain.ActorRoleHost,
+		EventType:   domain.EventTypeSeriesCreated,
+		Payload:     map[string]interface{}{"series_id": series.ID, "title": series.Title, "rrule": series.RRule},
+	})
+
+	occurrences, err := s.materialize(ctx, series)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return series, occurrences, nil
+}
+
+func (s *roomSeriesService) GetByID(ctx context.Context, seriesID uuid.UUID, userID uuid.UUID) (*domain.RoomSeries, []*domain.Room, error) {
+	series, err := s.getOwnSeries(ctx, seriesID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	occurrences, err := s.materialize(ctx, series)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return series, occurrences, nil
+}
+
+func (s *roomSeriesService) Cancel(ctx context.Context, seriesID uuid.UUID, userID uuid.UUID) error {
+	series, err := s.getOwnSeries(ctx, seriesID, userID)
+	if err != nil {
+		return err
+	}
+	if series.CancelledAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.seriesRepo.Cancel(ctx, seriesID, now); err != nil {
+		return errors.New("failed to cancel series")
+	}
+
+	occurrences, err := s.roomRepo.ListSeriesOccurrences(ctx, seriesID)
+	if err != nil {
+		return err
+	}
+
+	cancelled := 0
+	for _, room := range occurrences {
+		if room.Status != domain.RoomStatusScheduled {
+			continue
+		}
+		room.Status = domain.RoomStatusCancelled
+		room.UpdatedAt = now
+		if err := s.roomRepo.Update(ctx, room); err != nil {
+			s.log.Warn("Failed to cancel series occurrence", "error", err, "room_id", room.ID)
+			continue
+		}
+		cancelled++
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   now,
+		ActorUserID: &userID,
+		ActorRole:   domain.ActorRoleHost,
+		EventType:   domain.EventTypeSeriesCancelled,
+		Payload:     map[string]interface{}{"series_id": seriesID, "cancelled_occurrences": cancelled},
+	})
+
+	return nil
+}
+
+func (s *roomSeriesService) ListUpcoming(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.Room, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	seriesList, err := s.seriesRepo.ListActiveByHost(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, series := range seriesList {
+		if _, err := s.materialize(ctx, series); err != nil {
+			s.log.Warn("Failed to materialize series", "error", err, "series_id", series.ID)
+		}
+	}
+
+	rooms, err := s.roomRepo.ListUpcoming(ctx, userID, time.Now(), limit)
+	if err != nil {
+		return nil, err
+	}
+	if rooms == nil {
+		rooms = []*domain.Room{}
+	}
+
+	return rooms, nil
+}
+
+func (s *roomSeriesService) MaterializeActive(ctx context.Context) (int, error) {
+	seriesList, err := s.seriesRepo.ListActive(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Ошибка одной серии не должна останавливать продление остальных
+	materialized := 0
+	for _, series := range seriesList {
+		if ctx.Err() != nil {
+			return materialized, ctx.Err()
+		}
+		if _, err := s.materialize(ctx, series); err != nil {
+			s.log.Warn("Failed to materialize series", "error", err, "series_id", series.ID)
+			continue
+		}
+		materialized++
+	}
+
+	return materialized, nil
+}
+
+func (s *roomSeriesService) getOwnSeries(ctx context.Context, seriesID uuid.UUID, userID uuid.UUID) (*domain.RoomSeries, error) {
+	series, err := s.seriesRepo.GetByID(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series.HostUserID != userID {
+		return nil, errors.New("only host can manage series")
+	}
+	return series, nil
+}
+
+// materialize создает комнаты для вхождений серии на горизонт вперед и возвращает все
+// вхождения. Вхождение, которое уже есть (в том числе отмененное или перенесенное),
+// повторно не создается: оно определяется по исходному времени occurrence_start_at.
+func (s *roomSeriesService) materialize(ctx context.Context, series *domain.RoomSeries) ([]*domain.Room, error) {
+	occurrences, err := s.roomRepo.ListSeriesOccurrences(ctx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+	if series.CancelledAt != nil {
+		return occurrences, nil
+	}
+
+	loc, err := time.LoadLocation(series.Timezone)
+	if err != nil {
+		return nil, errors.New("invalid timezone")
+	}
+	rule, err := parseRecurrenceRule(series.RRule, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[int64]bool, len(occurrences))
+	for _, room := range occurrences {
+		if room.OccurrenceStartAt != nil {
+			existing[room.OccurrenceStartAt.Unix()] = true
+		}
+	}
+
+	now := time.Now()
+	duration := time.Duration(series.DurationMinutes) * time.Minute
+	created := 0
+	for _, startAt := range rule.between(series.StartAt, loc, now.Add(-duration), now.Add(s.cfg.SeriesHorizon)) {
+		if existing[startAt.Unix()] || created >= maxSeriesOccurrencesPerRun {
+			continue
+		}
+
+		startAt := startAt.UTC()
+		endAt := startAt.Add(duration)
+		room := &domain.Room{
+			ID:                 uuid.New(),
+			LiveKitRoomName:    uuid.New().String(),
+			HostUserID:         series.HostUserID,
+			Title:              series.Title,
+			Description:        series.Description,
+			Status:             domain.RoomStatusScheduled,
+			ScheduledStartAt:   &startAt,
+			ScheduledEndAt:     &endAt,
+			MaxParticipants:    series.MaxParticipants,
+			WaitingRoomEnabled: series.WaitingRoomEnabled,
+			Settings:           copySettings(series.Settings),
+			SeriesID:           &series.ID,
+			OccurrenceStartAt:  &startAt,
+			CreatedAt:          now,
+			UpdatedAt:          now,
+		}
+
+		// Параллельный запрос мог уже создать вхождение - уникальный индекс не даст дубликат
+		if err := s.roomRepo.Create(ctx, room); err != nil {
+			s.log.Warn("Failed to create series occurrence", "error", err, "series_id", series.ID, "start_at", startAt)
+			continue
+		}
+		occurrences = append(occurrences, room)
+		created++
+	}
+
+	if created > 0 {
+		s.log.Info("Series occurrences created", "series_id", series.ID, "count", created)
+		sortRoomsByOccurrence(occurrences)
+	}
+
+	return occurrences, nil
+}
+
+func copySettings(settings map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		copied[key] = value
+	}
+	return copied
+}
+
+func sortRoomsByOccurrence(rooms []*domain.Room) {
+	sort.Slice(rooms, func(i, j int) bool {
+		a, b := rooms[i].OccurrenceStartAt, rooms[j].OccurrenceStartAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Before(*b)
+	})
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"video_conference/pkg/logger"
+)
+
+// Период продления горизонта повторяющихся встреч
+const seriesMaterializeInterval = time.Hour
+
+// RoomSweeper - фоновый процесс, который периодически завершает просроченные и простаивающие комнаты,
+// создает новые вхождения повторяющихся встреч и удаляет вложения чата с истекшим сроком хранения.
+// У каждой задачи свой период, так что отключение автозавершения комнат не останавливает остальные.
+type RoomSweeper interface {
+	Start()
+	// Stop останавливает процесс и дожидается окончания текущего прохода
+	Stop()
+}
+
+type roomSweeper struct {
+	lifecycle       RoomLifecycleService
+	series          RoomSeriesService
+	attachments     ChatAttachmentService
+	interval        time.Duration
+	cleanupInterval time.Duration
+	log             logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRoomSweeper(lifecycle RoomLifecycleService, series RoomSeriesService, attachments ChatAttachmentService, interval time.Duration, cleanupInterval time.Duration, log logger.Logger) RoomSweeper {
+	return &roomSweeper{
+		lifecycle:       lifecycle,
+		series:          series,
+		attachments:     attachments,
+		interval:        interval,
+		cleanupInterval: cleanupInterval,
+		log:             log,
+	}
+}
+
+func (s *roomSweeper) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	if s.interval > 0 {
+		s.run(ctx, s.interval, s.sweep)
+		s.log.Info("Room sweeper started", "interval", s.interval)
+	} else {
+		s.log.Info("Room sweeper disabled")
+	}
+
+	s.run(ctx, seriesMaterializeInterval, s.materialize)
+
+	if s.cleanupInterval > 0 {
+		s.run(ctx, s.cleanupInterval, s.cleanup)
+		s.log.Info("Chat attachment cleanup started", "interval", s.cleanupInterval)
+	} else {
+		s.log.Info("Chat attachment cleanup disabled")
+	}
+}
+
+// run вызывает task каждые interval до отмены ctx
+func (s *roomSweeper) run(ctx context.Context, interval time.Duration, task func(ctx context.Context)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				task(ctx)
+			}
+		}
+	}()
+}
+
+func (s *roomSweeper) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.log.Info("Room sweeper stopped")
+}
+
+func (s *roomSweeper) sweep(ctx context.Context) {
+	ended, err := s.lifecycle.EndExpiredRooms(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("Failed to end expired rooms", "error", err)
+		}
+	} else if ended > 0 {
+		s.log.Info("Ended expired rooms", "count", ended)
+	}
+}
+
+func (s *roomSweeper) materialize(ctx context.Context) {
+	materialized, err := s.series.MaterializeActive(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("Failed to materialize room series", "error", err)
+		}
+	} else if materialized > 0 {
+		s.log.Debug("Materialized room series", "count", materialized)
+	}
+}
+
+func (s *roomSweeper) cleanup(ctx context.Context) {
+	deleted, err := s.attachments.DeleteExpired(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("Failed to delete expired chat attachments", "error", err)
+		}
+	} else if deleted > 0 {
+		s.log.Info("Deleted expired chat attachments", "count", deleted)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"video_conference/internal/config"
 	"video_conference/internal/repository"
 	"video_conference/pkg/logger"
+	"video_conference/pkg/storage"
 )
 
 type Services struct {
@@ -11,6 +12,7 @@ type Services struct {
 	User             UserService
 	Room             RoomService
 	Chat             ChatService
+	ChatAttachment   ChatAttachmentService
 	Media            MediaService
 	AnonymousMedia   AnonymousMediaService
 	AnonymousRoom    AnonymousRoomService
@@ -20,21 +22,66 @@ type Services struct {
 	ScreenCapture    ScreenCaptureService
 	AudioCapture     AudioCaptureService
 	WebRTC           WebRTCService
+	Realtime         RealtimeService
+	WaitingRoom      WaitingRoomService
+	LiveKit          LiveKitService
+	Moderation       ModerationService
+	LiveKitWebhook   LiveKitWebhookService
+	RoomLifecycle    RoomLifecycleService
+	RoomSeries       RoomSeriesService
+	RoomSweeper      RoomSweeper
+	Calendar         CalendarService
+	Recording        RecordingService
+	LiveStream       LiveStreamService
+	DialIn           DialInService
+	Breakout         BreakoutService
+	Poll             PollService
+	HandRaise        HandRaiseService
+	Reaction         ReactionService
 }
 
-func NewServices(repos *repository.Repositories, cfg *config.Config, log logger.Logger) *Services {
+func NewServices(repos *repository.Repositories, fileStorage storage.Storage, cfg *config.Config, log logger.Logger) *Services {
+	realtime := NewRealtimeService(repos.Realtime, log)
+	rateLimit := NewRateLimitService(repos.RateLimit, log)
+	livekit := NewLiveKitService(cfg.LiveKit, log)
+	dialIn := NewDialInService(repos.DialIn, repos.Room, repos.Audit, livekit, cfg.SIP, log)
+	media := NewMediaService(repos.Room, repos.Ingress, repos.Breakout, repos.Audit, livekit, cfg.LiveKit, log)
+	roomLifecycle := NewRoomLifecycleService(repos.Room, repos.Poll, repos.Audit, realtime, livekit, dialIn, media, cfg.Room, log)
+	roomSeries := NewRoomSeriesService(repos.RoomSeries, repos.Room, repos.Audit, cfg.Room, log)
+	chatAttachment := NewChatAttachmentService(repos.ChatAttachment, repos.Chat, repos.Room, fileStorage, cfg.Chat, cfg.Storage.URLTTL, log)
+	chat := NewChatService(repos.Chat, repos.Room, repos.Audit, realtime, rateLimit, chatAttachment, cfg.Chat, log)
+	recording := NewRecordingService(repos.Recording, repos.Room, repos.Audit, chat, livekit, cfg.LiveKit, log)
+	liveStream := NewLiveStreamService(repos.LiveStream, repos.Room, repos.Audit, livekit, cfg.LiveKit, log)
+
 	services := &Services{
 		Auth:          NewAuthService(repos.User, cfg.JWT, log),
 		User:          NewUserService(repos.User, repos.Audit, log),
-		Room:          NewRoomService(repos.Room, repos.Audit, cfg, log),
-		Chat:          NewChatService(repos.Chat, repos.Room, repos.Audit, log),
-		Media:         NewMediaService(repos.Room, cfg.LiveKit, log),
+		Room:          NewRoomService(repos.Room, repos.Audit, realtime, rateLimit, dialIn, media, cfg, log),
+		Chat:          chat,
+		ChatAttachment: chatAttachment,
+		Media:         media,
 		Stats:         NewStatsService(repos.Stats, log),
-		RateLimit:     NewRateLimitService(repos.RateLimit, log),
+		RateLimit:     rateLimit,
 		Audit:         NewAuditService(repos.Audit, log),
 		ScreenCapture: NewScreenCaptureService(log),
 		AudioCapture:  NewAudioCaptureService(log),
 		WebRTC:        NewWebRTCService(log),
+		Realtime:      realtime,
+		WaitingRoom:   NewWaitingRoomService(repos.Room, repos.Audit, realtime, cfg.Room, log),
+		LiveKit:       livekit,
+		Moderation:    NewModerationService(repos.Room, repos.Audit, realtime, livekit, log),
+		LiveKitWebhook: NewLiveKitWebhookService(repos.Room, repos.AnonymousRoom, repos.Ingress, repos.Audit, livekit, recording, liveStream, log),
+		RoomLifecycle:  roomLifecycle,
+		RoomSeries:     roomSeries,
+		RoomSweeper:    NewRoomSweeper(roomLifecycle, roomSeries, chatAttachment, cfg.Room.SweepInterval, cfg.Chat.AttachmentCleanupInterval, log),
+		Calendar:       NewCalendarService(repos.Room, repos.Calendar, cfg.Server, log),
+		Recording:      recording,
+		LiveStream:     liveStream,
+		DialIn:         dialIn,
+		Breakout:       NewBreakoutService(repos.Room, repos.Breakout, repos.Audit, realtime, chat, roomLifecycle, log),
+		Poll:           NewPollService(repos.Poll, repos.Room, repos.Audit, realtime, log),
+		HandRaise:      NewHandRaiseService(repos.HandRaise, repos.Room, realtime, livekit, log),
+		Reaction:       NewReactionService(repos.Reaction, repos.Room, realtime, rateLimit, cfg.Room, log),
 	}
 	
 	// Инициализируем анонимные сервисы только если есть AnonymousRoom repository
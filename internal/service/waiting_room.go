@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"video_conference/internal/config"
+	"video_conference/internal/domain"
+	"video_conference/internal/repository"
+	"video_conference/pkg/logger"
+)
+
+type WaitingRoomService interface {
+	List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.WaitingRoomEntry, error)
+	GetOwnEntry(ctx context.Context, roomID uuid.UUID, entryID uuid.UUID, userID uuid.UUID) (*domain.WaitingRoomEntry, error)
+	Approve(ctx context.Context, roomID uuid.UUID, entryID uuid.UUID, userID uuid.UUID, reason *string) (*domain.WaitingRoomEntry, *domain.RoomParticipant, error)
+	Reject(ctx context.Context, roomID uuid.UUID, entryID uuid.UUID, userID uuid.UUID, reason *string) (*domain.WaitingRoomEntry, error)
+}
+
+type waitingRoomService struct {
+	roomRepo  repository.RoomRepository
+	auditRepo repository.AuditRepository
+	realtime  RealtimeService
+	perms     *roomPermissions
+	cfg       config.RoomConfig
+	log       logger.Logger
+}
+
+func NewWaitingRoomService(roomRepo repository.RoomRepository, auditRepo repository.AuditRepository, realtime RealtimeService, cfg config.RoomConfig, log logger.Logger) WaitingRoomService {
+	return &waitingRoomService{
+		roomRepo:  roomRepo,
+		auditRepo: auditRepo,
+		realtime:  realtime,
+		perms:     newRoomPermissions(roomRepo),
+		cfg:       cfg,
+		log:       log,
+	}
+}
+
+func (s *waitingRoomService) List(ctx context.Context, roomID uuid.UUID, userID uuid.UUID) ([]*domain.WaitingRoomEntry, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageWaitingRoom); err != nil {
+		return nil, err
+	}
+
+	s.expireEntries(ctx, roomID)
+
+	entries, err := s.roomRepo.GetWaitingRoomEntries(ctx, roomID, domain.WaitingRoomStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []*domain.WaitingRoomEntry{}
+	}
+
+	return entries, nil
+}
+
+func (s *waitingRoomService) GetOwnEntry(ctx context.Context, roomID uuid.UUID, entryID uuid.UUID, userID uuid.UUID) (*domain.WaitingRoomEntry, error) {
+	s.expireEntries(ctx, roomID)
+
+	entry, err := s.roomRepo.GetWaitingRoomEntryByID(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.RoomID != roomID || entry.UserID == nil || *entry.UserID != userID {
+		return nil, errors.New("waiting room entry not found")
+	}
+
+	return entry, nil
+}
+
+func (s *waitingRoomService) Approve(ctx context.Context, roomID uuid.UUID, entryID uuid.UUID, userID uuid.UUID, reason *string) (*domain.WaitingRoomEntry, *domain.RoomParticipant, error) {
+	room, entry, err := s.getPendingEntry(ctx, roomID, entryID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Создаем участника, если гость еще не в комнате; запись появляется только вместе с решением
+	var participant, created *domain.RoomParticipant
+	if entry.UserID != nil {
+		participant, _ = s.roomRepo.GetParticipant(ctx, roomID, *entry.UserID)
+	}
+	if participant == nil {
+		created = &domain.RoomParticipant{
+			ID:           uuid.New(),
+			RoomID:       roomID,
+			UserID:       entry.UserID,
+			Role:         domain.ParticipantRoleParticipant,
+			DisplayName:  entry.DisplayName,
+			JoinedAt:     time.Now(),
+			InitialMuted: false,
+		}
+		participant = created
+	}
+
+	if err := s.decide(ctx, entry, domain.WaitingRoomStatusApproved, userID, reason, created); err != nil {
+		return nil, nil, err
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeWaitingRoomApproved,
+		Payload:     s.auditPayload(entry, &participant.ID),
+	})
+
+	return entry, participant, nil
+}
+
+func (s *waitingRoomService) Reject(ctx context.Context, roomID uuid.UUID, entryID uuid.UUID, userID uuid.UUID, reason *string) (*domain.WaitingRoomEntry, error) {
+	room, entry, err := s.getPendingEntry(ctx, roomID, entryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.decide(ctx, entry, domain.WaitingRoomStatusRejected, userID, reason, nil); err != nil {
+		return nil, err
+	}
+
+	s.auditRepo.CreateLog(ctx, &domain.AuditLog{
+		EventTime:   time.Now(),
+		ActorUserID: &userID,
+		ActorRole:   s.perms.ActorRole(ctx, room, userID),
+		RoomID:      &roomID,
+		EventType:   domain.EventTypeWaitingRoomRejected,
+		Payload:     s.auditPayload(entry, nil),
+	})
+
+	return entry, nil
+}
+
+// getPendingEntry проверяет права модератора и возвращает комнату и ее необработанную заявку
+func (s *waitingRoomService) getPendingEntry(ctx context.Context, roomID uuid.UUID, entryID uuid.UUID, userID uuid.UUID) (*domain.Room, *domain.WaitingRoomEntry, error) {
+	room, err := s.roomRepo.GetByID(ctx, roomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.perms.Authorize(ctx, room, userID, ActionManageWaitingRoom); err != nil {
+		return nil, nil, err
+	}
+
+	s.expireEntries(ctx, roomID)
+
+	entry, err := s.roomRepo.GetWaitingRoomEntryByID(ctx, entryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry.RoomID != roomID {
+		return nil, nil, errors.New("waiting room entry not found")
+	}
+
+	switch entry.Status {
+	case domain.WaitingRoomStatusPending:
+		return room, entry, nil
+	case domain.WaitingRoomStatusExpired:
+		return nil, nil, errors.New("waiting room entry expired")
+	default:
+		return nil, nil, errors.New("waiting room entry already decided")
+	}
+}
+
+// decide фиксирует решение по заявке; если заявку уже обработал другой хост, возвращает ошибку
+func (s *waitingRoomService) decide(ctx context.Context, entry *domain.WaitingRoomEntry, status string, userID uuid.UUID, reason *string, participant *domain.RoomParticipant) error {
+	now := time.Now()
+	entry.Status = status
+	entry.DecidedAt = &now
+	entry.DecidedByUserID = &userID
+	entry.Reason = reason
+
+	if err := s.roomRepo.DecideWaitingRoomEntry(ctx, entry, participant); err != nil {
+		return err
+	}
+
+	publishWaitingRoomEntry(ctx, s.roomRepo, s.realtime, s.log, entry)
+	return nil
+}
+
+// expireEntries переводит просроченные заявки комнаты в expired и уведомляет гостей
+func (s *waitingRoomService) expireEntries(ctx context.Context, roomID uuid.UUID) {
+	if s.cfg.WaitingRoomEntryTTL <= 0 {
+		return
+	}
+
+	expired, err := s.roomRepo.ExpireWaitingRoomEntries(ctx, roomID, time.Now().Add(-s.cfg.WaitingRoomEntryTTL))
+	if err != nil {
+		s.log.Warn("Failed to expire waiting room entries", "error", err, "room_id", roomID)
+		return
+	}
+
+	for _, entry := range expired {
+		publishWaitingRoomEntry(ctx, s.roomRepo, s.realtime, s.log, entry)
+	}
+}
+
+func (s *waitingRoomService) auditPayload(entry *domain.WaitingRoomEntry, participantID *uuid.UUID) map[string]interface{} {
+	payload := map[string]interface{}{
+		"entry_id":     entry.ID,
+		"display_name": entry.DisplayName,
+	}
+	if entry.UserID != nil {
+		payload["user_id"] = *entry.UserID
+	}
+	if entry.Reason != nil {
+		payload["reason"] = *entry.Reason
+	}
+	if participantID != nil {
+		payload["participant_id"] = *participantID
+	}
+	return payload
+}
+
+// publishWaitingRoomEntry уведомляет хостов, co-host-ов и ожидающего гостя об изменении заявки.
+// Остальным участникам данные гостя не рассылаются; гость слушает события по ID своей заявки.
+func publishWaitingRoomEntry(ctx context.Context, roomRepo repository.RoomRepository, realtime RealtimeService, log logger.Logger, entry *domain.WaitingRoomEntry) {
+	recipients := []uuid.UUID{entry.ID}
+
+	participants, err := roomRepo.GetParticipantsByRoom(ctx, entry.RoomID)
+	if err != nil {
+		log.Warn("Failed to resolve waiting room moderators", "error", err, "room_id", entry.RoomID)
+	}
+	for _, participant := range participants {
+		if !participant.IsKicked && roleCan(participant.Role, ActionManageWaitingRoom) {
+			recipients = append(recipients, participant.ID)
+		}
+	}
+
+	if err := realtime.PublishTo(ctx, entry.RoomID, recipients, domain.RoomEventTypeWaitingRoom, entry); err != nil {
+		log.Warn("Failed to publish waiting room entry", "error", err, "room_id", entry.RoomID, "entry_id", entry.ID)
+	}
+}
@@ -0,0 +1,9 @@
+-- ============================================
+-- Отзыв приглашений в комнаты
+-- ============================================
+
+-- Отозванное приглашение остается в таблице для истории, но больше не принимается
+ALTER TABLE room_invites
+  ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN room_invites.revoked_at IS 'Время отзыва приглашения хостом';
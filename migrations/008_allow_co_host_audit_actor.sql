@@ -0,0 +1,8 @@
+-- ============================================
+-- Co-host как автор действий в журнале аудита
+-- ============================================
+
+-- Действия co-host записываются с его собственной ролью, а не как действия хоста
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_actor_role_check;
+ALTER TABLE audit_log
+  ADD CONSTRAINT audit_log_actor_role_check CHECK (actor_role IN ('user','host','co_host','technical_admin','system'));
@@ -0,0 +1,34 @@
+-- ============================================
+-- Повторяющиеся встречи (серии)
+-- ============================================
+
+-- Шаблон серии: параметры комнаты и правило повторения (подмножество RFC 5545 RRULE)
+CREATE TABLE IF NOT EXISTS room_series (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    host_user_id UUID NOT NULL REFERENCES users(id),
+    title TEXT NOT NULL,
+    description TEXT,
+    max_participants INTEGER NOT NULL DEFAULT 10 CHECK (max_participants > 0 AND max_participants <= 500),
+    waiting_room_enabled BOOLEAN NOT NULL DEFAULT true,
+    settings JSONB NOT NULL DEFAULT '{}'::jsonb,
+    rrule TEXT NOT NULL,
+    start_at TIMESTAMPTZ NOT NULL,
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0 AND duration_minutes <= 1440),
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    cancelled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_series_host ON room_series(host_user_id);
+
+-- Вхождение серии - обычная комната. occurrence_start_at - исходное время по правилу,
+-- оно не меняется при переносе отдельного вхождения и не дает создать его повторно.
+ALTER TABLE rooms
+  ADD COLUMN IF NOT EXISTS series_id UUID REFERENCES room_series(id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS occurrence_start_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rooms_series_occurrence ON rooms(series_id, occurrence_start_at);
+
+COMMENT ON TABLE room_series IS 'Серии повторяющихся встреч';
+COMMENT ON COLUMN rooms.occurrence_start_at IS 'Исходное время вхождения серии по правилу повторения';
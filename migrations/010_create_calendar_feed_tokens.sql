@@ -0,0 +1,12 @@
+-- ============================================
+-- Токены подписки на календарь (webcal)
+-- ============================================
+
+-- У пользователя одна действующая ссылка подписки; хранится только хеш токена
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE calendar_feed_tokens IS 'Токены ссылок подписки на календарь встреч пользователя';
@@ -0,0 +1,29 @@
+-- ============================================
+-- Записи встреч (LiveKit Egress)
+-- ============================================
+
+-- Запись всей комнаты (room_composite) или отдельной дорожки (track).
+-- Статус обновляется по webhook-ам egress_started/egress_updated/egress_ended.
+-- Строка вставляется до вызова StartEgress, чтобы webhook egress_started, пришедший
+-- раньше ответа API, нашел ее. До ответа egress_id пуст, UNIQUE допускает несколько NULL.
+CREATE TABLE IF NOT EXISTS recordings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    egress_id TEXT UNIQUE,
+    kind TEXT NOT NULL CHECK (kind IN ('room_composite','track')),
+    track_sid TEXT,
+    status TEXT NOT NULL DEFAULT 'starting' CHECK (status IN ('starting','active','ending','completed','failed','aborted')),
+    file_location TEXT,
+    duration_seconds INTEGER,
+    size_bytes BIGINT,
+    error TEXT,
+    started_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ,
+    ended_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recordings_room ON recordings(room_id, created_at DESC);
+
+COMMENT ON TABLE recordings IS 'Записи встреч через LiveKit Egress';
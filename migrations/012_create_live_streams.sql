@@ -0,0 +1,27 @@
+-- ============================================
+-- Трансляции встреч (LiveKit Egress: RTMP и HLS)
+-- ============================================
+
+-- rtmp_url - адрес сервера без ключа, ключ трансляции хранится только в зашифрованном виде.
+-- Для HLS в playlist_location сохраняется адрес плейлиста в хранилище Egress.
+-- Строка вставляется до вызова StartEgress, до ответа API egress_id пуст.
+CREATE TABLE IF NOT EXISTS live_streams (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    egress_id TEXT UNIQUE,
+    protocol TEXT NOT NULL CHECK (protocol IN ('rtmp','hls')),
+    rtmp_url TEXT,
+    stream_key_encrypted TEXT,
+    playlist_location TEXT,
+    status TEXT NOT NULL DEFAULT 'starting' CHECK (status IN ('starting','active','ending','completed','failed','aborted')),
+    error TEXT,
+    started_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ,
+    ended_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_live_streams_room ON live_streams(room_id, created_at DESC);
+
+COMMENT ON TABLE live_streams IS 'Трансляции встреч в RTMP и HLS через LiveKit Egress';
@@ -0,0 +1,20 @@
+-- ============================================
+-- Подключение к встрече по телефону (SIP dial-in)
+-- ============================================
+
+-- Источник участия: веб-клиент или звонок через SIP. Для звонков сохраняется
+-- только маскированный номер звонящего.
+ALTER TABLE room_participants
+  ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'web' CHECK (source IN ('web','phone')),
+  ADD COLUMN IF NOT EXISTS caller_id TEXT;
+
+-- PIN комнаты для входа по телефону и правило маршрутизации звонков в LiveKit SIP
+CREATE TABLE IF NOT EXISTS room_dial_ins (
+    room_id UUID PRIMARY KEY REFERENCES rooms(id) ON DELETE CASCADE,
+    phone_number TEXT NOT NULL,
+    pin TEXT NOT NULL UNIQUE,
+    sip_dispatch_rule_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE room_dial_ins IS 'Номер и PIN для подключения к комнате по телефону';
@@ -0,0 +1,31 @@
+-- ============================================
+-- Входящие трансляции внешних ведущих (LiveKit Ingress: RTMP и WHIP)
+-- ============================================
+
+-- Ingress публикуется в комнату отдельным участником с ролью ingest и источником ingress
+ALTER TABLE room_participants DROP CONSTRAINT IF EXISTS room_participants_role_check;
+ALTER TABLE room_participants
+  ADD CONSTRAINT room_participants_role_check CHECK (role IN ('host','co_host','participant','ingest'));
+
+ALTER TABLE room_participants DROP CONSTRAINT IF EXISTS room_participants_source_check;
+ALTER TABLE room_participants
+  ADD CONSTRAINT room_participants_source_check CHECK (source IN ('web','phone','ingress'));
+
+-- Ключ потока хранится только в зашифрованном виде. Отозванный ingress удаляется в LiveKit,
+-- строка остается для истории (revoked_at).
+CREATE TABLE IF NOT EXISTS room_ingresses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    ingress_id TEXT NOT NULL UNIQUE,
+    input_type TEXT NOT NULL CHECK (input_type IN ('rtmp','whip')),
+    url TEXT NOT NULL,
+    stream_key_encrypted TEXT NOT NULL,
+    participant_name TEXT NOT NULL,
+    created_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_ingresses_room ON room_ingresses(room_id, created_at DESC);
+
+COMMENT ON TABLE room_ingresses IS 'Входящие трансляции (RTMP/WHIP) внешних ведущих через LiveKit Ingress';
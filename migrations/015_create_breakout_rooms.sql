@@ -0,0 +1,23 @@
+-- ============================================
+-- Сессионные залы (breakout rooms)
+-- ============================================
+
+-- Сессионный зал - обычная комната со своей комнатой LiveKit и чатом, привязанная к основной
+ALTER TABLE rooms
+  ADD COLUMN IF NOT EXISTS parent_room_id UUID REFERENCES rooms(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_rooms_parent ON rooms(parent_room_id) WHERE parent_room_id IS NOT NULL;
+
+-- Распределение участников основной комнаты по залам: не больше одного зала на пользователя
+CREATE TABLE IF NOT EXISTS breakout_assignments (
+    parent_room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    breakout_room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (parent_room_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_breakout_assignments_room ON breakout_assignments(breakout_room_id);
+
+COMMENT ON COLUMN rooms.parent_room_id IS 'Основная комната для сессионного зала';
+COMMENT ON TABLE breakout_assignments IS 'Распределение участников по сессионным залам';
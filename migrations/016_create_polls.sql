@@ -0,0 +1,45 @@
+-- ============================================
+-- Опросы во время встречи
+-- ============================================
+
+-- Опрос создается черновиком, открывается и закрывается хостом или co-host.
+-- Закрытые опросы остаются для выгрузки результатов после встречи.
+CREATE TABLE IF NOT EXISTS polls (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    created_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    question TEXT NOT NULL,
+    multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+    anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft','open','closed')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    opened_at TIMESTAMPTZ,
+    closed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_polls_room ON polls(room_id, created_at);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL,
+    text TEXT NOT NULL,
+    UNIQUE (poll_id, position)
+);
+
+-- Голос хранит пользователя и в анонимном опросе: так один пользователь голосует один раз.
+-- Наружу автор голоса анонимного опроса не отдается.
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    display_name TEXT NOT NULL,
+    voted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (poll_id, option_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_poll_votes_user ON poll_votes(poll_id, user_id);
+
+COMMENT ON TABLE polls IS 'Опросы в комнатах';
+COMMENT ON TABLE poll_options IS 'Варианты ответа опросов';
+COMMENT ON TABLE poll_votes IS 'Голоса участников в опросах';
@@ -0,0 +1,14 @@
+-- ============================================
+-- Статистика реакций во время встречи
+-- ============================================
+
+-- Сами реакции не хранятся, только число реакций каждого вида за минуту встречи
+CREATE TABLE IF NOT EXISTS room_reaction_stats (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    minute TIMESTAMPTZ NOT NULL,
+    emoji TEXT NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (room_id, minute, emoji)
+);
+
+COMMENT ON TABLE room_reaction_stats IS 'Число реакций в комнатах по минутам';
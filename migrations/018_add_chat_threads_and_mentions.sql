@@ -0,0 +1,22 @@
+-- ============================================
+-- Ветки ответов и упоминания в чате
+-- ============================================
+
+-- reply_to_message_id - сообщение, на которое ответили; thread_root_id - первое сообщение ветки.
+-- Ветки одноуровневые: ответ на ответ попадает в ту же ветку.
+ALTER TABLE chat_messages
+  ADD COLUMN IF NOT EXISTS reply_to_message_id BIGINT REFERENCES chat_messages(id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS thread_root_id BIGINT REFERENCES chat_messages(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_chat_thread ON chat_messages(thread_root_id, created_at) WHERE thread_root_id IS NOT NULL;
+
+-- Упомянутые в сообщении участники (@имя разрешается в room_participants.id)
+CREATE TABLE IF NOT EXISTS chat_message_mentions (
+    message_id BIGINT NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    participant_id UUID NOT NULL REFERENCES room_participants(id) ON DELETE CASCADE,
+    PRIMARY KEY (message_id, participant_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_mentions_participant ON chat_message_mentions(participant_id, message_id DESC);
+
+COMMENT ON TABLE chat_message_mentions IS 'Упоминания участников в сообщениях чата';
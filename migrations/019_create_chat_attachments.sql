@@ -0,0 +1,29 @@
+-- ============================================
+-- Вложения сообщений чата
+-- ============================================
+
+-- Файл загружается до отправки сообщения (message_id NULL) и привязывается к нему при отправке.
+-- Сами файлы лежат во внешнем хранилище (локальный каталог или S3) по storage_key.
+-- Если файл не удалось удалить из хранилища, очистка откладывает вложение до cleanup_retry_at,
+-- чтобы несколько недоступных файлов не занимали каждый проход.
+CREATE TABLE IF NOT EXISTS chat_attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    message_id BIGINT REFERENCES chat_messages(id) ON DELETE SET NULL,
+    uploader_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL,
+    thumbnail_key TEXT,
+    width INT,
+    height INT,
+    cleanup_retry_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_attachments_message ON chat_attachments(message_id) WHERE message_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_chat_attachments_room ON chat_attachments(room_id);
+CREATE INDEX IF NOT EXISTS idx_chat_attachments_pending ON chat_attachments(created_at) WHERE message_id IS NULL;
+
+COMMENT ON TABLE chat_attachments IS 'Вложения (файлы и изображения) сообщений чата';
@@ -0,0 +1,17 @@
+-- ============================================
+-- История правок сообщений чата
+-- ============================================
+
+-- При каждой правке прежний текст сообщения сохраняется отдельной записью.
+-- created_at - когда эта версия была написана, replaced_at - когда ее заменила правка.
+CREATE TABLE IF NOT EXISTS chat_message_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    message_id BIGINT NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    replaced_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_revisions_message ON chat_message_revisions(message_id, id);
+
+COMMENT ON TABLE chat_message_revisions IS 'Предыдущие версии отредактированных сообщений чата';
@@ -0,0 +1,11 @@
+-- ============================================
+-- Личные сообщения в чате комнаты
+-- ============================================
+
+-- recipient_type - кому адресовано сообщение: room - всей комнате, participant - одному участнику
+-- (recipient_participant_id), hosts - только хосту и co-host. Личные сообщения видят отправитель и адресаты.
+ALTER TABLE chat_messages
+  ADD COLUMN IF NOT EXISTS recipient_type TEXT NOT NULL DEFAULT 'room' CHECK (recipient_type IN ('room','participant','hosts')),
+  ADD COLUMN IF NOT EXISTS recipient_participant_id UUID REFERENCES room_participants(id);
+
+CREATE INDEX IF NOT EXISTS idx_chat_recipient ON chat_messages(recipient_participant_id, created_at DESC) WHERE recipient_participant_id IS NOT NULL;
@@ -0,0 +1,65 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// Encryptor шифрует короткие секреты (ключи трансляций и т.п.) для хранения в БД.
+// AES-256-GCM, ключ выводится из секрета конфигурации через SHA-256.
+type Encryptor struct {
+	aead cipher.AEAD
+}
+
+func New(secret string) (*Encryptor, error) {
+	if secret == "" {
+		return nil, errors.New("encryption secret is empty")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Encryptor{aead: aead}, nil
+}
+
+// Encrypt возвращает base64(nonce || ciphertext)
+func (e *Encryptor) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := e.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (e *Encryptor) Decrypt(encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New("invalid ciphertext")
+	}
+
+	nonceSize := e.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("invalid ciphertext")
+	}
+
+	plaintext, err := e.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt")
+	}
+
+	return string(plaintext), nil
+}
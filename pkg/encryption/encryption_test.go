@@ -0,0 +1,87 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	enc, err := New("stream-key-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plaintext := range []string{"", "live_abc123", "ключ трансляции с юникодом"} {
+		encoded, err := enc.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): %v", plaintext, err)
+		}
+		decoded, err := enc.Decrypt(encoded)
+		if err != nil {
+			t.Fatalf("Decrypt(%q): %v", plaintext, err)
+		}
+		if decoded != plaintext {
+			t.Errorf("round trip = %q, want %q", decoded, plaintext)
+		}
+	}
+}
+
+func TestEncryptUsesRandomNonce(t *testing.T) {
+	enc, _ := New("stream-key-secret")
+
+	first, _ := enc.Encrypt("same key")
+	second, _ := enc.Encrypt("same key")
+	if first == second {
+		t.Error("two encryptions of the same plaintext are identical")
+	}
+}
+
+func TestDecryptRejectsTamperedData(t *testing.T) {
+	enc, _ := New("stream-key-secret")
+	encoded, _ := enc.Encrypt("live_abc123")
+	sealed, _ := base64.StdEncoding.DecodeString(encoded)
+
+	flip := func(i int) string {
+		tampered := append([]byte(nil), sealed...)
+		tampered[i] ^= 0x01
+		return base64.StdEncoding.EncodeToString(tampered)
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr string
+	}{
+		{name: "nonce", encoded: flip(0), wantErr: "failed to decrypt"},
+		{name: "ciphertext", encoded: flip(len(sealed) / 2), wantErr: "failed to decrypt"},
+		{name: "tag", encoded: flip(len(sealed) - 1), wantErr: "failed to decrypt"},
+		{name: "truncated", encoded: base64.StdEncoding.EncodeToString(sealed[:len(sealed)-1]), wantErr: "failed to decrypt"},
+		{name: "shorter than nonce", encoded: base64.StdEncoding.EncodeToString(sealed[:4]), wantErr: "invalid ciphertext"},
+		{name: "not base64", encoded: "%%%", wantErr: "invalid ciphertext"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := enc.Decrypt(tt.encoded)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Decrypt error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecryptWithOtherSecret(t *testing.T) {
+	enc, _ := New("stream-key-secret")
+	other, _ := New("another-secret")
+
+	encoded, _ := enc.Encrypt("live_abc123")
+	if _, err := other.Decrypt(encoded); err == nil {
+		t.Error("ciphertext decrypted with a different secret")
+	}
+}
+
+func TestNewRequiresSecret(t *testing.T) {
+	if _, err := New(""); err == nil {
+		t.Error("expected error for empty secret")
+	}
+}
@@ -0,0 +1,139 @@
+package ical
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Статусы событий (RFC 5545, 3.8.1.11)
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// Максимальная длина строки в октетах без CRLF (RFC 5545, 3.1)
+const maxLineOctets = 75
+
+// Calendar - объект VCALENDAR с набором событий
+type Calendar struct {
+	ProdID string
+	// Name - отображаемое имя календаря (X-WR-CALNAME), для подписок
+	Name string
+	// RefreshInterval - как часто клиенту перечитывать подписку, 0 - не указывать
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event - событие VEVENT. UID должен быть стабильным, а Sequence расти при каждом
+// изменении, чтобы календарь заменил ранее импортированное событие, а не создал новое.
+type Event struct {
+	UID          string
+	Sequence     int
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Location     string
+	URL          string
+	Status       string
+	Created      time.Time
+	LastModified time.Time
+}
+
+// Bytes сериализует календарь в формат text/calendar
+func (c *Calendar) Bytes() []byte {
+	var b strings.Builder
+	stamp := formatTime(time.Now())
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+c.ProdID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		interval := formatDuration(c.RefreshInterval)
+		writeLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:"+interval)
+		writeLine(&b, "X-PUBLISHED-TTL:"+interval)
+	}
+
+	for _, event := range c.Events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+event.UID)
+		writeLine(&b, "DTSTAMP:"+stamp)
+		writeLine(&b, "SEQUENCE:"+strconv.Itoa(event.Sequence))
+		writeLine(&b, "DTSTART:"+formatTime(event.Start))
+		writeLine(&b, "DTEND:"+formatTime(event.End))
+		writeLine(&b, "SUMMARY:"+escapeText(event.Summary))
+		if event.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		if event.Location != "" {
+			writeLine(&b, "LOCATION:"+escapeText(event.Location))
+		}
+		if event.URL != "" {
+			writeLine(&b, "URL:"+event.URL)
+		}
+		if event.Status != "" {
+			writeLine(&b, "STATUS:"+event.Status)
+		}
+		if !event.Created.IsZero() {
+			writeLine(&b, "CREATED:"+formatTime(event.Created))
+		}
+		if !event.LastModified.IsZero() {
+			writeLine(&b, "LAST-MODIFIED:"+formatTime(event.LastModified))
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// formatDuration - длительность в формате RFC 5545 с точностью до минут (например, PT15M)
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes < 1 {
+		minutes = 1
+	}
+	return "PT" + strconv.Itoa(minutes) + "M"
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// writeLine записывает строку с переносом длинных строк (folding) по границе UTF-8 символов
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		// Не разрываем многобайтовый символ
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Строка продолжения начинается с пробела, он входит в лимит
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
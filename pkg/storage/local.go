@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local - хранилище в локальном каталоге. Ссылки на скачивание ведут на API приложения
+// (baseURL) и подписываются HMAC-SHA256: ключ, имя файла и срок действия.
+type Local struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+func NewLocal(dir string, baseURL string, secret string) (*Local, error) {
+	if dir == "" {
+		return nil, errors.New("storage directory is empty")
+	}
+	if secret == "" {
+		return nil, errors.New("signing secret is empty")
+	}
+
+	return &Local{
+		dir:     filepath.Clean(dir),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  []byte(secret),
+	}, nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Пишем во временный файл рядом и переименовываем, чтобы не отдать недописанный объект
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("size mismatch: expected %d bytes, got %d", size, written)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) SignedURL(ctx context.Context, key string, fileName string, ttl time.Duration) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+
+	expires := time.Now().Add(ttl).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	if fileName != "" {
+		query.Set("name", fileName)
+	}
+	query.Set("signature", l.sign(key, fileName, expires))
+
+	return l.baseURL + "/" + escapePath(key) + "?" + query.Encode(), nil
+}
+
+// Open проверяет подпись ссылки из SignedURL и открывает объект
+func (l *Local) Open(key string, fileName string, expires int64, signature string) (*os.File, error) {
+	if time.Now().Unix() > expires {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(l.sign(key, fileName, expires))) {
+		return nil, ErrInvalidSignature
+	}
+
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+func (l *Local) sign(key string, fileName string, expires int64) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(key + "\n" + fileName + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// escapePath кодирует сегменты ключа, сохраняя разделители
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = escapeRFC3986(segment)
+	}
+	return strings.Join(segments, "/")
+}
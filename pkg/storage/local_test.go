@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const localBaseURL = "https://example.com/api/v1/files"
+
+func newTestLocal(t *testing.T) *Local {
+	t.Helper()
+
+	local, err := NewLocal(t.TempDir(), localBaseURL+"/", "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return local
+}
+
+// signedParams разбирает ссылку так же, как обработчик скачивания /api/v1/files/*key
+func signedParams(t *testing.T, link string) (key string, fileName string, expires int64, signature string) {
+	t.Helper()
+
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, ok := strings.CutPrefix(u.Path, "/api/v1/files/")
+	if !ok {
+		t.Fatalf("signed url %q is outside the files endpoint", link)
+	}
+	expires, err = strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, u.Query().Get("name"), expires, u.Query().Get("signature")
+}
+
+func TestLocalSignedURLRoundTrip(t *testing.T) {
+	local := newTestLocal(t)
+	ctx := context.Background()
+	key := "chat/room/файл (1).txt"
+
+	if err := local.Put(ctx, key, strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	link, err := local.SignedURL(ctx, key, "отчет.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	if !strings.HasPrefix(link, localBaseURL+"/chat/room/") {
+		t.Errorf("signed url = %q", link)
+	}
+
+	gotKey, fileName, expires, signature := signedParams(t, link)
+	if gotKey != key || fileName != "отчет.txt" {
+		t.Fatalf("signed url params: key %q name %q", gotKey, fileName)
+	}
+
+	file, err := local.Open(gotKey, fileName, expires, signature)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer file.Close()
+	if body, _ := io.ReadAll(file); string(body) != "hello" {
+		t.Errorf("object = %q, want %q", body, "hello")
+	}
+}
+
+func TestLocalOpenRejectsInvalidLinks(t *testing.T) {
+	local := newTestLocal(t)
+	ctx := context.Background()
+
+	if err := local.Put(ctx, "chat/a.txt", strings.NewReader("a"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.Put(ctx, "chat/b.txt", strings.NewReader("b"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := local.SignedURL(ctx, "chat/a.txt", "a.txt", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, fileName, expires, signature := signedParams(t, link)
+
+	expiredLink, err := local.SignedURL(ctx, "chat/a.txt", "a.txt", -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiredKey, expiredName, expiredAt, expiredSignature := signedParams(t, expiredLink)
+
+	otherSecret, err := NewLocal(t.TempDir(), localBaseURL, "other-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreignLink, _ := otherSecret.SignedURL(ctx, "chat/a.txt", "a.txt", time.Minute)
+	_, _, foreignExpires, foreignSignature := signedParams(t, foreignLink)
+
+	tests := []struct {
+		name      string
+		key       string
+		fileName  string
+		expires   int64
+		signature string
+	}{
+		{name: "expired", key: expiredKey, fileName: expiredName, expires: expiredAt, signature: expiredSignature},
+		{name: "tampered key", key: "chat/b.txt", fileName: fileName, expires: expires, signature: signature},
+		{name: "tampered name", key: key, fileName: "b.txt", expires: expires, signature: signature},
+		{name: "extended expiry", key: key, fileName: fileName, expires: expires + 3600, signature: signature},
+		{name: "tampered signature", key: key, fileName: fileName, expires: expires, signature: strings.Repeat("0", len(signature))},
+		{name: "empty signature", key: key, fileName: fileName, expires: expires},
+		{name: "signed with another secret", key: key, fileName: fileName, expires: foreignExpires, signature: foreignSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := local.Open(tt.key, tt.fileName, tt.expires, tt.signature)
+			if err == nil {
+				file.Close()
+			}
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Open error = %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+func TestLocalOpenMissingObject(t *testing.T) {
+	local := newTestLocal(t)
+	ctx := context.Background()
+
+	link, err := local.SignedURL(ctx, "chat/missing.txt", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := local.Open(signedParams(t, link)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestLocalRejectsInvalidKeys(t *testing.T) {
+	local := newTestLocal(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/etc/passwd", "chat/../secret", "chat//a", "chat\\a", "."} {
+		if _, err := local.SignedURL(ctx, key, "", time.Minute); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("SignedURL(%q) error = %v, want %v", key, err, ErrInvalidKey)
+		}
+		if err := local.Put(ctx, key, strings.NewReader(""), 0, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) error = %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+}
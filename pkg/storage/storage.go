@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("object not found")
+	ErrInvalidKey       = errors.New("invalid object key")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+// Storage - хранилище файлов. Ключ - путь объекта внутри хранилища вида "chat/<room>/<file>".
+type Storage interface {
+	// Put сохраняет объект; size - точный размер тела
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Delete удаляет объект; отсутствующий объект ошибкой не считается
+	Delete(ctx context.Context, key string) error
+	// SignedURL возвращает ссылку на скачивание, которая действует ttl.
+	// fileName подставляется в Content-Disposition ответа.
+	SignedURL(ctx context.Context, key string, fileName string, ttl time.Duration) (string, error)
+}
+
+// validKey запрещает пустые сегменты и выход за пределы хранилища
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// ContentDisposition - заголовок вложения с именем файла в ASCII и UTF-8 (RFC 6266)
+func ContentDisposition(fileName string) string {
+	if fileName == "" {
+		return "attachment"
+	}
+
+	ascii := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, fileName)
+
+	return `attachment; filename="` + ascii + `"; filename*=UTF-8''` + escapeRFC3986(fileName)
+}
+
+// escapeRFC3986 кодирует все, кроме незарезервированных символов (A-Z a-z 0-9 - _ . ~)
+func escapeRFC3986(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&15])
+	}
+	return b.String()
+}